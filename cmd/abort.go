@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// failFast stops the check at the first file that does not match or could not be checked, or at the first error
+var failFast bool
+
+const failFastUsage = "stop at the first file that does not match or could not be checked, or at the first error, and exit with code 1, like a CI pipeline verifying release artifacts wants"
+
+// stoppedByFailFast reports whether the check was stopped by --fail-fast
+var stoppedByFailFast atomic.Bool
+
+// maxErrors is the number of failures after which the run stops, 0 for no limit
+var maxErrors int
+
+const maxErrorsUsage = "stop after this number of files that could not be read and errors, like the thousands of a disconnected mount, printing the results collected until then and exiting with code 5 (default: no limit)"
+
+// maxErrorsExitCode is the exit code of a run stopped by --max-errors
+const maxErrorsExitCode = 5
+
+// failedFilesCount is the number of files of the run that could not be checked or hashed
+var failedFilesCount atomic.Int64
+
+// stoppedByMaxErrors reports whether files were left unprocessed because of --max-errors
+var stoppedByMaxErrors atomic.Bool
+
+// countFailedFile counts the file for --max-errors when it could not be read
+func countFailedFile(failed bool) {
+	if failed {
+		failedFilesCount.Add(1)
+	}
+}
+
+// failFastOn stops the check with --fail-fast when the file failed
+func failFastOn(failed bool) {
+	if failFast && failed {
+		stoppedByFailFast.Store(true)
+	}
+}
+
+// isRunStopped reports whether the run has to stop because of --fail-fast or --max-errors
+func isRunStopped(errorsList *[]error) bool {
+	if failFast {
+		outputMutex.Lock()
+		hasErrors := len(*errorsList) > 0
+		outputMutex.Unlock()
+		failFastOn(hasErrors)
+	}
+	return stoppedByFailFast.Load() || isMaxErrorsReached(errorsList)
+}
+
+// isMaxErrorsReached reports whether the run has to stop because of --max-errors, counting the files that could not
+// be read and the errors of the list, like the directories that could not be walked
+func isMaxErrorsReached(errorsList *[]error) bool {
+	if maxErrors <= 0 {
+		return false
+	}
+	outputMutex.Lock()
+	errorsQuantity := len(*errorsList)
+	outputMutex.Unlock()
+
+	if failedFilesCount.Load()+int64(errorsQuantity) < int64(maxErrors) {
+		return false
+	}
+	stoppedByMaxErrors.Store(true)
+	return true
+}
+
+// printStoppedByMaxErrors tells that the run was stopped by --max-errors
+func printStoppedByMaxErrors() {
+	fmt.Println()
+	fmt.Printf("🛑 : stopped after %d errors (--max-errors), the path may be unreachable or the disk failing\n", maxErrors)
+}
+
+// printStoppedByFailFast tells that the check was stopped by --fail-fast
+func printStoppedByFailFast() {
+	fmt.Println()
+	fmt.Println("🛑 : stopped at the first failure (--fail-fast)")
+}
@@ -0,0 +1,215 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// archiveManifestExtension is the extension of the manifests with the checksums of the members of the archives,
+// in the format of sha512sum whatever the extension of the checksum files is
+const archiveManifestExtension = ".members.sha512"
+
+var intoArchives bool
+
+// isArchive reports whether the file is a .tar, .tar.gz, .tgz or .zip archive whose members can be verified
+func isArchive(path string) bool {
+	lowerPath := strings.ToLower(path)
+	for _, extension := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(lowerPath, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// memberPath returns the path used to show a member of the archive
+func memberPath(archiveAbsolutePath string, member string) string {
+	return archiveAbsolutePath + "/" + member
+}
+
+// walkArchive calls the function with the name and the content of each regular file of the archive, in the archive order
+func walkArchive(archiveAbsolutePath string, walkFunction func(member string, reader io.Reader) error) error {
+	if strings.HasSuffix(strings.ToLower(archiveAbsolutePath), ".zip") {
+		zipReader, err := zip.OpenReader(archiveAbsolutePath)
+		if err != nil {
+			return err
+		}
+		defer zipReader.Close()
+
+		for _, zipFile := range zipReader.File {
+			if zipFile.FileInfo().IsDir() {
+				continue
+			}
+
+			reader, err := zipFile.Open()
+			if err != nil {
+				return err
+			}
+			err = walkFunction(zipFile.Name, reader)
+			reader.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := openDataFile(archiveAbsolutePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	lowerPath := strings.ToLower(archiveAbsolutePath)
+	if strings.HasSuffix(lowerPath, ".gz") || strings.HasSuffix(lowerPath, ".tgz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := walkFunction(header.Name, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// createArchiveManifest stores the checksums of the members of the archive in its members manifest, signing it
+// with --sign
+func createArchiveManifest(archiveAbsolutePath string) ChecksumFileCreationResult {
+	manifestPath := archiveAbsolutePath + archiveManifestExtension
+
+	if _, err := os.Stat(manifestPath); err == nil {
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Existing, Error: nil}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+
+	var manifest strings.Builder
+	if writeHeader {
+		manifest.WriteString(metadataHeader(sha512Algorithm.Name))
+	}
+	err := walkArchive(archiveAbsolutePath, func(member string, reader io.Reader) error {
+		if strings.ContainsAny(member, "\n\r") {
+			return fmt.Errorf("the name of the member %q is not supported", member)
+		}
+
+		hexMemberChecksum, err := hashReader(reader)
+		if err != nil {
+			return fmt.Errorf("%s: %w", member, err)
+		}
+
+		manifest.WriteString(hexMemberChecksum + "  " + member + "\n")
+		return nil
+	})
+	if err != nil {
+		if os.IsPermission(err) {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: LockedCreation, Error: err}
+		}
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+
+	if err := os.WriteFile(manifestPath, []byte(manifest.String()), 0o644); err != nil {
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+	if signChecksumFiles {
+		if err := signFile(manifestPath, signingKey); err != nil {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+		}
+	}
+
+	return ChecksumFileCreationResult{Path: manifestPath, Status: Created, Error: nil}
+}
+
+// checkArchiveMembers compares the members of the archive with the checksums of its members manifest, when it has one
+func checkArchiveMembers(archiveAbsolutePath string, results *[]ChecksumFileVerificationResult) {
+	manifestPath := archiveAbsolutePath + archiveManifestExtension
+
+	content, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		appendLocked(results, ChecksumFileVerificationResult{Path: manifestPath, Status: CheckingFailed, Error: err})
+		return
+	}
+
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		appendLocked(results, ChecksumFileVerificationResult{Path: manifestPath, Status: CheckingFailed, Error: err})
+		return
+	}
+
+	expected := make(map[string]manifestEntry, len(entries))
+	for _, entry := range entries {
+		expected[entry.Path] = entry
+	}
+
+	err = walkArchive(archiveAbsolutePath, func(member string, reader io.Reader) error {
+		verificationReporter(memberPath(archiveAbsolutePath, member), results, func(path string) ChecksumFileVerificationResult {
+			entry, ok := expected[member]
+			if !ok {
+				return ChecksumFileVerificationResult{Path: path, Status: NotFound, Error: nil}
+			}
+			delete(expected, member)
+
+			hexMemberChecksum, err := hashReaderWith(reader, entry.Algorithm)
+			if err != nil {
+				return ChecksumFileVerificationResult{Path: path, Status: CheckingFailed, Error: err}
+			}
+
+			if strings.EqualFold(hexMemberChecksum, entry.Checksum) {
+				return ChecksumFileVerificationResult{Path: path, Status: Match, Error: nil}
+			}
+			return ChecksumFileVerificationResult{Path: path, Status: NotMatch, Error: nil}
+		})
+		return nil
+	})
+	if err != nil {
+		appendLocked(results, ChecksumFileVerificationResult{Path: archiveAbsolutePath, Status: CheckingFailed, Error: err})
+		return
+	}
+
+	for _, entry := range entries {
+		if _, missing := expected[entry.Path]; missing {
+			appendLocked(results, ChecksumFileVerificationResult{Path: memberPath(archiveAbsolutePath, entry.Path), Status: CheckingFailed, Error: errors.New("member not found in the archive")})
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveManifest_Zip(t *testing.T) {
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "photos.zip")
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	zipWriter := zip.NewWriter(file)
+	for name, content := range map[string]string{"a.raw": "a", "2024/b.raw": "b"} {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatalf("create member: %v", err)
+		}
+		writer.Write([]byte(content))
+	}
+	zipWriter.Close()
+	file.Close()
+
+	if result := createArchiveManifest(archivePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	var results []ChecksumFileVerificationResult
+	checkArchiveMembers(archivePath, &results)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, result := range results {
+		if result.Status != Match {
+			t.Fatalf("expected status %s, got %s for %s (%v)", Match, result.Status, result.Path, result.Error)
+		}
+	}
+}
+
+func TestArchiveManifest_TarNotMatch(t *testing.T) {
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "backup.tar")
+
+	writeTar := func(content string) {
+		file, err := os.Create(archivePath)
+		if err != nil {
+			t.Fatalf("create archive: %v", err)
+		}
+		tarWriter := tar.NewWriter(file)
+		tarWriter.WriteHeader(&tar.Header{Name: "budget.xlsx", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tarWriter.Write([]byte(content))
+		tarWriter.Close()
+		file.Close()
+	}
+
+	writeTar("budget")
+	if result := createArchiveManifest(archivePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	writeTar("BUDGET")
+	var results []ChecksumFileVerificationResult
+	checkArchiveMembers(archivePath, &results)
+	if len(results) != 1 || results[0].Status != NotMatch {
+		t.Fatalf("expected a %s result, got %+v", NotMatch, results)
+	}
+	if results[0].Path != memberPath(archivePath, "budget.xlsx") {
+		t.Fatalf("unexpected path %s", results[0].Path)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPreservingAccessTime opens the file for reading without updating its access time, so the verification sweeps
+// do not defeat the tiering based on access times. O_NOATIME is only allowed to the owner of the file, so the other
+// files are opened as usual.
+func openPreservingAccessTime(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|unix.O_NOATIME, 0)
+	if errors.Is(err, unix.EPERM) {
+		return os.Open(path)
+	}
+	return file, err
+}
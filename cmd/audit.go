@@ -0,0 +1,328 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errorsAuditingFiles []error
+var resultsAuditingFiles []AuditResult
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Compare the files with the catalog.",
+	Long: `Compare the files with the catalog and report the new, deleted and modified files,
+and the files whose content changed while their size and modification time remain the same (silently corrupted).
+
+Example:
+  checksum-utils audit /mnt/external-disk
+  checksum-utils audit --catalog /volume1/catalog.db /volume1/photos
+`,
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completePaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		printHeader()
+
+		c, err := openCatalog(catalogPath)
+		if err != nil {
+			errorsAuditingFiles = append(errorsAuditingFiles, err)
+			printErrorsAuditingFiles()
+			return
+		}
+		defer c.Close()
+
+		paths, expandErrors, _ := gatherPaths(args)
+		errorsAuditingFiles = append(errorsAuditingFiles, expandErrors...)
+		if len(paths) == 0 {
+			printErrorsAuditingFiles()
+			return
+		}
+
+		fmt.Println()
+		fmt.Println("Catalog", catalogPath)
+		fmt.Printf("Processing %d paths\n", len(paths))
+
+		resultsAuditingFiles = []AuditResult{}
+		seen := make(map[string]bool)
+		processPaths(paths, &errorsAuditingFiles, func(filePath string) error {
+			return handleFileAudit(c, filePath, seen, &resultsAuditingFiles)
+		})
+
+		for _, path := range paths {
+			deletedResults, err := findDeletedFiles(c, path, seen)
+			if err != nil {
+				errorsAuditingFiles = append(errorsAuditingFiles, err)
+				continue
+			}
+			for _, result := range deletedResults {
+				fmt.Printf("- %s 🗑️\n", result.Path)
+				if err := recordVerification(c, result.Path, "audit", string(result.Status), auditVerificationOutcome(result.Status), result.Error); err != nil {
+					errorsAuditingFiles = append(errorsAuditingFiles, err)
+				}
+			}
+			resultsAuditingFiles = append(resultsAuditingFiles, deletedResults...)
+		}
+
+		printResultsAuditingFiles(resultsAuditingFiles)
+		printErrorsAuditingFiles()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVar(&catalogPath, "catalog", defaultCatalogPath(), "path of the catalog database")
+	auditCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	auditCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	auditCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	auditCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	auditCmd.Flags().BoolVarP(&oneFileSystem, "one-file-system", "x", false, oneFileSystemUsage)
+	auditCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	auditCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+}
+
+type AuditStatus string
+
+const (
+	AuditUnchanged AuditStatus = "Unchanged"
+	AuditNew       AuditStatus = "New"
+	AuditDeleted   AuditStatus = "Deleted"
+	AuditModified  AuditStatus = "Modified"
+	AuditCorrupted AuditStatus = "Corrupted"
+	AuditFailed    AuditStatus = "Failed"
+	AuditLocked    AuditStatus = "Locked"
+)
+
+type AuditResult struct {
+	Path   string
+	Status AuditStatus
+	Error  error
+}
+
+func handleFileAudit(c *catalog, filePath string, seen map[string]bool, results *[]AuditResult) error {
+	fileAbsolutePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	if isChecksumFile(fileAbsolutePath) {
+		return nil
+	}
+	seen[fileAbsolutePath] = true
+
+	prefix := fmt.Sprintf("- %s ", fileAbsolutePath)
+	spinner := startProgress(prefix)
+	start := time.Now()
+	result := auditFile(c, fileAbsolutePath)
+	elapsed := time.Since(start)
+	spinner.Stop()
+
+	*results = append(*results, result)
+
+	if err := recordVerification(c, fileAbsolutePath, "audit", string(result.Status), auditVerificationOutcome(result.Status), result.Error); err != nil {
+		errorsAuditingFiles = append(errorsAuditingFiles, err)
+	}
+
+	if spinner.Enabled() {
+		clearProgressLine(prefix)
+	} else {
+		fmt.Print(prefix)
+	}
+	switch result.Status {
+	case AuditUnchanged:
+		fmt.Print("✅")
+	case AuditNew:
+		fmt.Print("🆕")
+	case AuditModified:
+		fmt.Print("✏️")
+	case AuditCorrupted:
+		fmt.Print("⚠️")
+	case AuditLocked:
+		fmt.Print("🔒")
+	case AuditFailed:
+		fmt.Print("❌")
+	}
+
+	if result.Status == AuditUnchanged || result.Status == AuditCorrupted || result.Status == AuditFailed {
+		fmt.Printf(" (%s)", formatDuration(elapsed))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// auditFile compares the file with its entry in the catalog. The content is hashed only when the size and
+// modification time are the same.
+func auditFile(c *catalog, fileAbsolutePath string) AuditResult {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditFailed, Error: err}
+	}
+
+	entry, err := c.Get(fileAbsolutePath)
+	if err != nil {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditFailed, Error: err}
+	}
+
+	if entry == nil {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditNew, Error: nil}
+	}
+
+	if entry.Size != fileInfo.Size() || !entry.ModTime.Equal(fileInfo.ModTime()) {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditModified, Error: nil}
+	}
+
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		if os.IsPermission(err) {
+			return AuditResult{Path: fileAbsolutePath, Status: AuditLocked, Error: err}
+		}
+		return AuditResult{Path: fileAbsolutePath, Status: AuditFailed, Error: err}
+	}
+	defer file.Close()
+
+	hexFileChecksum, err := hashReader(file)
+	if err != nil {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditFailed, Error: err}
+	}
+
+	if !strings.EqualFold(hexFileChecksum, entry.Digest) {
+		return AuditResult{Path: fileAbsolutePath, Status: AuditCorrupted, Error: nil}
+	}
+
+	return AuditResult{Path: fileAbsolutePath, Status: AuditUnchanged, Error: nil}
+}
+
+// auditVerificationOutcome classifies the audit status for the verification history
+func auditVerificationOutcome(status AuditStatus) verificationOutcome {
+	switch status {
+	case AuditUnchanged:
+		return verificationPassed
+	case AuditCorrupted, AuditFailed:
+		return verificationFailed
+	}
+	return verificationInconclusive
+}
+
+// findDeletedFiles returns the cataloged files inside the path that were not seen during the walk and no longer exist
+func findDeletedFiles(c *catalog, path string, seen map[string]bool) ([]AuditResult, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.List(absolutePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []AuditResult
+	for _, entry := range entries {
+		if seen[entry.Path] {
+			continue
+		}
+		if _, err := os.Lstat(entry.Path); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		seen[entry.Path] = true
+		results = append(results, AuditResult{Path: entry.Path, Status: AuditDeleted, Error: nil})
+	}
+
+	return results, nil
+}
+
+func printResultsAuditingFiles(results []AuditResult) {
+	if len(results) > 0 {
+		fmt.Println("Results:", len(results), "files processed")
+	}
+
+	var unchangedQuantity = 0
+	var newResults []AuditResult
+	var deletedResults []AuditResult
+	var modifiedResults []AuditResult
+	var corruptedResults []AuditResult
+	var lockedResults []AuditResult
+	var failedResults []AuditResult
+
+	for _, result := range results {
+		switch result.Status {
+		case AuditUnchanged:
+			unchangedQuantity++
+		case AuditNew:
+			newResults = append(newResults, result)
+		case AuditDeleted:
+			deletedResults = append(deletedResults, result)
+		case AuditModified:
+			modifiedResults = append(modifiedResults, result)
+		case AuditCorrupted:
+			corruptedResults = append(corruptedResults, result)
+		case AuditLocked:
+			lockedResults = append(lockedResults, result)
+		case AuditFailed:
+			failedResults = append(failedResults, result)
+		}
+	}
+
+	if unchangedQuantity > 0 {
+		fmt.Println("✅ :", unchangedQuantity, "files match the catalog")
+	}
+
+	printAuditResultsGroup("🆕 :", "files not in the catalog", newResults)
+	printAuditResultsGroup("🗑️ :", "cataloged files deleted", deletedResults)
+	printAuditResultsGroup("✏️ :", "files modified, their size or modification time changed", modifiedResults)
+	printAuditResultsGroup("⚠️ :", "files silently corrupted, their content changed but their size and modification time did not", corruptedResults)
+	printAuditResultsGroup("🔒 :", "files could not be read due to permissions", lockedResults)
+
+	if len(failedResults) > 0 {
+		fmt.Println("❌ :", len(failedResults), "files failed to audit")
+		for _, failedResult := range failedResults {
+			fmt.Print("- ", failedResult.Path, " | Error: ", failedResult.Error)
+			fmt.Println()
+		}
+	}
+}
+
+func printAuditResultsGroup(icon string, description string, results []AuditResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println(icon, len(results), description)
+	for _, result := range results {
+		fmt.Print("- ", result.Path)
+		fmt.Println()
+	}
+}
+
+func printErrorsAuditingFiles() {
+	if len(errorsAuditingFiles) > 0 {
+		fmt.Println()
+		fmt.Println("Errors:")
+
+		for _, error := range errorsAuditingFiles {
+			fmt.Println("- ", error)
+		}
+	}
+}
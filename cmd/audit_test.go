@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditFile(t *testing.T) {
+	tempDir := t.TempDir()
+	unchangedPath := filepath.Join(tempDir, "unchanged.txt")
+	corruptedPath := filepath.Join(tempDir, "corrupted.txt")
+	deletedPath := filepath.Join(tempDir, "deleted.txt")
+
+	for _, path := range []string{unchangedPath, corruptedPath, deletedPath} {
+		if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	c, err := openCatalog(filepath.Join(t.TempDir(), "catalog.db"))
+	if err != nil {
+		t.Fatalf("open catalog: %v", err)
+	}
+	defer c.Close()
+
+	for _, path := range []string{unchangedPath, corruptedPath, deletedPath} {
+		if result := updateCatalogEntry(c, path); result.Status != CatalogAdded {
+			t.Fatalf("expected status %s, got %s (%v)", CatalogAdded, result.Status, result.Error)
+		}
+	}
+
+	// Same size and modification time, different content
+	fileInfo, err := os.Stat(corruptedPath)
+	if err != nil {
+		t.Fatalf("stat file: %v", err)
+	}
+	if err := os.WriteFile(corruptedPath, []byte("jello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Chtimes(corruptedPath, fileInfo.ModTime(), fileInfo.ModTime()); err != nil {
+		t.Fatalf("change times: %v", err)
+	}
+
+	if err := os.Remove(deletedPath); err != nil {
+		t.Fatalf("remove file: %v", err)
+	}
+
+	newPath := filepath.Join(tempDir, "new.txt")
+	if err := os.WriteFile(newPath, []byte("new"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	expected := map[string]AuditStatus{
+		unchangedPath: AuditUnchanged,
+		corruptedPath: AuditCorrupted,
+		newPath:       AuditNew,
+	}
+	seen := make(map[string]bool)
+	for path, status := range expected {
+		seen[path] = true
+		if result := auditFile(c, path); result.Status != status {
+			t.Fatalf("%s: expected status %s, got %s (%v)", path, status, result.Status, result.Error)
+		}
+	}
+
+	deletedResults, err := findDeletedFiles(c, tempDir, seen)
+	if err != nil {
+		t.Fatalf("find deleted files: %v", err)
+	}
+	if len(deletedResults) != 1 || deletedResults[0].Path != deletedPath || deletedResults[0].Status != AuditDeleted {
+		t.Fatalf("expected %s to be deleted, got %+v", deletedPath, deletedResults)
+	}
+}
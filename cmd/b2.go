@@ -0,0 +1,438 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// b2Scheme is the prefix of the paths of files in a Backblaze B2 bucket, like b2://bucket/prefix
+const b2Scheme = "b2://"
+
+// b2APIURL is the URL of the authorization of the native API of Backblaze B2
+var b2APIURL = "https://api.backblazeb2.com"
+
+// b2SHA512Info is the name of the file info of B2 holding the checksum stored by create --remote-copy
+const b2SHA512Info = "sha512"
+
+// b2LargeFileSHA1Info is the file info holding the SHA1 of a large file, which B2 does not compute itself,
+// set by the tools that upload large files like rclone and the b2 command
+const b2LargeFileSHA1Info = "large_file_sha1"
+
+// b2MaxCopySize is the size of the largest file that b2_copy_file can copy, larger files are copied in parts
+const b2MaxCopySize = 5_000_000_000
+
+// b2CopyPartSize is the size of the parts of the copies of the large files
+const b2CopyPartSize = 1 << 30
+
+// b2SHA1Algorithm is the algorithm of the checksums computed by B2 for the files uploaded to it
+var b2SHA1Algorithm = hashAlgorithm{Name: "sha1", New: sha1.New}
+
+// b2Client is a minimal client of the native API of Backblaze B2
+type b2Client struct {
+	APIURL             string
+	DownloadURL        string
+	AccountID          string
+	AuthorizationToken string
+	HTTPClient         *http.Client
+}
+
+// b2File is a file listed in a bucket
+type b2File struct {
+	FileID        string            `json:"fileId"`
+	FileName      string            `json:"fileName"`
+	ContentLength int64             `json:"contentLength"`
+	ContentSHA1   string            `json:"contentSha1"`
+	ContentType   string            `json:"contentType"`
+	FileInfo      map[string]string `json:"fileInfo"`
+	Action        string            `json:"action"`
+}
+
+// SHA1 returns the hexadecimal SHA1 checksum of the content of the file known by B2, or an empty string
+// when it is unknown, like for large files uploaded without the large_file_sha1 file info
+func (f b2File) SHA1() string {
+	if checksum := strings.TrimPrefix(f.ContentSHA1, "unverified:"); checksum != "" && checksum != "none" {
+		return checksum
+	}
+	return f.FileInfo[b2LargeFileSHA1Info]
+}
+
+// newB2Client authorizes the application key of $B2_APPLICATION_KEY_ID and $B2_APPLICATION_KEY
+func newB2Client() (*b2Client, error) {
+	keyID, key := os.Getenv("B2_APPLICATION_KEY_ID"), os.Getenv("B2_APPLICATION_KEY")
+	if keyID == "" || key == "" {
+		return nil, errors.New("B2_APPLICATION_KEY_ID and B2_APPLICATION_KEY are required for b2:// paths")
+	}
+
+	request, err := http.NewRequest(http.MethodGet, b2APIURL+"/b2api/v2/b2_authorize_account", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(keyID, key)
+
+	var authorization struct {
+		AccountID          string `json:"accountId"`
+		AuthorizationToken string `json:"authorizationToken"`
+		APIURL             string `json:"apiUrl"`
+		DownloadURL        string `json:"downloadUrl"`
+	}
+	if err := doB2Request(http.DefaultClient, request, &authorization); err != nil {
+		return nil, fmt.Errorf("the B2 application key could not be authorized: %w", err)
+	}
+
+	return &b2Client{
+		APIURL:             authorization.APIURL,
+		DownloadURL:        authorization.DownloadURL,
+		AccountID:          authorization.AccountID,
+		AuthorizationToken: authorization.AuthorizationToken,
+		HTTPClient:         http.DefaultClient,
+	}, nil
+}
+
+// call calls the operation of the API with the request as JSON, decoding its JSON response into response
+func (c *b2Client) call(operation string, request any, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, c.APIURL+"/b2api/v2/"+operation, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Authorization", c.AuthorizationToken)
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	if err := doB2Request(c.HTTPClient, httpRequest, response); err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	return nil
+}
+
+// doB2Request sends the request and decodes its JSON response into response, or returns the error of B2
+func doB2Request(client *http.Client, request *http.Request, response any) error {
+	httpResponse, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		var b2Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(httpResponse.Body).Decode(&b2Error) == nil && b2Error.Code != "" {
+			return fmt.Errorf("%s: %s", b2Error.Code, b2Error.Message)
+		}
+		return errors.New(httpResponse.Status)
+	}
+
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(httpResponse.Body).Decode(response)
+}
+
+// BucketID returns the ID of the bucket with the name
+func (c *b2Client) BucketID(bucket string) (string, error) {
+	var response struct {
+		Buckets []struct {
+			BucketID   string `json:"bucketId"`
+			BucketName string `json:"bucketName"`
+		} `json:"buckets"`
+	}
+	if err := c.call("b2_list_buckets", map[string]string{"accountId": c.AccountID, "bucketName": bucket}, &response); err != nil {
+		return "", err
+	}
+
+	for _, b := range response.Buckets {
+		if b.BucketName == bucket {
+			return b.BucketID, nil
+		}
+	}
+	return "", fmt.Errorf("the bucket %s does not exist", bucket)
+}
+
+// ListFiles returns the latest version of the files of the bucket whose name starts with the prefix
+func (c *b2Client) ListFiles(bucketID string, prefix string) ([]b2File, error) {
+	var files []b2File
+	startFileName := ""
+
+	for {
+		var response struct {
+			Files        []b2File `json:"files"`
+			NextFileName *string  `json:"nextFileName"`
+		}
+		request := map[string]any{"bucketId": bucketID, "prefix": prefix, "maxFileCount": 1000}
+		if startFileName != "" {
+			request["startFileName"] = startFileName
+		}
+		if err := c.call("b2_list_file_names", request, &response); err != nil {
+			return nil, err
+		}
+
+		for _, file := range response.Files {
+			if file.Action == "" || file.Action == "upload" {
+				files = append(files, file)
+			}
+		}
+
+		if response.NextFileName == nil || *response.NextFileName == "" {
+			return files, nil
+		}
+		startFileName = *response.NextFileName
+	}
+}
+
+// DownloadFile returns the content of the file of the bucket
+func (c *b2Client) DownloadFile(bucket string, name string) (io.ReadCloser, error) {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, c.DownloadURL+"/file/"+url.PathEscape(bucket)+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", c.AuthorizationToken)
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s: %s", b2URL(bucket, name), response.Status)
+	}
+
+	return response.Body, nil
+}
+
+// SetFileInfo replaces the file info of the file with a server side copy of it, since the file info of a version
+// cannot be changed. Files larger than 5 GB are copied in parts, as b2_copy_file does not copy them.
+func (c *b2Client) SetFileInfo(bucketID string, file b2File, info map[string]string) error {
+	if file.ContentLength <= b2MaxCopySize {
+		return c.call("b2_copy_file", map[string]any{
+			"sourceFileId":      file.FileID,
+			"fileName":          file.FileName,
+			"metadataDirective": "REPLACE",
+			"contentType":       file.ContentType,
+			"fileInfo":          info,
+		}, nil)
+	}
+
+	var largeFile struct {
+		FileID string `json:"fileId"`
+	}
+	if err := c.call("b2_start_large_file", map[string]any{
+		"bucketId":    bucketID,
+		"fileName":    file.FileName,
+		"contentType": file.ContentType,
+		"fileInfo":    info,
+	}, &largeFile); err != nil {
+		return err
+	}
+
+	var partSHA1s []string
+	for start := int64(0); start < file.ContentLength; start += b2CopyPartSize {
+		end := min(start+b2CopyPartSize, file.ContentLength) - 1
+
+		var part struct {
+			ContentSHA1 string `json:"contentSha1"`
+		}
+		if err := c.call("b2_copy_part", map[string]any{
+			"sourceFileId": file.FileID,
+			"largeFileId":  largeFile.FileID,
+			"partNumber":   len(partSHA1s) + 1,
+			"range":        fmt.Sprintf("bytes=%d-%d", start, end),
+		}, &part); err != nil {
+			c.call("b2_cancel_large_file", map[string]string{"fileId": largeFile.FileID}, nil)
+			return err
+		}
+		partSHA1s = append(partSHA1s, part.ContentSHA1)
+	}
+
+	if err := c.call("b2_finish_large_file", map[string]any{"fileId": largeFile.FileID, "partSha1Array": partSHA1s}, nil); err != nil {
+		c.call("b2_cancel_large_file", map[string]string{"fileId": largeFile.FileID}, nil)
+		return err
+	}
+	return nil
+}
+
+// parseB2URL returns the bucket and the file name prefix of a URL like b2://bucket/prefix
+func parseB2URL(rawURL string) (string, string, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(rawURL, b2Scheme), "/")
+	if !strings.HasPrefix(rawURL, b2Scheme) || bucket == "" {
+		return "", "", fmt.Errorf("%s is not a valid b2:// URL, like b2://bucket/prefix", rawURL)
+	}
+
+	return bucket, strings.TrimSuffix(prefix, "/"), nil
+}
+
+// b2URL returns the b2:// URL of the file
+func b2URL(bucket string, name string) string {
+	return b2Scheme + bucket + "/" + name
+}
+
+// b2Location is the folder of a bucket holding the copy of a local directory, with its files listed once
+type b2Location struct {
+	Client   *b2Client
+	Bucket   string
+	BucketID string
+	Prefix   string
+	Files    map[string]b2File
+}
+
+// openB2Location authorizes the application key and lists the files inside the b2:// URL
+func openB2Location(rawURL string) (*b2Location, error) {
+	bucket, prefix, err := parseB2URL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newB2Client()
+	if err != nil {
+		return nil, err
+	}
+
+	bucketID, err := c.BucketID(bucket)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rawURL, err)
+	}
+
+	location := &b2Location{Client: c, Bucket: bucket, BucketID: bucketID, Prefix: prefix, Files: map[string]b2File{}}
+	listPrefix := location.fileName("")
+	files, err := c.ListFiles(bucketID, listPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rawURL, err)
+	}
+	for _, file := range files {
+		location.Files[file.FileName] = file
+	}
+
+	return location, nil
+}
+
+// fileName returns the name in the bucket of the file, relative to the folder
+func (l *b2Location) fileName(file string) string {
+	if l.Prefix == "" {
+		return file
+	}
+	return l.Prefix + "/" + file
+}
+
+// URL returns the b2:// URL of the file, relative to the folder
+func (l *b2Location) URL(file string) string {
+	return b2URL(l.Bucket, l.fileName(file))
+}
+
+// checkCopy compares the copy of the local file with the checksum of the local file. When B2 knows the SHA1 of
+// the copy, the local file is hashed instead of downloading the copy, since B2 verified the SHA1 of the content it
+// received and keeps verifying it. The checksum stored in the file info of the copy by create must match too.
+func (l *b2Location) checkCopy(file string, localFileAbsolutePath string) ChecksumFileVerificationResult {
+	fileURL := l.URL(file)
+
+	checksum, failed := localChecksumOfCopy(fileURL, localFileAbsolutePath)
+	if failed != nil {
+		return *failed
+	}
+
+	remoteFile, ok := l.Files[l.fileName(file)]
+	if !ok {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: fmt.Errorf("the copy of %s does not exist", localFileAbsolutePath)}
+	}
+
+	if stored := remoteFile.FileInfo[b2SHA512Info]; stored != "" && !strings.EqualFold(stored, checksum) {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("the %s file info of the copy does not match the checksum of %s", b2SHA512Info, localFileAbsolutePath)}
+	}
+
+	if copySHA1 := remoteFile.SHA1(); copySHA1 != "" {
+		checksums, err := hashPathWithAll(localFileAbsolutePath, []hashAlgorithm{sha512Algorithm, b2SHA1Algorithm})
+		if err != nil {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+		}
+		if !strings.EqualFold(checksums[sha512Algorithm.Name], checksum) {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("%s does not match its checksum, so its copy cannot be verified", localFileAbsolutePath)}
+		}
+		if !strings.EqualFold(checksums[b2SHA1Algorithm.Name], copySHA1) {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("the SHA1 of the copy does not match %s", localFileAbsolutePath)}
+		}
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Match, Error: nil}
+	}
+
+	content, err := l.Client.DownloadFile(l.Bucket, remoteFile.FileName)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+	defer content.Close()
+
+	hexFileChecksum, err := hashReader(content)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+
+	if strings.EqualFold(hexFileChecksum, checksum) {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Match, Error: nil}
+	}
+
+	return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("the copy does not match the checksum of %s", localFileAbsolutePath)}
+}
+
+// storeChecksumOfCopy stores the checksum of the local file in the sha512 file info of its copy, once the copy
+// is verified, keeping the rest of its file info
+func (l *b2Location) storeChecksumOfCopy(file string, localFileAbsolutePath string) ChecksumFileCreationResult {
+	fileURL := l.URL(file)
+
+	remoteFile, ok := l.Files[l.fileName(file)]
+	checksum, failed := localChecksumOfCopy(fileURL, localFileAbsolutePath)
+	if failed != nil && failed.Status == NotFound {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: fmt.Errorf("%s does not have a checksum file, create it first", localFileAbsolutePath)}
+	}
+	if failed == nil && ok && strings.EqualFold(remoteFile.FileInfo[b2SHA512Info], checksum) {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Existing, Error: nil}
+	}
+
+	if result := l.checkCopy(file, localFileAbsolutePath); result.Status != Match {
+		err := result.Error
+		if err == nil {
+			err = fmt.Errorf("the copy could not be verified: %s", result.Status)
+		}
+		return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+	}
+
+	info := make(map[string]string, len(remoteFile.FileInfo)+1)
+	for name, value := range remoteFile.FileInfo {
+		info[name] = value
+	}
+	info[b2SHA512Info] = strings.ToLower(checksum)
+
+	if err := l.Client.SetFileInfo(l.BucketID, remoteFile, info); err != nil {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+	}
+
+	return ChecksumFileCreationResult{Path: fileURL, Status: Created, Error: nil}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseB2URL(t *testing.T) {
+	bucket, prefix, err := parseB2URL("b2://offsite-backup/photos/2024/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bucket != "offsite-backup" || prefix != "photos/2024" {
+		t.Fatalf("unexpected bucket %q and prefix %q", bucket, prefix)
+	}
+
+	if _, _, err := parseB2URL("b2://"); err == nil {
+		t.Fatalf("expected error for a URL without bucket")
+	}
+}
+
+func TestB2RemoteCopy(t *testing.T) {
+	sha1Of := func(content string) string {
+		sum := sha1.Sum([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	// The copies in the bucket: a.raw with its SHA1, b.raw corrupted and c.raw a large file without SHA1
+	var mutex sync.Mutex
+	contents := map[string]string{"photos/a.raw": "raw a", "photos/sub/b.raw": "corrupted", "photos/c.raw": "raw c"}
+	files := map[string]b2File{
+		"photos/a.raw":     {FileID: "1", FileName: "photos/a.raw", ContentSHA1: sha1Of("raw a"), FileInfo: map[string]string{"src_last_modified_millis": "1"}},
+		"photos/sub/b.raw": {FileID: "2", FileName: "photos/sub/b.raw", ContentSHA1: sha1Of("corrupted"), FileInfo: map[string]string{}},
+		"photos/c.raw":     {FileID: "3", FileName: "photos/c.raw", ContentSHA1: "none", FileInfo: map[string]string{}},
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.URL.Path == "/b2api/v2/b2_authorize_account" {
+			if keyID, key, _ := r.BasicAuth(); keyID != "key-id" || key != "key" {
+				http.Error(w, `{"code":"unauthorized","message":"bad key"}`, http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"accountId": "account", "authorizationToken": "token", "apiUrl": server.URL, "downloadUrl": server.URL})
+			return
+		}
+		if r.Header.Get("Authorization") != "token" {
+			http.Error(w, `{"code":"bad_auth_token","message":"bad token"}`, http.StatusUnauthorized)
+			return
+		}
+
+		var request map[string]any
+		json.NewDecoder(r.Body).Decode(&request)
+		switch r.URL.Path {
+		case "/b2api/v2/b2_list_buckets":
+			json.NewEncoder(w).Encode(map[string]any{"buckets": []map[string]string{{"bucketId": "bucket-id", "bucketName": "offsite-backup"}}})
+		case "/b2api/v2/b2_list_file_names":
+			var listed []b2File
+			for name, file := range files {
+				if strings.HasPrefix(name, request["prefix"].(string)) {
+					listed = append(listed, file)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"files": listed, "nextFileName": nil})
+		case "/b2api/v2/b2_copy_file":
+			source := request["sourceFileId"].(string)
+			for name, file := range files {
+				if file.FileID == source {
+					file.FileID += "-copy"
+					file.FileInfo = map[string]string{}
+					for key, value := range request["fileInfo"].(map[string]any) {
+						file.FileInfo[key] = value.(string)
+					}
+					files[name] = file
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]string{})
+		default:
+			content, ok := contents[strings.TrimPrefix(r.URL.Path, "/file/offsite-backup/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, content)
+		}
+	}))
+	defer server.Close()
+
+	b2APIURL = server.URL
+	defer func() { b2APIURL = "https://api.backblazeb2.com" }()
+	t.Setenv("B2_APPLICATION_KEY_ID", "key-id")
+	t.Setenv("B2_APPLICATION_KEY", "key")
+
+	localDir := t.TempDir()
+	for name, content := range map[string]string{"a.raw": "raw a", "sub/b.raw": "raw b", "c.raw": "raw c"} {
+		localPath := filepath.Join(localDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		if err := os.WriteFile(localPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(localPath); result.Status != Created {
+			t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+		}
+	}
+
+	remoteCopy = "b2://offsite-backup/photos"
+	defer func() { remoteCopy = "" }()
+
+	var results []ChecksumFileVerificationResult
+	if err := runRemoteCopyVerification(localDir, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	expected := map[string]ChecksumFileVerificationStatus{
+		"b2://offsite-backup/photos/a.raw":     Match,
+		"b2://offsite-backup/photos/sub/b.raw": NotMatch,
+		"b2://offsite-backup/photos/c.raw":     Match,
+	}
+	for path, status := range expected {
+		if statuses[path] != status {
+			t.Fatalf("expected status %s for %s, got results %+v", status, path, results)
+		}
+	}
+
+	var creationResults []ChecksumFileCreationResult
+	if err := runRemoteCopyCreation(localDir, &creationResults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creationStatuses := map[string]ChecksumFileCreationStatus{}
+	for _, result := range creationResults {
+		creationStatuses[result.Path] = result.Status
+	}
+	if creationStatuses["b2://offsite-backup/photos/a.raw"] != Created || creationStatuses["b2://offsite-backup/photos/sub/b.raw"] != Failed {
+		t.Fatalf("unexpected creation results %+v", creationResults)
+	}
+
+	checksum, err := hashReader(strings.NewReader("raw a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := files["photos/a.raw"].FileInfo
+	if info[b2SHA512Info] != checksum || info["src_last_modified_millis"] != "1" {
+		t.Fatalf("unexpected file info %v", info)
+	}
+	if _, ok := files["photos/sub/b.raw"].FileInfo[b2SHA512Info]; ok {
+		t.Fatalf("the file info of the corrupted copy was set")
+	}
+
+	creationResults = nil
+	if err := runRemoteCopyCreation(localDir, &creationResults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, result := range creationResults {
+		if result.Path == "b2://offsite-backup/photos/a.raw" && result.Status != Existing {
+			t.Fatalf("expected status %s for the copy with the file info, got %s", Existing, result.Status)
+		}
+	}
+}
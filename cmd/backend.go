@@ -0,0 +1,314 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// storageBackend gives access to the files of a storage, so the commands handle the files of every storage the same
+// way. New remotes are added by implementing it and registering it in storageBackends.
+type storageBackend interface {
+	// URL returns the URL of the file shown in the results
+	URL(file string) string
+	// List returns the sorted paths of the files inside the storage, as expected by the other methods
+	List() ([]string, error)
+	// Open returns a reader streaming the content of the file
+	Open(file string) (io.ReadCloser, error)
+	// Write stores the content in the file
+	Write(file string, content []byte) error
+	// Resolve returns the path of the file given relative to the root of the storage, with slashes
+	Resolve(relativePath string) string
+}
+
+// remoteHasher is implemented by the backends able to compute the checksum of a file where it is stored,
+// used with --remote-hash instead of streaming its content
+type remoteHasher interface {
+	HashRemote(file string) (string, error)
+}
+
+// checksumMetadataStore is implemented by the backends able to keep the checksum of a file in its metadata,
+// read by check when the file has no checksum file and written by create with --s3-metadata
+type checksumMetadataStore interface {
+	// ReadChecksumMetadata returns the checksum kept in the metadata of the file, or an empty string
+	ReadChecksumMetadata(file string) (string, error)
+	WriteChecksumMetadata(file string, checksum string) error
+}
+
+// storageBackendOpener opens the backend of the URLs starting with its prefix
+type storageBackendOpener struct {
+	Prefix string
+	Open   func(rawURL string) (storageBackend, error)
+}
+
+// storageBackends are the backends of the remote URLs
+var storageBackends = []storageBackendOpener{
+	{Prefix: sftpScheme, Open: openSFTPBackend},
+	{Prefix: s3Scheme, Open: openS3Backend},
+	{Prefix: webdavScheme, Open: openWebDAVBackend},
+	{Prefix: webdavSecureScheme, Open: openWebDAVBackend},
+	{Prefix: rcloneScheme, Open: openRcloneBackend},
+}
+
+// isRemoteURL reports whether the argument is the URL of a registered backend
+func isRemoteURL(arg string) bool {
+	for _, opener := range storageBackends {
+		if strings.HasPrefix(arg, opener.Prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openBackend returns the backend of the remote URL, or of the local directory when it is not a remote URL
+func openBackend(rawURL string) (storageBackend, error) {
+	for _, opener := range storageBackends {
+		if strings.HasPrefix(rawURL, opener.Prefix) {
+			return opener.Open(rawURL)
+		}
+	}
+	return openLocalBackend(rawURL)
+}
+
+// hashBackendFile returns the hexadecimal checksum of the file, streaming its content or, with --remote-hash,
+// computing it where it is stored when the backend is able to
+func hashBackendFile(backend storageBackend, file string) (string, error) {
+	if hasher, ok := backend.(remoteHasher); ok && remoteHashing {
+		return hasher.HashRemote(file)
+	}
+
+	content, err := backend.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	return hashReader(content)
+}
+
+// readBackendFile returns the whole content of the file
+func readBackendFile(backend storageBackend, file string) ([]byte, error) {
+	content, err := backend.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return io.ReadAll(content)
+}
+
+// listBackendFiles returns the files of the backend and the set of their paths
+func listBackendFiles(backend storageBackend) ([]string, map[string]bool, error) {
+	files, err := backend.List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[string]bool, len(files))
+	for _, file := range files {
+		existing[file] = true
+	}
+
+	return files, existing, nil
+}
+
+// runBackendVerification checks the checksum files of the files of the backend
+func runBackendVerification(backend storageBackend, results *[]ChecksumFileVerificationResult) error {
+	files, existing, err := listBackendFiles(backend)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if isChecksumFile(file) {
+			continue
+		}
+
+		reportChecksumFileVerification(backend.URL(file), results, func(string) ChecksumFileVerificationResult {
+			return checkBackendChecksumFile(backend, file, existing)
+		})
+	}
+
+	return nil
+}
+
+// checkBackendChecksumFile compares the checksum file of the file, or the checksum kept in its metadata when it has
+// none, with its checksum
+func checkBackendChecksumFile(backend storageBackend, file string, existing map[string]bool) ChecksumFileVerificationResult {
+	fileURL := backend.URL(file)
+
+	var checksumFileContent []byte
+	if existing[file+checksumFileExtension] {
+		content, err := readBackendFile(backend, file+checksumFileExtension)
+		if err != nil {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+		}
+		checksumFileContent = content
+	} else if store, ok := backend.(checksumMetadataStore); ok {
+		checksum, err := store.ReadChecksumMetadata(file)
+		if err != nil {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+		}
+		if checksum == "" {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: NotFound, Error: nil}
+		}
+		checksumFileContent = []byte(checksum)
+	} else {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: NotFound, Error: nil}
+	}
+
+	// The checksum files are read like the local ones, in any of the formats and encodings written by create
+	checksum, listed := parseChecksumFile(string(checksumFileContent)).checksumOfFile(file)
+	if !listed {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Malformed, Error: fmt.Errorf("the checksum file does not have the checksum of the file")}
+	}
+	if err := validateChecksum(checksum, sha512Algorithm); err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Malformed, Error: err}
+	}
+
+	hexFileChecksum, err := hashBackendFile(backend, file)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+
+	if strings.EqualFold(hexFileChecksum, checksum) {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Match, Error: nil}
+	}
+
+	return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: nil}
+}
+
+// runBackendCreation stores the checksum files of the files of the backend that do not have one
+func runBackendCreation(backend storageBackend, results *[]ChecksumFileCreationResult) error {
+	files, existing, err := listBackendFiles(backend)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if isChecksumFile(file) {
+			continue
+		}
+
+		reportChecksumFileCreation(backend.URL(file), results, func(string) ChecksumFileCreationResult {
+			return createBackendChecksumFile(backend, file, existing)
+		})
+	}
+
+	return nil
+}
+
+// createBackendChecksumFile computes the checksum of the file and stores it in its checksum file, or in its metadata
+// with --s3-metadata
+func createBackendChecksumFile(backend storageBackend, file string, existing map[string]bool) ChecksumFileCreationResult {
+	fileURL := backend.URL(file)
+
+	if existing[file+checksumFileExtension] {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Existing, Error: nil}
+	}
+
+	store, storeInMetadata := backend.(checksumMetadataStore)
+	storeInMetadata = storeInMetadata && s3MetadataStore
+	if storeInMetadata {
+		if checksum, err := store.ReadChecksumMetadata(file); err != nil {
+			return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+		} else if checksum != "" {
+			return ChecksumFileCreationResult{Path: fileURL, Status: Existing, Error: nil}
+		}
+	}
+
+	hexFileChecksum, err := hashBackendFile(backend, file)
+	if err != nil {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+	}
+
+	if storeInMetadata {
+		if err := store.WriteChecksumMetadata(file, hexFileChecksum); err != nil {
+			return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+		}
+		return ChecksumFileCreationResult{Path: fileURL, Status: Created, Error: nil}
+	}
+
+	if err := backend.Write(file+checksumFileExtension, []byte(hexFileChecksum)); err != nil {
+		return ChecksumFileCreationResult{Path: fileURL, Status: Failed, Error: err}
+	}
+
+	return ChecksumFileCreationResult{Path: fileURL, Status: Created, Error: nil}
+}
+
+// localBackend is a directory of the local file system
+type localBackend struct {
+	Root string
+}
+
+// openLocalBackend returns the backend of the local directory
+func openLocalBackend(directory string) (storageBackend, error) {
+	root, err := filepath.Abs(directory)
+	if err != nil {
+		return nil, err
+	}
+	return localBackend{Root: root}, nil
+}
+
+// path returns the absolute path of the file, given relative to the directory with slashes
+func (b localBackend) path(file string) string {
+	return filepath.Join(b.Root, filepath.FromSlash(file))
+}
+
+func (b localBackend) URL(file string) string {
+	return b.path(file)
+}
+
+func (b localBackend) List() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(b.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(b.Root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (b localBackend) Resolve(relativePath string) string {
+	return relativePath
+}
+
+func (b localBackend) Open(file string) (io.ReadCloser, error) {
+	return openDataFile(b.path(file))
+}
+
+func (b localBackend) Write(file string, content []byte) error {
+	return replaceFile(b.path(file), string(content))
+}
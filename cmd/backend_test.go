@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalBackendCreateAndCheck(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"a.raw": "a", "2024/b.raw": "b"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	backend, err := openBackend(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var creationResults []ChecksumFileCreationResult
+	if err := runBackendCreation(backend, &creationResults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(creationResults) != 2 || creationResults[0].Status != Created || creationResults[1].Status != Created {
+		t.Fatalf("unexpected creation results %+v", creationResults)
+	}
+	if _, err := os.Stat(filepath.Join(root, "2024", "b.raw"+checksumFileExtension)); err != nil {
+		t.Fatalf("the checksum file was not created: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.raw"), []byte("corrupted"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	if err := runBackendVerification(backend, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	if statuses[filepath.Join(root, "a.raw")] != NotMatch || statuses[filepath.Join(root, "2024", "b.raw")] != Match || len(results) != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestRemoteCopyInLocalDirectory(t *testing.T) {
+	localDir := filepath.Join(t.TempDir(), "photos")
+	copyDir := filepath.Join(t.TempDir(), "copy")
+	for _, dir := range []string{localDir, copyDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+	}
+	for name, contents := range map[string][2]string{"a.raw": {"a", "a"}, "b.raw": {"b", "corrupted"}} {
+		if err := os.WriteFile(filepath.Join(localDir, name), []byte(contents[0]), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(copyDir, name), []byte(contents[1]), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(filepath.Join(localDir, name)); result.Status != Created {
+			t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+		}
+	}
+
+	remoteCopy = copyDir
+	defer func() { remoteCopy = "" }()
+
+	var results []ChecksumFileVerificationResult
+	if err := runRemoteCopyVerification(localDir, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	if statuses[filepath.Join(copyDir, "a.raw")] != Match || statuses[filepath.Join(copyDir, "b.raw")] != NotMatch || len(results) != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestLocalBackendCheck_SidecarFormats(t *testing.T) {
+	root := t.TempDir()
+	sidecars := map[string]func([]byte) string{
+		"coreutils.raw": func(hash []byte) string {
+			return coreutilsChecksumLine(hex.EncodeToString(hash), "coreutils.raw")
+		},
+		"metadata.raw": func(hash []byte) string {
+			return "# checksum-utils v1.0.0\n# size: 12\n# mtime: 2024-01-01T00:00:00Z\n" + hex.EncodeToString(hash) + "\r\n"
+		},
+		"base64.raw": func(hash []byte) string {
+			return base64.StdEncoding.EncodeToString(hash)
+		},
+	}
+	for name, sidecar := range sidecars {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		hash := sha512.Sum512([]byte(name))
+		if err := os.WriteFile(path+checksumFileExtension, []byte(sidecar(hash[:])), 0o600); err != nil {
+			t.Fatalf("write checksum file: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "truncated.raw"), []byte("truncated"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "truncated.raw"+checksumFileExtension), []byte("0123abcd"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	backend, err := openBackend(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	if err := runBackendVerification(backend, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	for _, result := range results {
+		expected := Match
+		if filepath.Base(result.Path) == "truncated.raw" {
+			expected = Malformed
+		}
+		if result.Status != expected {
+			t.Fatalf("expected status %s for %s, got %s (%v)", expected, result.Path, result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+// runInBackground lowers the CPU and I/O priority of the process, so scheduled scrubs do not slow down other workloads
+var runInBackground bool
+
+// backgroundUsage is the usage of the --background flag of the commands that hash files
+const backgroundUsage = "run with the lowest CPU priority and idle I/O priority (background mode on Windows), so other workloads are not slowed down"
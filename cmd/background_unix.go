@@ -0,0 +1,25 @@
+//go:build unix && !linux
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// enterBackgroundMode sets the lowest nice value; there is no portable way to lower the I/O priority
+func enterBackgroundMode() error {
+	return unix.Setpriority(unix.PRIO_PROCESS, 0, 19)
+}
@@ -0,0 +1,335 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errorsUpdatingCatalog []error
+var resultsUpdatingCatalog []CatalogUpdateResult
+
+var catalogPath string
+var listCatalogLong bool
+
+// catalogCmd represents the catalog command
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Manage the catalog database.",
+	Long: `Store the path, size, modification time and checksum of the files in a SQLite database,
+instead of (or in addition to) checksum files next to them.
+
+Example:
+  checksum-utils catalog update /mnt/external-disk
+  checksum-utils catalog list /mnt/external-disk/photos
+  checksum-utils catalog --catalog /volume1/catalog.db update /volume1
+`,
+}
+
+// catalogUpdateCmd represents the catalog update command
+var catalogUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Add the files to the catalog.",
+	Long: `Add the files to the catalog, computing their checksum.
+Files already in the catalog are hashed again only when their size or modification time changed.
+
+Example:
+  checksum-utils catalog update .
+  checksum-utils catalog update ~/documents
+`,
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completePaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		printHeader()
+
+		c, err := openCatalog(catalogPath)
+		if err != nil {
+			errorsUpdatingCatalog = append(errorsUpdatingCatalog, err)
+			printErrorsUpdatingCatalog()
+			return
+		}
+		defer c.Close()
+
+		paths, expandErrors, _ := gatherPaths(args)
+		errorsUpdatingCatalog = append(errorsUpdatingCatalog, expandErrors...)
+		if len(paths) == 0 {
+			printErrorsUpdatingCatalog()
+			return
+		}
+
+		fmt.Println()
+		fmt.Println("Catalog", catalogPath)
+		fmt.Printf("Processing %d paths\n", len(paths))
+
+		resultsUpdatingCatalog = []CatalogUpdateResult{}
+		processPaths(paths, &errorsUpdatingCatalog, func(filePath string) error {
+			return handleCatalogUpdate(c, filePath, &resultsUpdatingCatalog)
+		})
+
+		printResultsUpdatingCatalog(resultsUpdatingCatalog)
+		printErrorsUpdatingCatalog()
+	},
+}
+
+// catalogListCmd represents the catalog list command
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the files in the catalog.",
+	Long: `List the files in the catalog, optionally only the ones inside the given paths.
+The output uses the format of sha512sum, so it can be saved as a manifest.
+
+Example:
+  checksum-utils catalog list
+  checksum-utils catalog list /mnt/external-disk/photos > SHA512SUMS
+  checksum-utils catalog list --long ~/documents
+`,
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completePaths,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openCatalog(catalogPath)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		paths := []string{""}
+		if len(args) > 0 {
+			paths = paths[:0]
+			for _, arg := range args {
+				absolutePath, err := filepath.Abs(arg)
+				if err != nil {
+					return err
+				}
+				paths = append(paths, absolutePath)
+			}
+		}
+
+		for _, path := range paths {
+			entries, err := c.List(path)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				if listCatalogLong {
+					fmt.Printf("%s  %12d  %s  %s  %s\n", entry.LastVerifiedAt.Format(time.DateTime), entry.Size, entry.ModTime.Format(time.DateTime), entry.Digest, entry.Path)
+					continue
+				}
+				fmt.Printf("%s  %s\n", entry.Digest, entry.Path)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+	catalogCmd.AddCommand(catalogUpdateCmd)
+	catalogCmd.AddCommand(catalogListCmd)
+
+	catalogCmd.PersistentFlags().StringVar(&catalogPath, "catalog", defaultCatalogPath(), "path of the catalog database")
+	catalogUpdateCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	catalogUpdateCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	catalogUpdateCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	catalogUpdateCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	catalogUpdateCmd.Flags().BoolVarP(&oneFileSystem, "one-file-system", "x", false, oneFileSystemUsage)
+	catalogUpdateCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	catalogUpdateCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+	catalogListCmd.Flags().BoolVarP(&listCatalogLong, "long", "l", false, "also print the last verification time, size and modification time")
+}
+
+type CatalogUpdateStatus string
+
+const (
+	CatalogAdded     CatalogUpdateStatus = "Added"
+	CatalogUpdated   CatalogUpdateStatus = "Updated"
+	CatalogUnchanged CatalogUpdateStatus = "Unchanged"
+	CatalogFailed    CatalogUpdateStatus = "Failed"
+	CatalogLocked    CatalogUpdateStatus = "Locked"
+)
+
+type CatalogUpdateResult struct {
+	Path   string
+	Status CatalogUpdateStatus
+	Error  error
+}
+
+func handleCatalogUpdate(c *catalog, filePath string, results *[]CatalogUpdateResult) error {
+	fileAbsolutePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	if isChecksumFile(fileAbsolutePath) {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("- %s ", fileAbsolutePath)
+	spinner := startProgress(prefix)
+	start := time.Now()
+	result := updateCatalogEntry(c, fileAbsolutePath)
+	elapsed := time.Since(start)
+	spinner.Stop()
+
+	*results = append(*results, result)
+
+	if spinner.Enabled() {
+		clearProgressLine(prefix)
+	} else {
+		fmt.Print(prefix)
+	}
+	switch result.Status {
+	case CatalogAdded:
+		fmt.Print("✅")
+	case CatalogUpdated:
+		fmt.Print("🔄")
+	case CatalogUnchanged:
+		fmt.Print("⏭️")
+	case CatalogLocked:
+		fmt.Print("🔒")
+	case CatalogFailed:
+		fmt.Print("❌")
+	}
+
+	if result.Status == CatalogAdded || result.Status == CatalogUpdated || result.Status == CatalogFailed {
+		fmt.Printf(" (%s)", formatDuration(elapsed))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// updateCatalogEntry adds the file to the catalog, or updates its entry when its size or modification time changed
+func updateCatalogEntry(c *catalog, fileAbsolutePath string) CatalogUpdateResult {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogFailed, Error: err}
+	}
+
+	entry, err := c.Get(fileAbsolutePath)
+	if err != nil {
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogFailed, Error: err}
+	}
+
+	if entry != nil && entry.Size == fileInfo.Size() && entry.ModTime.Equal(fileInfo.ModTime()) {
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogUnchanged, Error: nil}
+	}
+
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		if os.IsPermission(err) {
+			return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogLocked, Error: err}
+		}
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogFailed, Error: err}
+	}
+	defer file.Close()
+
+	hexFileChecksum, err := hashReader(file)
+	if err != nil {
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogFailed, Error: err}
+	}
+
+	now := time.Now()
+	status := CatalogUpdated
+	if entry == nil {
+		status = CatalogAdded
+		entry = &catalogEntry{Path: fileAbsolutePath, AddedAt: now}
+	}
+
+	entry.Size = fileInfo.Size()
+	entry.ModTime = fileInfo.ModTime()
+	entry.Algorithm = sha512Algorithm.Name
+	entry.Digest = hexFileChecksum
+	entry.LastVerifiedAt = now
+
+	if err := c.Put(*entry); err != nil {
+		return CatalogUpdateResult{Path: fileAbsolutePath, Status: CatalogFailed, Error: err}
+	}
+
+	return CatalogUpdateResult{Path: fileAbsolutePath, Status: status, Error: nil}
+}
+
+func printResultsUpdatingCatalog(results []CatalogUpdateResult) {
+	if len(results) > 0 {
+		fmt.Println("Results:", len(results), "files processed")
+	}
+
+	var addedQuantity = 0
+	var updatedQuantity = 0
+	var unchangedQuantity = 0
+	var lockedResults []CatalogUpdateResult
+	var failedResults []CatalogUpdateResult
+
+	for _, result := range results {
+		switch result.Status {
+		case CatalogAdded:
+			addedQuantity++
+		case CatalogUpdated:
+			updatedQuantity++
+		case CatalogUnchanged:
+			unchangedQuantity++
+		case CatalogLocked:
+			lockedResults = append(lockedResults, result)
+		case CatalogFailed:
+			failedResults = append(failedResults, result)
+		}
+	}
+
+	if addedQuantity > 0 {
+		fmt.Println("✅ :", addedQuantity, "files added to the catalog")
+	}
+
+	if updatedQuantity > 0 {
+		fmt.Println("🔄 :", updatedQuantity, "files updated because their size or modification time changed")
+	}
+
+	if unchangedQuantity > 0 {
+		fmt.Println("⏭️ :", unchangedQuantity, "files unchanged since they were cataloged")
+	}
+
+	if len(lockedResults) > 0 {
+		fmt.Println("🔒 :", len(lockedResults), "files could not be read due to permissions")
+		for _, lockedResult := range lockedResults {
+			fmt.Print("- ", lockedResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(failedResults) > 0 {
+		fmt.Println("❌ :", len(failedResults), "files failed to catalog")
+		for _, failedResult := range failedResults {
+			fmt.Print("- ", failedResult.Path, " | Error: ", failedResult.Error)
+			fmt.Println()
+		}
+	}
+}
+
+func printErrorsUpdatingCatalog() {
+	if len(errorsUpdatingCatalog) > 0 {
+		fmt.Println()
+		fmt.Println("Errors:")
+
+		for _, error := range errorsUpdatingCatalog {
+			fmt.Println("- ", error)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// catalogSchema creates the tables of the catalog database when they do not exist
+const catalogSchema = `
+CREATE TABLE IF NOT EXISTS files (
+	path             TEXT PRIMARY KEY,
+	size             INTEGER NOT NULL,
+	mtime            INTEGER NOT NULL,
+	algorithm        TEXT NOT NULL,
+	digest           TEXT NOT NULL,
+	added_at         INTEGER NOT NULL,
+	last_verified_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS verifications (
+	path        TEXT NOT NULL,
+	verified_at INTEGER NOT NULL,
+	command     TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	outcome     TEXT NOT NULL,
+	error       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS verifications_path ON verifications (path, verified_at);
+`
+
+// catalogEntry is the record of a file in the catalog. Times are stored as Unix nanoseconds.
+type catalogEntry struct {
+	Path           string
+	Size           int64
+	ModTime        time.Time
+	Algorithm      string
+	Digest         string
+	AddedAt        time.Time
+	LastVerifiedAt time.Time
+}
+
+// verificationOutcome classifies the statuses of the different commands in the history
+type verificationOutcome string
+
+const (
+	verificationPassed       verificationOutcome = "passed"
+	verificationFailed       verificationOutcome = "failed"
+	verificationInconclusive verificationOutcome = "inconclusive"
+)
+
+// verificationRecord is a result of check or audit stored in the history of a file
+type verificationRecord struct {
+	Path       string
+	VerifiedAt time.Time
+	Command    string
+	Status     string
+	Outcome    verificationOutcome
+	Error      string
+}
+
+// catalog is a SQLite database storing the checksum of the files, as an alternative to the checksum files
+type catalog struct {
+	db *sql.DB
+}
+
+// defaultCatalogPath returns the path of the catalog used when --catalog is not given
+func defaultCatalogPath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "checksum-utils.db"
+	}
+	return filepath.Join(configDirectory, "checksum-utils", "catalog.db")
+}
+
+// openCatalog opens the catalog database, creating it when it does not exist
+func openCatalog(path string) (*catalog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, sharing one connection avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(catalogSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &catalog{db: db}, nil
+}
+
+func (c *catalog) Close() error {
+	return c.db.Close()
+}
+
+// Get returns the entry of the file, or nil when the file is not in the catalog
+func (c *catalog) Get(path string) (*catalogEntry, error) {
+	row := c.db.QueryRow(`SELECT path, size, mtime, algorithm, digest, added_at, last_verified_at FROM files WHERE path = ?`, path)
+
+	entry, err := scanCatalogEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// Put inserts or replaces the entry of the file
+func (c *catalog) Put(entry catalogEntry) error {
+	_, err := c.db.Exec(
+		`INSERT INTO files (path, size, mtime, algorithm, digest, added_at, last_verified_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET size = excluded.size, mtime = excluded.mtime, algorithm = excluded.algorithm,
+			digest = excluded.digest, last_verified_at = excluded.last_verified_at`,
+		entry.Path, entry.Size, entry.ModTime.UnixNano(), entry.Algorithm, entry.Digest, entry.AddedAt.UnixNano(), entry.LastVerifiedAt.UnixNano(),
+	)
+	return err
+}
+
+// MarkVerified sets the last verification time of the file
+func (c *catalog) MarkVerified(path string, verifiedAt time.Time) error {
+	_, err := c.db.Exec(`UPDATE files SET last_verified_at = ? WHERE path = ?`, verifiedAt.UnixNano(), path)
+	return err
+}
+
+// RecordVerification adds a result to the history of the file
+func (c *catalog) RecordVerification(record verificationRecord) error {
+	_, err := c.db.Exec(
+		`INSERT INTO verifications (path, verified_at, command, status, outcome, error) VALUES (?, ?, ?, ?, ?, ?)`,
+		record.Path, record.VerifiedAt.UnixNano(), record.Command, record.Status, string(record.Outcome), record.Error,
+	)
+	return err
+}
+
+// History returns the results recorded for the file, from the oldest to the newest
+func (c *catalog) History(path string) ([]verificationRecord, error) {
+	rows, err := c.db.Query(`SELECT path, verified_at, command, status, outcome, error FROM verifications WHERE path = ? ORDER BY verified_at, rowid`, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []verificationRecord
+	for rows.Next() {
+		var record verificationRecord
+		var verifiedAt int64
+		var outcome string
+		if err := rows.Scan(&record.Path, &verifiedAt, &record.Command, &record.Status, &outcome, &record.Error); err != nil {
+			return nil, err
+		}
+		record.VerifiedAt = time.Unix(0, verifiedAt)
+		record.Outcome = verificationOutcome(outcome)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// LastVerifications returns the last time each file passed a catalog verification or was verified by a command,
+// recorded in its history
+func (c *catalog) LastVerifications() (map[string]time.Time, error) {
+	rows, err := c.db.Query(`SELECT path, MAX(verified_at) FROM (
+		SELECT path, verified_at FROM verifications
+		UNION ALL
+		SELECT path, last_verified_at FROM files
+	) GROUP BY path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lastVerifications := map[string]time.Time{}
+	for rows.Next() {
+		var path string
+		var verifiedAt int64
+		if err := rows.Scan(&path, &verifiedAt); err != nil {
+			return nil, err
+		}
+		lastVerifications[path] = time.Unix(0, verifiedAt)
+	}
+
+	return lastVerifications, rows.Err()
+}
+
+// List returns, ordered by path, the entry of the file or the entries of the files inside the directory,
+// or all the entries when the path is empty
+func (c *catalog) List(path string) ([]catalogEntry, error) {
+	query := `SELECT path, size, mtime, algorithm, digest, added_at, last_verified_at FROM files`
+	var args []any
+	if path != "" {
+		prefix := strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator)
+		query += ` WHERE path = ? OR substr(path, 1, length(?)) = ?`
+		args = append(args, path, prefix, prefix)
+	}
+	query += ` ORDER BY path`
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []catalogEntry
+	for rows.Next() {
+		entry, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// scanCatalogEntry reads an entry from a row of the files table
+func scanCatalogEntry(row interface{ Scan(...any) error }) (*catalogEntry, error) {
+	var entry catalogEntry
+	var modTime, addedAt, lastVerifiedAt int64
+
+	if err := row.Scan(&entry.Path, &entry.Size, &modTime, &entry.Algorithm, &entry.Digest, &addedAt, &lastVerifiedAt); err != nil {
+		return nil, err
+	}
+
+	entry.ModTime = time.Unix(0, modTime)
+	entry.AddedAt = time.Unix(0, addedAt)
+	entry.LastVerifiedAt = time.Unix(0, lastVerifiedAt)
+
+	return &entry, nil
+}
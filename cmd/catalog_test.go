@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpdateCatalogEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data", "data.txt")
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	c, err := openCatalog(filepath.Join(tempDir, "catalog.db"))
+	if err != nil {
+		t.Fatalf("open catalog: %v", err)
+	}
+	defer c.Close()
+
+	result := updateCatalogEntry(c, filePath)
+	if result.Status != CatalogAdded {
+		t.Fatalf("expected status %s, got %s (%v)", CatalogAdded, result.Status, result.Error)
+	}
+
+	result = updateCatalogEntry(c, filePath)
+	if result.Status != CatalogUnchanged {
+		t.Fatalf("expected status %s, got %s (%v)", CatalogUnchanged, result.Status, result.Error)
+	}
+
+	if err := os.WriteFile(filePath, []byte("hello world"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatalf("change times: %v", err)
+	}
+
+	result = updateCatalogEntry(c, filePath)
+	if result.Status != CatalogUpdated {
+		t.Fatalf("expected status %s, got %s (%v)", CatalogUpdated, result.Status, result.Error)
+	}
+
+	entry, err := c.Get(filePath)
+	if err != nil {
+		t.Fatalf("get entry: %v", err)
+	}
+	if entry == nil || entry.Size != int64(len("hello world")) || !entry.ModTime.Equal(modTime) {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	entries, err := c.List(filepath.Join(tempDir, "data"))
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != filePath {
+		t.Fatalf("expected the entry of %s, got %+v", filePath, entries)
+	}
+
+	entries, err = c.List(filepath.Join(tempDir, "dat"))
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries for a sibling prefix, got %+v", entries)
+	}
+}
+
+func TestRecordVerification(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	c, err := openCatalog(filepath.Join(tempDir, "catalog.db"))
+	if err != nil {
+		t.Fatalf("open catalog: %v", err)
+	}
+	defer c.Close()
+
+	if result := updateCatalogEntry(c, filePath); result.Status != CatalogAdded {
+		t.Fatalf("expected status %s, got %s (%v)", CatalogAdded, result.Status, result.Error)
+	}
+	entry, err := c.Get(filePath)
+	if err != nil {
+		t.Fatalf("get entry: %v", err)
+	}
+
+	if err := recordVerification(c, filePath, "check", string(NotMatch), checkVerificationOutcome(NotMatch), nil); err != nil {
+		t.Fatalf("record verification: %v", err)
+	}
+	if err := recordVerification(c, filePath, "audit", string(AuditUnchanged), auditVerificationOutcome(AuditUnchanged), nil); err != nil {
+		t.Fatalf("record verification: %v", err)
+	}
+
+	records, err := c.History(filePath)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Command != "check" || records[0].Outcome != verificationFailed {
+		t.Fatalf("unexpected first record: %+v", records[0])
+	}
+	if records[1].Command != "audit" || records[1].Outcome != verificationPassed {
+		t.Fatalf("unexpected second record: %+v", records[1])
+	}
+
+	updatedEntry, err := c.Get(filePath)
+	if err != nil {
+		t.Fatalf("get entry: %v", err)
+	}
+	if !updatedEntry.LastVerifiedAt.After(entry.LastVerifiedAt) {
+		t.Fatalf("expected the last verification time to be updated")
+	}
+}
+
+func TestSortStaleFirst(t *testing.T) {
+	tempDir := t.TempDir()
+	dataDir := filepath.Join(tempDir, "data")
+	if err := os.Mkdir(dataDir, 0o700); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+
+	var filePaths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		filePath := filepath.Join(dataDir, name)
+		if err := os.WriteFile(filePath, []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		filePaths = append(filePaths, filePath)
+	}
+
+	c, err := openCatalog(filepath.Join(tempDir, "catalog.db"))
+	if err != nil {
+		t.Fatalf("open catalog: %v", err)
+	}
+	defer c.Close()
+
+	// a.txt was verified after c.txt, and b.txt was never verified
+	if err := recordVerification(c, filePaths[2], "check", string(Match), checkVerificationOutcome(Match), nil); err != nil {
+		t.Fatalf("record verification: %v", err)
+	}
+	if err := recordVerification(c, filePaths[0], "check", string(Match), checkVerificationOutcome(Match), nil); err != nil {
+		t.Fatalf("record verification: %v", err)
+	}
+
+	var errs []error
+	sorted, err := sortStaleFirst(c, []string{dataDir}, &errs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{filePaths[1], filePaths[2], filePaths[0]}
+	if !slices.Equal(sorted, expected) {
+		t.Fatalf("expected %v, got %v", expected, sorted)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// slackWebhookURL, discordWebhookURL, telegramBotToken and telegramChatID configure the chat services notified
+// when files do not match or fail, usually set in the configuration file
+var slackWebhookURL string
+var discordWebhookURL string
+var telegramBotToken string
+var telegramChatID string
+
+// telegramAPIURL is the URL of the Bot API of Telegram
+var telegramAPIURL = "https://api.telegram.org"
+
+// chatMessageMaxFiles is the number of files listed in a chat message, so a disk full of corrupted files
+// does not flood the channel
+const chatMessageMaxFiles = 10
+
+// chatNotifier sends a message to a chat service
+type chatNotifier interface {
+	Name() string
+	Send(message string) error
+}
+
+// slackNotifier posts to an incoming webhook of Slack
+type slackNotifier struct {
+	webhookURL string
+}
+
+func (n slackNotifier) Name() string {
+	return "Slack"
+}
+
+func (n slackNotifier) Send(message string) error {
+	return postJSON(n.webhookURL, map[string]string{"text": message})
+}
+
+// discordNotifier posts to a webhook of a Discord channel
+type discordNotifier struct {
+	webhookURL string
+}
+
+func (n discordNotifier) Name() string {
+	return "Discord"
+}
+
+func (n discordNotifier) Send(message string) error {
+	return postJSON(n.webhookURL, map[string]string{"content": message})
+}
+
+// telegramNotifier sends the messages with a Telegram bot to a chat
+type telegramNotifier struct {
+	botToken string
+	chatID   string
+}
+
+func (n telegramNotifier) Name() string {
+	return "Telegram"
+}
+
+func (n telegramNotifier) Send(message string) error {
+	err := postJSON(telegramAPIURL+"/bot"+n.botToken+"/sendMessage", map[string]string{"chat_id": n.chatID, "text": message})
+	if err != nil {
+		// The URL has the token of the bot
+		return errors.New(strings.ReplaceAll(err.Error(), n.botToken, "<token>"))
+	}
+	return nil
+}
+
+// chatNotifiers returns the chat services configured with their flags
+func chatNotifiers() []chatNotifier {
+	var notifiers []chatNotifier
+	if slackWebhookURL != "" {
+		notifiers = append(notifiers, slackNotifier{webhookURL: slackWebhookURL})
+	}
+	if discordWebhookURL != "" {
+		notifiers = append(notifiers, discordNotifier{webhookURL: discordWebhookURL})
+	}
+	if telegramBotToken != "" && telegramChatID != "" {
+		notifiers = append(notifiers, telegramNotifier{botToken: telegramBotToken, chatID: telegramChatID})
+	}
+	return notifiers
+}
+
+// validateChatSettings returns an error when a chat service is only partially configured
+func validateChatSettings() error {
+	if (telegramBotToken == "") != (telegramChatID == "") {
+		return fmt.Errorf("--telegram-bot-token and --telegram-chat-id have to be used together")
+	}
+	return nil
+}
+
+// sendChatNotifications sends a message to the configured chat services when files did not match or failed,
+// or there were errors, and returns the errors sending it
+func sendChatNotifications(results []ChecksumFileVerificationResult, errs []error) []error {
+	notifiers := chatNotifiers()
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	summary := summarizeCheck(results)
+	if summary.Mismatched == 0 && summary.Failed == 0 && len(errs) == 0 {
+		return nil
+	}
+
+	message := formatChatMessage(results, errs)
+	var sendErrors []error
+	for _, notifier := range notifiers {
+		if err := notifier.Send(message); err != nil {
+			sendErrors = append(sendErrors, fmt.Errorf("the %s notification could not be sent: %w", notifier.Name(), err))
+		}
+	}
+	return sendErrors
+}
+
+// formatChatMessage returns the message of the chat services: the headline of the check and the first files that
+// did not match or failed
+func formatChatMessage(results []ChecksumFileVerificationResult, errs []error) string {
+	var message strings.Builder
+	message.WriteString("⚠️ " + checkHeadline(results, errs))
+
+	listed := 0
+	for _, result := range results {
+		switch result.Status {
+		case NotMatch, CheckingFailed, LockedVerification, BadSignature, Malformed, TimedOutVerification:
+		default:
+			continue
+		}
+		if listed == chatMessageMaxFiles {
+			message.WriteString("\n- …")
+			break
+		}
+		message.WriteString("\n- " + result.Path)
+		if result.Error != nil {
+			message.WriteString(" | " + result.Error.Error())
+		}
+		listed++
+	}
+	return message.String()
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendChatNotifications(t *testing.T) {
+	received := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode notification: %v", err)
+		}
+		received[r.URL.Path] = payload
+	}))
+	defer server.Close()
+
+	slackWebhookURL = server.URL + "/slack"
+	discordWebhookURL = server.URL + "/discord"
+	telegramAPIURL, telegramBotToken, telegramChatID = server.URL, "123:secret", "-100"
+	defer func() {
+		slackWebhookURL, discordWebhookURL = "", ""
+		telegramAPIURL, telegramBotToken, telegramChatID = "https://api.telegram.org", "", ""
+	}()
+
+	// Nothing is sent when every file matches
+	if errs := sendChatNotifications([]ChecksumFileVerificationResult{{Path: "/volume1/a.raw", Status: Match}}, nil); len(errs) > 0 || len(received) > 0 {
+		t.Fatalf("expected no notification, got %v (%v)", received, errs)
+	}
+
+	results := []ChecksumFileVerificationResult{{Path: "/volume1/b.raw", Status: NotMatch, Error: errors.New("probable bit rot")}}
+	if errs := sendChatNotifications(results, nil); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if text := received["/slack"]["text"]; !strings.Contains(text, "1 files do not match") || !strings.Contains(text, "/volume1/b.raw | probable bit rot") {
+		t.Errorf("unexpected Slack message %q", text)
+	}
+	if content := received["/discord"]["content"]; !strings.Contains(content, "/volume1/b.raw") {
+		t.Errorf("unexpected Discord message %q", content)
+	}
+	if telegram := received["/bot123:secret/sendMessage"]; telegram["chat_id"] != "-100" || !strings.Contains(telegram["text"], "/volume1/b.raw") {
+		t.Errorf("unexpected Telegram message %v", telegram)
+	}
+}
+
+func TestFormatChatMessage_ListsTheFirstFiles(t *testing.T) {
+	var results []ChecksumFileVerificationResult
+	for i := 0; i < chatMessageMaxFiles+5; i++ {
+		results = append(results, ChecksumFileVerificationResult{Path: "/volume1/file.raw", Status: NotMatch})
+	}
+
+	message := formatChatMessage(results, nil)
+
+	if lines := strings.Count(message, "\n- /volume1/file.raw"); lines != chatMessageMaxFiles {
+		t.Fatalf("expected %d files in the message, got %d:\n%s", chatMessageMaxFiles, lines, message)
+	}
+	if !strings.HasSuffix(message, "\n- …") {
+		t.Fatalf("expected the message to tell that there are more files:\n%s", message)
+	}
+}
@@ -16,14 +16,15 @@ limitations under the License.
 package cmd
 
 import (
-	"crypto/sha512"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -32,6 +33,29 @@ import (
 var errorsCheckingChecksumFiles []error
 var resultsCheckingChecksumFiles []ChecksumFileVerificationResult
 
+var expectedChecksum string
+var manifestPath string
+var verifySignatures bool
+var manifestSignaturePath string
+var keyringPath string
+var recordHistory bool
+var relocateChecksumFiles bool
+var quarantineDirectory string
+var maxDuration time.Duration
+var staleFirst bool
+
+// maxDurationExitCode is the exit code of a check stopped by --max-duration
+const maxDurationExitCode = 3
+
+// checkDeadline is the time when the check stops, when --max-duration is used
+var checkDeadline time.Time
+
+// stoppedByMaxDuration reports whether files were left unchecked because of --max-duration
+var stoppedByMaxDuration atomic.Bool
+
+// historyCatalog is the catalog where the results are recorded when --record-history is used
+var historyCatalog *catalog
+
 // checkCmd represents the check command
 var checkCmd = &cobra.Command{
 	Use:   "check",
@@ -42,11 +66,169 @@ Example:
   checksum-utils check ./work
 	checksum-utils check ~/documents
   checksum-utils check /mnt/external-disk/budget.pdf
+  checksum-utils check --expect 9b71d224bd62f378... ~/downloads/debian.iso
+  checksum-utils check --manifest ~/downloads/SHA512SUMS --verify-signature ~/downloads/debian.iso
+  checksum-utils check --manifest https://cdimage.debian.org/debian-cd/current/amd64/iso-cd/SHA512SUMS ./isos
+  checksum-utils check --quarantine /volume1/quarantine /volume1/photos
+  checksum-utils check --into-archives ./backups
+  checksum-utils check --include '*.raw' --exclude '*.tmp' ~/photos
+  checksum-utils check --exclude-from /volume1/.checksumignore /volume1
+  checksum-utils check --min-size 1M --max-size 500G /volume1
+  checksum-utils check --older-than 30d /volume1/archive
+  checksum-utils check --resume /volume1
+  checksum-utils check --resume --max-duration 6h /volume1
+  checksum-utils check --incremental /volume1
+  checksum-utils check --sample 5% /volume1
+  checksum-utils check --stale-first --max-duration 2h /volume1
+  checksum-utils check --metrics-textfile /var/lib/node_exporter/checksum.prom /volume1
+  checksum-utils check --webhook-url https://automation.local/hooks/checksum /volume1
+  checksum-utils check --email-to admin@example.com /volume1
+  checksum-utils check --ping-url https://hc-ping.com/your-uuid /volume1
+  checksum-utils check sftp://backup@nas.local/volume1/photos
+  checksum-utils check s3://offsite-backup/photos
+  checksum-utils check davs://juan@cloud.example.com/remote.php/dav/files/juan/Photos
+  checksum-utils check rclone:b2-archive:photos
+  checksum-utils check --remote-copy rclone:b2-archive:photos /volume1/photos
+  checksum-utils check --remote-copy b2://offsite-backup/photos /volume1/photos
+  checksum-utils check --remote-copy s3://offsite-backup/photos --s3-etag /volume1/photos
 `,
-	Args: cobra.MinimumNArgs(0),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if expectedChecksum != "" && manifestPath != "" {
+			return errors.New("--expect and --manifest cannot be used together")
+		}
+		if isURL(manifestPath) && len(args) > 1 {
+			return errors.New("--manifest with a URL requires at most the directory of the files listed in it")
+		}
+		if s3ETagVerification && !strings.HasPrefix(remoteCopy, s3Scheme) {
+			return errors.New("--s3-etag requires --remote-copy with an s3:// path")
+		}
+		if expectedChecksum != "" && len(args) != 1 {
+			return errors.New("--expect requires exactly one file")
+		}
+		if err := validateReadOnlyOptions(); err != nil {
+			return err
+		}
+		if staleFirst && hddMode {
+			return errors.New("--stale-first and --hdd cannot be used together")
+		}
+		if samplePercentage > 0 && sampleCount > 0 {
+			return errors.New("--sample and --sample-count cannot be used together")
+		}
+		return nil
+	},
+	ValidArgsFunction: completePaths,
 	Run: func(cmd *cobra.Command, args []string) {
 		printHeader()
 
+		if err := acquireRunLocks(args); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			printErrorsCheckingChecksumFiles()
+			os.Exit(lockedExitCode)
+		}
+		defer releaseRunLocks()
+
+		if err := startReadOnlyCheck(args); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			printErrorsCheckingChecksumFiles()
+			os.Exit(1)
+		}
+
+		checkStartedAt = time.Now()
+
+		if maxDuration > 0 {
+			checkDeadline = time.Now().Add(maxDuration)
+		}
+
+		if err := validateEmailSettings(); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			printErrorsCheckingChecksumFiles()
+			os.Exit(1)
+		}
+
+		if err := validateChatSettings(); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			printErrorsCheckingChecksumFiles()
+			os.Exit(1)
+		}
+
+		if verifySignatures {
+			if err := ensureGPG(); err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+				printErrorsCheckingChecksumFiles()
+				os.Exit(1)
+			}
+		}
+
+		// The order of --stale-first comes from the history, so it has to be recorded
+		if recordHistory || staleFirst {
+			c, err := openCatalog(catalogPath)
+			if err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+				printErrorsCheckingChecksumFiles()
+				os.Exit(1)
+			}
+			defer c.Close()
+			historyCatalog = c
+		}
+
+		if err := pingStarted(); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+		if err := recordCheckStarted(); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+
+		if expectedChecksum != "" {
+			runExpectedChecksumVerification(args[0])
+			return
+		}
+
+		if manifestPath != "" {
+			runManifestVerification(args)
+			return
+		}
+
+		if remoteCopy != "" {
+			if len(args) != 1 {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, fmt.Errorf("--remote-copy requires the local directory of the copy"))
+				printErrorsCheckingChecksumFiles()
+				os.Exit(1)
+			}
+
+			fmt.Println()
+			fmt.Println("Processing", args[0], "→", remoteCopy)
+
+			resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
+			if err := runRemoteCopyVerification(args[0], &resultsCheckingChecksumFiles); err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			}
+			printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+			finishCheckRun(resultsCheckingChecksumFiles)
+			printErrorsCheckingChecksumFiles()
+			return
+		}
+
+		// The results of all the paths, for the metrics
+		var checkedResults []ChecksumFileVerificationResult
+
+		args, remoteArgs := splitRemoteArgs(args)
+		for _, remoteArg := range remoteArgs {
+			fmt.Println()
+			fmt.Println("Processing", remoteArg)
+
+			resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
+			if err := runRemoteVerification(remoteArg, &resultsCheckingChecksumFiles); err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			}
+			printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+			checkedResults = append(checkedResults, resultsCheckingChecksumFiles...)
+		}
+		if len(remoteArgs) > 0 && len(args) == 0 {
+			finishCheckRun(checkedResults)
+			printErrorsCheckingChecksumFiles()
+			return
+		}
+
 		paths, expandErrors, hadGlob := gatherPaths(args)
 		for _, err := range expandErrors {
 			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
@@ -55,72 +237,509 @@ Example:
 			printErrorsCheckingChecksumFiles()
 			return
 		}
+		startRunStatus(paths)
+
+		verificationCache, err := loadVerificationCache(verificationCachePath)
+		if err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+		activeVerificationCache = verificationCache
 
 		if hadGlob || len(paths) > 1 {
 			fmt.Println()
 			fmt.Printf("Processing %d paths\n", len(paths))
 			resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
-			processPaths(paths, &errorsCheckingChecksumFiles, func(filePath string) error {
-				return handleChecksumFileVerification(filePath, &resultsCheckingChecksumFiles)
-			})
+			checkPathsWithCheckpoint(paths, &resultsCheckingChecksumFiles)
+			resultsCheckingChecksumFiles = detectMovedFiles(dropListedNotFound(resultsCheckingChecksumFiles))
+			if quarantineDirectory != "" {
+				quarantineNotMatchingFiles(resultsCheckingChecksumFiles, paths, quarantineDirectory)
+			}
 			printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+			checkedResults = append(checkedResults, resultsCheckingChecksumFiles...)
 		} else {
 			for _, path := range paths {
 				fmt.Println()
 				fmt.Println("Processing", path)
 
 				resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
-				processPaths([]string{path}, &errorsCheckingChecksumFiles, func(filePath string) error {
-					return handleChecksumFileVerification(filePath, &resultsCheckingChecksumFiles)
-				})
+				checkPathsWithCheckpoint([]string{path}, &resultsCheckingChecksumFiles)
+				resultsCheckingChecksumFiles = detectMovedFiles(dropListedNotFound(resultsCheckingChecksumFiles))
+				if quarantineDirectory != "" {
+					quarantineNotMatchingFiles(resultsCheckingChecksumFiles, []string{path}, quarantineDirectory)
+				}
 
 				printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+				checkedResults = append(checkedResults, resultsCheckingChecksumFiles...)
+			}
+		}
+
+		if activeVerificationCache != nil {
+			if err := activeVerificationCache.save(); err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
 			}
+			activeVerificationCache = nil
 		}
 
+		finishCheckRun(checkedResults)
 		printErrorsCheckingChecksumFiles()
+
+		if stoppedByMaxDuration.Load() {
+			fmt.Println()
+			fmt.Printf("⏱️ : stopped after the maximum duration of %s, run the same command with --resume to continue\n", maxDuration)
+			os.Exit(maxDurationExitCode)
+		}
+		if stoppedByMaxErrors.Load() {
+			printStoppedByMaxErrors()
+			os.Exit(maxErrorsExitCode)
+		}
+		if stoppedByFailFast.Load() {
+			printStoppedByFailFast()
+			os.Exit(1)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringVar(&checksumFileExtension, "suffix", checksumFileExtension, checksumFileExtensionUsage)
+	checkCmd.Flags().StringVar(&storeDirectory, "store-dir", "", storeDirectoryUsage)
+	checkCmd.Flags().StringVar(&storeMode, "store", sidecarStoreMode, storeModeUsage)
+	checkCmd.Flags().BoolVar(&hiddenSidecars, "hidden-sidecar", false, hiddenSidecarsUsage)
+	checkCmd.Flags().StringVar(&manifestPath, "manifest", "", "verify the files listed in a manifest like SHA512SUMS, or downloaded from an http(s) URL, instead of their checksum files")
+	checkCmd.Flags().BoolVar(&verifySignatures, "verify-signature", false, "verify the GPG signature of the manifest, or of each checksum file (.sha512.asc), before trusting it")
+	checkCmd.Flags().StringVar(&manifestSignaturePath, "signature", "", "detached signature of the manifest (default: the manifest path with .asc, .sig, .sign or .gpg)")
+	checkCmd.Flags().StringVar(&keyringPath, "keyring", "", "verify signatures only with the keys of this keyring file instead of the GPG keys")
+	checkCmd.Flags().BoolVar(&relocateChecksumFiles, "relocate", false, "move the checksum files of moved or renamed files next to their new location")
+	checkCmd.Flags().StringVar(&quarantineDirectory, "quarantine", "", "move the files that do not match their checksum file, with their checksum file, into this directory")
+	checkCmd.Flags().BoolVar(&remoteHashing, "remote-hash", false, "compute the checksum of sftp:// files on the remote host with sha512sum instead of streaming their content")
+	checkCmd.Flags().BoolVar(&intoArchives, "into-archives", false, "also check the members of .tar, .tar.gz and .zip archives against their manifest (.members.sha512)")
+	checkCmd.Flags().StringVar(&remoteCopy, "remote-copy", "", remoteCopyUsage)
+	checkCmd.Flags().BoolVar(&s3ETagVerification, "s3-etag", false, s3ETagVerificationUsage)
+	checkCmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "URL of the S3 compatible service used by s3:// paths, like a MinIO server (default: $AWS_ENDPOINT_URL_S3 or AWS)")
+	checkCmd.Flags().StringVar(&filesFrom, "files-from", "", filesFromUsage)
+	checkCmd.Flags().BoolVarP(&nullSeparated, "null", "0", false, nullSeparatedUsage)
+	checkCmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "number of files checked, and of directories read, at the same time")
+	checkCmd.Flags().BoolVar(&hddMode, "hdd", false, hddModeUsage)
+	checkCmd.Flags().BoolVarP(&oneFileSystem, "one-file-system", "x", false, oneFileSystemUsage)
+	checkCmd.Flags().Var(&includePatterns, "include", includePatternsUsage)
+	checkCmd.Flags().Var(&excludePatterns, "exclude", excludePatternsUsage)
+	checkCmd.Flags().StringSliceVar(&onlyExtensions, "only-ext", nil, onlyExtensionsUsage)
+	checkCmd.Flags().Var(&includeRegexps, "include-regex", includeRegexpsUsage)
+	checkCmd.Flags().Var(&excludeRegexps, "exclude-regex", excludeRegexpsUsage)
+	checkCmd.Flags().Var(&excludeRules, "exclude-from", excludeFromUsage)
+	checkCmd.Flags().BoolVar(&excludeCommon, "exclude-common", false, excludeCommonUsage)
+	checkCmd.Flags().IntVar(&maxDepth, "max-depth", 0, maxDepthUsage)
+	checkCmd.Flags().BoolVar(&skipHidden, "skip-hidden", false, skipHiddenUsage)
+	checkCmd.Flags().BoolVar(&includeSnapshots, "include-snapshots", false, includeSnapshotsUsage)
+	checkCmd.Flags().Var(&minFileSize, "min-size", minFileSizeUsage)
+	checkCmd.Flags().Var(&maxFileSize, "max-size", maxFileSizeUsage)
+	checkCmd.Flags().Var(&newerThan, "newer-than", newerThanUsage)
+	checkCmd.Flags().Var(&olderThan, "older-than", olderThanUsage)
+	checkCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, followSymlinksUsage)
+	checkCmd.Flags().BoolVar(&staleFirst, "stale-first", false, "check first the files that have not been verified the longest according to the catalog, recording the results like --record-history")
+	checkCmd.Flags().Var(&samplePercentage, "sample", "check only a random sample of this percentage of the files with a checksum file, like 5%")
+	checkCmd.Flags().IntVar(&sampleCount, "sample-count", 0, "check only a random sample of this number of files with a checksum file")
+	checkCmd.Flags().BoolVar(&incrementalCheck, "incremental", false, "skip the files that matched their checksum file in a previous check and did not change since then")
+	checkCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "stop checking after this duration, like 2h, keeping a checkpoint for --resume and exiting with code 3 (default: no limit)")
+	checkCmd.Flags().BoolVar(&resumeFromCheckpoint, "resume", false, "skip the files checked by a previous interrupted check of the same paths, reusing their results")
+	checkCmd.Flags().StringVar(&partialReportPath, "partial-report", partialReportPath, partialReportPathUsage)
+	checkCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	checkCmd.Flags().StringVar(&lockScope, "lock", noLockScope, lockScopeUsage)
+	checkCmd.Flags().DurationVar(&lockWait, "lock-wait", 0, lockWaitUsage)
+	checkCmd.Flags().IntVar(&unstableRetries, "unstable-retries", 0, unstableRetriesUsage)
+	checkCmd.Flags().DurationVar(&fileTimeout, "file-timeout", 0, fileTimeoutUsage)
+	checkCmd.Flags().IntVar(&maxErrors, "max-errors", 0, maxErrorsUsage)
+	checkCmd.Flags().BoolVar(&failFast, "fail-fast", false, failFastUsage)
+	checkCmd.Flags().BoolVar(&readOnlyMode, "read-only", false, readOnlyModeUsage)
+	checkCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	checkCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	checkCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	checkCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	checkCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+	checkCmd.Flags().StringVar(&metricsTextfile, "metrics-textfile", "", metricsTextfileUsage)
+	checkCmd.Flags().StringVar(&webhookURL, "webhook-url", "", webhookURLUsage)
+	checkCmd.Flags().StringVar(&pingURL, "ping-url", "", pingURLUsage)
+	checkCmd.Flags().StringVar(&checkStatePath, "state-file", checkStatePath, checkStatePathUsage)
+	checkCmd.Flags().StringSliceVar(&emailRecipients, "email-to", nil, "send the report of the check by email to these addresses, through the --smtp-server")
+	checkCmd.Flags().StringVar(&smtpServer, "smtp-server", "", "host and port of the SMTP server used by --email-to, like smtp.example.com:587")
+	checkCmd.Flags().StringVar(&smtpUsername, "smtp-username", "", "username of the SMTP server used by --email-to")
+	checkCmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "password of the SMTP server used by --email-to, better set in the configuration file than in the command line (default: $SMTP_PASSWORD)")
+	checkCmd.Flags().StringVar(&smtpSender, "smtp-from", "", "address the reports of --email-to are sent from (default: the SMTP username)")
+	checkCmd.Flags().StringVar(&slackWebhookURL, "slack-webhook-url", "", "incoming webhook of the Slack channel notified when files do not match or fail")
+	checkCmd.Flags().StringVar(&discordWebhookURL, "discord-webhook-url", "", "webhook of the Discord channel notified when files do not match or fail")
+	checkCmd.Flags().StringVar(&telegramBotToken, "telegram-bot-token", "", "token of the Telegram bot that notifies the --telegram-chat-id when files do not match or fail")
+	checkCmd.Flags().StringVar(&telegramChatID, "telegram-chat-id", "", "Telegram chat notified by the --telegram-bot-token")
+	checkCmd.Flags().BoolVar(&recordHistory, "record-history", false, "record the results in the verification history of the catalog")
+	checkCmd.Flags().StringVar(&catalogPath, "catalog", defaultCatalogPath(), "path of the catalog database used by --record-history")
+	checkCmd.Flags().StringVar(&expectedChecksum, "expect", "", "verify a single file against this hexadecimal checksum instead of its checksum file (md5, sha1, sha256, sha384 or sha512)")
 }
 
 type ChecksumFileVerificationStatus string
 
 const (
-	Match              ChecksumFileVerificationStatus = "Match"
-	NotMatch           ChecksumFileVerificationStatus = "NotMatch"
-	NotFound           ChecksumFileVerificationStatus = "NotFound"
-	CheckingFailed     ChecksumFileVerificationStatus = "CheckingFailed"
-	LockedVerification ChecksumFileVerificationStatus = "Locked"
+	Match                ChecksumFileVerificationStatus = "Match"
+	NotMatch             ChecksumFileVerificationStatus = "NotMatch"
+	NotFound             ChecksumFileVerificationStatus = "NotFound"
+	CheckingFailed       ChecksumFileVerificationStatus = "CheckingFailed"
+	LockedVerification   ChecksumFileVerificationStatus = "Locked"
+	BadSignature         ChecksumFileVerificationStatus = "BadSignature"
+	Orphaned             ChecksumFileVerificationStatus = "Orphaned"
+	Moved                ChecksumFileVerificationStatus = "Moved"
+	SkippedVerification  ChecksumFileVerificationStatus = "Skipped"
+	Unchanged            ChecksumFileVerificationStatus = "Unchanged"
+	SpecialVerification  ChecksumFileVerificationStatus = "Special"
+	Malformed            ChecksumFileVerificationStatus = "Malformed"
+	UnstableVerification ChecksumFileVerificationStatus = "Unstable"
+	TimedOutVerification ChecksumFileVerificationStatus = "TimedOut"
 )
 
 type ChecksumFileVerificationResult struct {
-	Path   string
-	Status ChecksumFileVerificationStatus
-	Error  error
+	Path          string
+	Status        ChecksumFileVerificationStatus
+	Error         error
+	MovedFrom     string
+	QuarantinedTo string
+	// ListedIn is the checksum file listing several files through which the file was verified, when it has none of its own
+	ListedIn string
+}
+
+// runExpectedChecksumVerification verifies a single file against the checksum given with --expect
+// and exits with a non-zero code when it does not match
+func runExpectedChecksumVerification(path string) {
+	expectedChecksum = decodeDigest(strings.TrimSpace(expectedChecksum))
+	algorithm, err := algorithmForDigest(expectedChecksum)
+	if err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		printErrorsCheckingChecksumFiles()
+		os.Exit(1)
+	}
+
+	if fileInfo, err := os.Stat(path); err == nil && fileInfo.IsDir() {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, fmt.Errorf("%s is a directory, --expect requires a file", path))
+		printErrorsCheckingChecksumFiles()
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Println("Processing", path, "("+algorithm.Name+")")
+
+	resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
+	processPaths([]string{path}, &errorsCheckingChecksumFiles, func(filePath string) error {
+		return handleChecksumFileVerification(filePath, &resultsCheckingChecksumFiles, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+			return verifyExpectedChecksum(fileAbsolutePath, expectedChecksum, algorithm)
+		})
+	})
+
+	printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+	finishCheckRun(resultsCheckingChecksumFiles)
+	printErrorsCheckingChecksumFiles()
+
+	if len(errorsCheckingChecksumFiles) > 0 || len(resultsCheckingChecksumFiles) != 1 || resultsCheckingChecksumFiles[0].Status != Match {
+		os.Exit(1)
+	}
+}
+
+// runManifestVerification verifies the files listed in the manifest given with --manifest, after verifying
+// its signature when --verify-signature is used, and exits with a non-zero code when any of them does not match
+func runManifestVerification(args []string) {
+	// The directory where a manifest given with a URL is downloaded
+	var downloadDirectory string
+
+	failVerification := func(err error) {
+		if downloadDirectory != "" {
+			os.RemoveAll(downloadDirectory)
+		}
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		printErrorsCheckingChecksumFiles()
+		os.Exit(1)
+	}
+
+	manifestAbsolutePath, err := filepath.Abs(manifestPath)
+	if err != nil {
+		failVerification(err)
+	}
+	baseDirectory := filepath.Dir(manifestAbsolutePath)
+
+	// A manifest given with a URL is downloaded, and its paths are relative to the directory given, or to the
+	// current directory
+	if isURL(manifestPath) {
+		baseDirectory = "."
+		if len(args) == 1 {
+			baseDirectory = args[0]
+		}
+		if baseDirectory, err = filepath.Abs(baseDirectory); err != nil {
+			failVerification(err)
+		}
+		if info, err := os.Stat(baseDirectory); err != nil {
+			failVerification(err)
+		} else if !info.IsDir() {
+			failVerification(fmt.Errorf("%s is not a directory, --manifest with a URL requires the directory of the files listed in it", args[0]))
+		}
+
+		if downloadDirectory, err = os.MkdirTemp("", "checksum-utils-manifest-"); err != nil {
+			failVerification(err)
+		}
+		if manifestAbsolutePath, err = downloadManifest(manifestPath, downloadDirectory); err != nil {
+			failVerification(err)
+		}
+	}
+
+	content, err := os.ReadFile(manifestAbsolutePath)
+	if err != nil {
+		failVerification(err)
+	}
+
+	if verifySignatures {
+		signaturePath := manifestSignaturePath
+		if signaturePath == "" {
+			signaturePath, err = findManifestSignature(manifestAbsolutePath)
+			if err != nil {
+				failVerification(err)
+			}
+		}
+
+		if err := verifySignature(signaturePath, manifestAbsolutePath, keyringPath); err != nil {
+			failVerification(fmt.Errorf("the manifest %s is not trusted: %w", manifestPath, err))
+		}
+
+		fmt.Println()
+		fmt.Println("🔏 Signature verified:", signaturePath)
+	}
+
+	if downloadDirectory != "" {
+		os.RemoveAll(downloadDirectory)
+	}
+
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		failVerification(fmt.Errorf("%s: %w", manifestPath, err))
+	}
+	var rejectedResults []ChecksumFileVerificationResult
+	if isURL(manifestPath) {
+		entries, rejectedResults = localManifestEntries(entries, manifestPath)
+	}
+	entries = resolveManifestEntries(entries, baseDirectory)
+
+	paths, expandErrors, _ := expandArgs(args)
+	errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, expandErrors...)
+
+	selectedEntries, notListedPaths, selectErrors := selectManifestEntries(entries, paths)
+	errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, selectErrors...)
+
+	fmt.Println()
+	fmt.Println("Processing", manifestPath)
+
+	resultsCheckingChecksumFiles = append([]ChecksumFileVerificationResult{}, rejectedResults...)
+	for _, entry := range selectedEntries {
+		if isRunStopped(&errorsCheckingChecksumFiles) {
+			break
+		}
+		if err := handleChecksumFileVerification(entry.Path, &resultsCheckingChecksumFiles, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+			return checkExpectedChecksum(fileAbsolutePath, entry.Checksum, entry.Algorithm)
+		}); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
+	for _, path := range notListedPaths {
+		if isRunStopped(&errorsCheckingChecksumFiles) {
+			break
+		}
+		if err := handleChecksumFileVerification(path, &resultsCheckingChecksumFiles, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotFound, Error: nil}
+		}); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
+
+	printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+	finishCheckRun(resultsCheckingChecksumFiles)
+	printErrorsCheckingChecksumFiles()
+
+	if stoppedByFailFast.Load() {
+		printStoppedByFailFast()
+		os.Exit(1)
+	}
+	if stoppedByMaxErrors.Load() {
+		printStoppedByMaxErrors()
+		os.Exit(maxErrorsExitCode)
+	}
+	if len(errorsCheckingChecksumFiles) > 0 {
+		os.Exit(1)
+	}
+	for _, result := range resultsCheckingChecksumFiles {
+		if result.Status != Match {
+			os.Exit(1)
+		}
+	}
 }
 
-func handleChecksumFileVerification(filePath string, results *[]ChecksumFileVerificationResult) error {
+// selectManifestEntries returns the entries of the manifest for the files and directories of the paths,
+// and the files of the paths that are not listed in the manifest. Without paths, all the entries are selected.
+func selectManifestEntries(entries []manifestEntry, paths []string) ([]manifestEntry, []string, []error) {
+	if len(paths) == 0 {
+		return entries, nil, nil
+	}
+
+	var selected []manifestEntry
+	var notListed []string
+	var errs []error
+	alreadySelected := make(map[string]bool)
+
+	for _, path := range paths {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fileInfo, err := os.Stat(absolutePath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		listed := false
+		normalizedAbsolutePath := normalizedPath(absolutePath)
+		for _, entry := range entries {
+			normalizedEntryPath := normalizedPath(entry.Path)
+			matches := normalizedEntryPath == normalizedAbsolutePath
+			if fileInfo.IsDir() {
+				matches = strings.HasPrefix(normalizedEntryPath, normalizedAbsolutePath+string(filepath.Separator))
+			}
+			if !matches {
+				continue
+			}
+
+			listed = true
+			if !alreadySelected[entry.Path] {
+				alreadySelected[entry.Path] = true
+				selected = append(selected, entry)
+			}
+		}
+
+		if !listed && !fileInfo.IsDir() {
+			notListed = append(notListed, absolutePath)
+		}
+	}
+
+	return selected, notListed, errs
+}
+
+// isMaxDurationReached reports whether the check has to stop because of --max-duration
+func isMaxDurationReached() bool {
+	return !checkDeadline.IsZero() && time.Now().After(checkDeadline)
+}
+
+// verifyChecksumFile checks the checksum file of the file, verifying first its signature when --verify-signature is used
+func verifyChecksumFile(fileAbsolutePath string) ChecksumFileVerificationResult {
+	return verifyChecksumFileWith(fileAbsolutePath, nil)
+}
+
+// verifyChecksumFileWith verifies the file like verifyChecksumFile, reading its content through wrap when it is not
+// nil, so the reads can be observed or controlled
+func verifyChecksumFileWith(fileAbsolutePath string, wrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+	if verifySignatures {
+		checksumFile := checksumFilePath(fileAbsolutePath)
+		if _, err := os.Stat(checksumFile); err == nil {
+			if err := verifySignature(checksumFile+signatureExtension, checksumFile, keyringPath); err != nil {
+				return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: BadSignature, Error: err}
+			}
+		}
+	}
+
+	return verifyWithLimits(fileAbsolutePath, func(limitsWrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+		return checkChecksumFileWith(fileAbsolutePath, chainWraps(limitsWrap, wrap))
+	})
+}
+
+// verifyWithLimits verifies the file with check, abandoning it after --file-timeout and verifying it again while it is
+// modified during the hashing, up to --unstable-retries times
+func verifyWithLimits(fileAbsolutePath string, check func(wrap func(io.Reader) io.Reader) ChecksumFileVerificationResult) ChecksumFileVerificationResult {
+	return retryUnstable(func() ChecksumFileVerificationResult {
+		return withFileTimeout(check, func(err error) ChecksumFileVerificationResult {
+			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: TimedOutVerification, Error: err}
+		})
+	}, func(result ChecksumFileVerificationResult) bool {
+		return result.Status == UnstableVerification
+	})
+}
+
+func handleChecksumFileVerification(filePath string, results *[]ChecksumFileVerificationResult, verify func(string) ChecksumFileVerificationResult) error {
+	return handleChecksumFileVerificationWith(filePath, results, verify, nil)
+}
+
+// handleChecksumFileVerificationWith verifies the file with verify, or the files listed in the checksum file reading
+// their content through wrap when it is not nil, so the reads can be observed or controlled
+func handleChecksumFileVerificationWith(filePath string, results *[]ChecksumFileVerificationResult, verify func(string) ChecksumFileVerificationResult, wrap func(io.Reader) io.Reader) error {
 	fileAbsolutePath, err := filepath.Abs(filePath)
 	if err != nil {
 		return err
 	}
 
-	ext := filepath.Ext(fileAbsolutePath)
-	if ext == ".sha512" {
+	if isChecksumFile(fileAbsolutePath) {
+		if checkTagManifest(fileAbsolutePath, results) {
+			return nil
+		}
+		if checkListedFilesWith(fileAbsolutePath, results, wrap) {
+			return nil
+		}
+		if result, orphaned := checkOrphanedChecksumFile(fileAbsolutePath); orphaned {
+			appendLocked(results, result)
+		}
 		return nil
 	}
 
+	verificationReporter(fileAbsolutePath, results, verify)
+
+	if intoArchives && isArchive(fileAbsolutePath) {
+		checkArchiveMembers(fileAbsolutePath, results)
+	}
+
+	return nil
+}
+
+// verificationReporter verifies each file found by the check and records its result, printing it by default; the
+// TUI replaces it to show the results in its screen instead
+var verificationReporter = reportChecksumFileVerification
+
+// reportChecksumFileVerification verifies the file showing the progress, records and prints the result
+func reportChecksumFileVerification(fileAbsolutePath string, results *[]ChecksumFileVerificationResult, verify func(string) ChecksumFileVerificationResult) {
 	prefix := fmt.Sprintf("- %s ", fileAbsolutePath)
-	spinner := startProgress(prefix)
+	// The spinners of several jobs would overwrite each other
+	spinner := &progressSpinner{}
+	if jobs <= 1 {
+		spinner = startProgress(prefix)
+	}
+	activeRunStatus.beginFile(fileAbsolutePath)
 	start := time.Now()
-	result := checkChecksumFile(fileAbsolutePath)
+	result := verify(fileAbsolutePath)
 	elapsed := time.Since(start)
+	activeRunStatus.finishFile(fileAbsolutePath)
 	spinner.Stop()
+	countFailedFile(result.Status == CheckingFailed || result.Status == LockedVerification || result.Status == TimedOutVerification)
+	failFastOn(checkVerificationOutcome(result.Status) == verificationFailed || result.Status == LockedVerification)
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 
 	*results = append(*results, result)
+	alertMismatch(result)
+
+	if activeCheckpoint != nil {
+		if err := activeCheckpoint.record(result); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
+	if activeVerificationCache != nil {
+		activeVerificationCache.update(result)
+	}
+
+	if historyCatalog != nil {
+		if err := recordVerification(historyCatalog, fileAbsolutePath, "check", string(result.Status), checkVerificationOutcome(result.Status), result.Error); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
 
 	if spinner.Enabled() {
 		clearProgressLine(prefix)
@@ -138,19 +757,36 @@ func handleChecksumFileVerification(filePath string, results *[]ChecksumFileVeri
 		fmt.Print("🔒")
 	case CheckingFailed:
 		fmt.Print("❌")
+	case BadSignature:
+		fmt.Print("🔏")
+	case SpecialVerification:
+		fmt.Print("🔌")
+	case Malformed:
+		fmt.Print("🧩")
+	case UnstableVerification:
+		fmt.Print("🌀")
+	case TimedOutVerification:
+		fmt.Print("⌛")
 	}
 
-	if result.Status != NotFound && result.Status != LockedVerification {
+	if result.Status != NotFound && result.Status != LockedVerification && result.Status != BadSignature && result.Status != SpecialVerification && result.Status != Malformed {
 		fmt.Printf(" (%s)", formatDuration(elapsed))
 	}
 	fmt.Println()
-
-	return nil
 }
 
 func checkChecksumFile(fileAbsolutePath string) ChecksumFileVerificationResult {
-	file, err := os.Open(fileAbsolutePath)
+	return checkChecksumFileWith(fileAbsolutePath, nil)
+}
+
+// checkChecksumFileWith checks the checksum file of the file, reading its content through wrap when it is not nil,
+// so the reads can be observed or controlled
+func checkChecksumFileWith(fileAbsolutePath string, wrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+	file, err := openDataFile(fileAbsolutePath)
 	if err != nil {
+		if _, special := err.(*specialFileError); special {
+			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: SpecialVerification, Error: err}
+		}
 		if os.IsPermission(err) {
 			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: LockedVerification, Error: err}
 		}
@@ -158,42 +794,502 @@ func checkChecksumFile(fileAbsolutePath string) ChecksumFileVerificationResult {
 	}
 	defer file.Close()
 
-	if _, err := os.Stat(fileAbsolutePath + ".sha512"); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotFound, Error: nil}
+	if stored, err := hasStoredChecksum(fileAbsolutePath); err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	} else if !stored {
+		// The files without a checksum file can have a .checksums file with the checksums of several algorithms
+		if _, err := os.Stat(fileAbsolutePath + multiHashExtension); err == nil && storeMode == sidecarStoreMode && storeDirectory == "" {
+			return checkMultiHashFile(fileAbsolutePath, wrapReader(file, wrap))
 		}
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotFound, Error: nil}
+	}
+
+	content, err := readStoredChecksum(fileAbsolutePath)
+	if err != nil {
 		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
 	}
+	checksum, listed := content.checksumOfFile(fileAbsolutePath)
+	if !listed && len(content.Entries) <= 1 {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Malformed, Error: fmt.Errorf("the checksum file is empty")}
+	}
+	if !listed {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotFound, Error: nil}
+	}
+	// A truncated or corrupted checksum file would otherwise be reported as a file that does not match
+	if err := validateChecksum(checksum, sha512Algorithm); err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Malformed, Error: err}
+	}
 
-	// Create a new SHA512 hash object
-	hash := sha512.New()
+	// A different size is enough to know that the content changed, without reading the whole file
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	}
+	if content.Size >= 0 && fileInfo.Size() != content.Size {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotMatch, Error: fmt.Errorf("modified after the checksum was created, the size changed from %d to %d bytes", content.Size, fileInfo.Size())}
+	}
 
-	// Copy the file content to the hash object
-	if _, err := io.Copy(hash, file); err != nil {
+	hexFileChecksum, err := hashLinkedFile(fileAbsolutePath, wrapReader(file, wrap))
+	if err != nil {
 		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
 	}
 
-	// Get the checksum as a byte slice
-	fileChecksum := hash.Sum(nil)
+	// A file being written would otherwise be reported as a file that does not match
+	if err := modifiedWhileHashing(fileAbsolutePath, fileInfo); err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: UnstableVerification, Error: err}
+	}
+
+	if strings.EqualFold(hexFileChecksum, checksum) {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Match, Error: nil}
+	}
+
+	return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotMatch, Error: classifyMismatch(content, fileInfo)}
+}
+
+// classifyMismatch explains why the file does not match its checksum when its metadata was recorded with --record-size:
+// a file modified after the checksum was created has a different modification time, while the same size and
+// modification time with a different content point to bit rot. It returns nil when the metadata was not recorded.
+func classifyMismatch(content checksumFileContent, fileInfo os.FileInfo) error {
+	if content.ModTime.IsZero() {
+		return nil
+	}
+	if !fileInfo.ModTime().Equal(content.ModTime) {
+		return fmt.Errorf("modified after the checksum was created, the modification time changed from %s to %s", content.ModTime.Format(time.RFC3339), fileInfo.ModTime().Format(time.RFC3339))
+	}
+	return fmt.Errorf("probable bit rot, the content changed but the size and the modification time did not")
+}
+
+// validateChecksum returns an error when the checksum is not a hexadecimal checksum of the length of the algorithm
+func validateChecksum(hexChecksum string, algorithm hashAlgorithm) error {
+	if _, err := hex.DecodeString(hexChecksum); err != nil {
+		return fmt.Errorf("%q is not a hexadecimal checksum", hexChecksum)
+	}
+	if length := algorithm.New().Size() * 2; len(hexChecksum) != length {
+		return fmt.Errorf("the checksum has %d characters instead of the %d of a %s checksum, the checksum file may be truncated", len(hexChecksum), length, algorithm.Name)
+	}
+	return nil
+}
+
+// chainWraps returns the wrap reading through inner and then through outer, either of which can be nil
+func chainWraps(inner func(io.Reader) io.Reader, outer func(io.Reader) io.Reader) func(io.Reader) io.Reader {
+	return func(reader io.Reader) io.Reader {
+		return wrapReader(wrapReader(reader, inner), outer)
+	}
+}
+
+// wrapReader returns the reader wrapped by wrap, or the reader itself when wrap is nil
+func wrapReader(reader io.Reader, wrap func(io.Reader) io.Reader) io.Reader {
+	if wrap == nil {
+		return reader
+	}
+	return wrap(reader)
+}
+
+// sizeMetadataPrefix starts the line of a checksum file that records the size of the file, written by --record-size
+const sizeMetadataPrefix = "# size: "
+
+// modTimeMetadataPrefix starts the line of a checksum file that records the modification time of the file,
+// written by --record-size
+const modTimeMetadataPrefix = "# mtime: "
+
+// checksumFileContent is the content of a checksum file
+type checksumFileContent struct {
+	Checksum string
+	// Size is the size of the file when its checksum was computed, or -1 when it was not recorded
+	Size int64
+	// ModTime is the modification time of the file when its checksum was computed, or zero when it was not recorded
+	ModTime time.Time
+	// Entries are the checksums of the "<checksum>  <file>" lines, several when the checksum file lists several files
+	Entries []checksumFileEntry
+}
+
+// checksumFileEntry is a "<checksum>  <file>" line of a checksum file
+type checksumFileEntry struct {
+	Name     string
+	Checksum string
+}
+
+// parseChecksumFile parses the content of a checksum file: the checksum in hexadecimal or base64, alone or in a
+// "<checksum>  <file>" line of sha512sum, or in several of them when it lists several files, preceded by optional
+// "# " metadata lines. The byte order mark, the CRLF
+// line endings and the surrounding whitespace of the checksum files edited on other systems are ignored.
+func parseChecksumFile(content string) checksumFileContent {
+	parsed := checksumFileContent{Size: -1}
+	content = strings.TrimPrefix(content, "\ufeff")
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, sizeMetadataPrefix) {
+			if size, err := strconv.ParseInt(strings.TrimPrefix(line, sizeMetadataPrefix), 10, 64); err == nil {
+				parsed.Size = size
+			}
+			continue
+		}
+		if strings.HasPrefix(line, modTimeMetadataPrefix) {
+			if modTime, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(line, modTimeMetadataPrefix)); err == nil {
+				parsed.ModTime = modTime
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(line, `\`)
+		line = strings.TrimPrefix(line, `\`)
+		separator := strings.IndexAny(line, " \t")
+		if separator < 0 {
+			parsed.Checksum = decodeDigest(line)
+			continue
+		}
+		parsed.Checksum = decodeDigest(line[:separator])
+		if name := strings.TrimPrefix(strings.TrimLeft(line[separator:], " \t"), "*"); name != "" {
+			if escaped {
+				name = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(name)
+			}
+			parsed.Entries = append(parsed.Entries, checksumFileEntry{Name: name, Checksum: parsed.Checksum})
+		}
+	}
+	return parsed
+}
+
+// checksumOfFile returns the checksum of the file in the checksum file: the one of its line when the checksum file
+// lists several files, like a manifest, or else its only checksum
+func (c checksumFileContent) checksumOfFile(fileAbsolutePath string) (string, bool) {
+	if len(c.Entries) <= 1 {
+		return c.Checksum, c.Checksum != ""
+	}
+	for _, entry := range c.Entries {
+		if entry.Name == filepath.Base(fileAbsolutePath) {
+			return entry.Checksum, true
+		}
+	}
+	return "", false
+}
+
+// checkListedFiles verifies the other files listed in the checksum file when it lists several files, like a
+// manifest, and reports whether it does
+func checkListedFiles(checksumFileAbsolutePath string, results *[]ChecksumFileVerificationResult) bool {
+	return checkListedFilesWith(checksumFileAbsolutePath, results, nil)
+}
+
+// checkListedFilesWith verifies the files listed in the checksum file like checkListedFiles, reading their content
+// through wrap when it is not nil
+func checkListedFilesWith(checksumFileAbsolutePath string, results *[]ChecksumFileVerificationResult, wrap func(io.Reader) io.Reader) bool {
+	if !hasSuffixFold(checksumFileAbsolutePath, checksumFileExtension) || hasSuffixFold(checksumFileAbsolutePath, archiveManifestExtension) {
+		return false
+	}
+	content, err := readChecksumFileContent(checksumFileAbsolutePath)
+	if err != nil || len(content.Entries) <= 1 {
+		return false
+	}
+
+	// The other files are only trusted when the signature of the checksum file is valid, with --verify-signature
+	var signatureError error
+	if verifySignatures {
+		signatureError = verifySignature(checksumFileAbsolutePath+signatureExtension, checksumFileAbsolutePath, keyringPath)
+	}
+
+	// The file of the checksum file is verified on its own
+	ownFileAbsolutePath := normalizedPath(trimChecksumFileExtension(checksumFileAbsolutePath))
+	for _, entry := range content.Entries {
+		// An absolute entry, or one leaving the directory of the checksum file, would verify files outside the tree
+		if !filepath.IsLocal(filepath.FromSlash(entry.Name)) {
+			appendLocked(results, ChecksumFileVerificationResult{Path: checksumFileAbsolutePath, Status: Malformed, Error: fmt.Errorf("%q is not a file inside the directory of the checksum file", entry.Name)})
+			continue
+		}
+		fileAbsolutePath := resolveNormalizationVariant(filepath.Join(filepath.Dir(checksumFileAbsolutePath), filepath.FromSlash(entry.Name)))
+		if normalizedPath(fileAbsolutePath) == ownFileAbsolutePath {
+			continue
+		}
+		// The walk skips the files filtered out, and verifies the ones with their own checksum file on their own
+		if isFilteredOutBelow(checkedDirectoryOf(checksumFileAbsolutePath), fileAbsolutePath) {
+			continue
+		}
+		if stored, err := hasStoredChecksum(fileAbsolutePath); err == nil && stored {
+			continue
+		}
+
+		if signatureError != nil {
+			verificationReporter(fileAbsolutePath, results, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+				return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: BadSignature, Error: signatureError}
+			})
+			continue
+		}
+
+		algorithm, err := algorithmForDigest(entry.Checksum)
+		if err != nil {
+			appendLocked(results, ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Malformed, Error: fmt.Errorf("%s: %w", checksumFileAbsolutePath, err)})
+			continue
+		}
+		verificationReporter(fileAbsolutePath, results, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+			result := verifyWithLimits(fileAbsolutePath, func(limitsWrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+				return checkExpectedChecksumWith(fileAbsolutePath, entry.Checksum, algorithm, chainWraps(limitsWrap, wrap))
+			})
+			result.ListedIn = checksumFileAbsolutePath
+			return result
+		})
+	}
+	return true
+}
+
+// checkedDirectories are the directories walked by the check, the roots of the filters of the files listed in the
+// checksum files found in them
+var checkedDirectories []string
+
+// setCheckedDirectories records the directories among the paths given to the check
+func setCheckedDirectories(paths []string) {
+	checkedDirectories = nil
+	for _, path := range paths {
+		if fileInfo, err := os.Stat(path); err == nil && fileInfo.IsDir() {
+			if directoryAbsolutePath, err := filepath.Abs(path); err == nil {
+				checkedDirectories = append(checkedDirectories, directoryAbsolutePath)
+			}
+		}
+	}
+}
+
+// checkedDirectoryOf returns the walked directory containing the checksum file, or its own directory when it was not
+// found by walking a directory
+func checkedDirectoryOf(checksumFileAbsolutePath string) string {
+	root := filepath.Dir(checksumFileAbsolutePath)
+	found := ""
+	for _, directory := range checkedDirectories {
+		if strings.HasPrefix(checksumFileAbsolutePath, directory+string(filepath.Separator)) && len(directory) > len(found) {
+			found = directory
+		}
+	}
+	if found != "" {
+		return found
+	}
+	return root
+}
+
+// dropListedNotFound removes the files without a checksum file that were verified because another checksum file
+// lists them
+func dropListedNotFound(results []ChecksumFileVerificationResult) []ChecksumFileVerificationResult {
+	verified := make(map[string]bool)
+	for _, result := range results {
+		if result.Status != NotFound {
+			verified[result.Path] = true
+		}
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if result.Status == NotFound && verified[result.Path] {
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// readChecksumFileContent reads and parses the checksum file
+func readChecksumFileContent(checksumFilePath string) (checksumFileContent, error) {
+	checksumFileContentByteArray, err := os.ReadFile(checksumFilePath)
+	if err != nil {
+		return checksumFileContent{}, err
+	}
+
+	return parseChecksumFile(string(checksumFileContentByteArray)), nil
+}
+
+// readChecksumFile returns the checksum stored in the checksum file
+func readChecksumFile(checksumFilePath string) (string, error) {
+	content, err := readChecksumFileContent(checksumFilePath)
+	return content.Checksum, err
+}
+
+// checkOrphanedChecksumFile returns an Orphaned result when the file of the checksum file does not exist anymore
+func checkOrphanedChecksumFile(checksumFileAbsolutePath string) (ChecksumFileVerificationResult, bool) {
+	if !hasSuffixFold(checksumFileAbsolutePath, checksumFileExtension) || hasSuffixFold(checksumFileAbsolutePath, archiveManifestExtension) {
+		return ChecksumFileVerificationResult{}, false
+	}
+
+	fileAbsolutePath := trimChecksumFileExtension(checksumFileAbsolutePath)
+	if _, err := os.Lstat(fileAbsolutePath); !errors.Is(err, os.ErrNotExist) {
+		return ChecksumFileVerificationResult{}, false
+	}
+	if _, found := normalizationVariant(fileAbsolutePath); found {
+		return ChecksumFileVerificationResult{}, false
+	}
+
+	return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Orphaned, Error: nil}, true
+}
+
+// detectMovedFiles pairs the orphaned checksum files with the files without a checksum file that have the same checksum,
+// reporting them as moved or renamed, and moving the checksum files next to them when --relocate is used
+func detectMovedFiles(results []ChecksumFileVerificationResult) []ChecksumFileVerificationResult {
+	orphanedIndexesByChecksum := make(map[string][]int)
+	hasOrphans := false
+	for i, result := range results {
+		if result.Status != Orphaned {
+			continue
+		}
+		hasOrphans = true
+
+		checksum, err := readChecksumFile(checksumFilePath(result.Path))
+		if err != nil {
+			results[i].Error = err
+			continue
+		}
+		checksum = strings.ToLower(checksum)
+		orphanedIndexesByChecksum[checksum] = append(orphanedIndexesByChecksum[checksum], i)
+	}
+
+	if !hasOrphans {
+		return results
+	}
+
+	moved := make(map[int]bool)
+	for i, result := range results {
+		if result.Status != NotFound || len(orphanedIndexesByChecksum) == 0 {
+			continue
+		}
+
+		file, err := openDataFile(result.Path)
+		if err != nil {
+			continue
+		}
+		hexFileChecksum, err := hashReader(file)
+		file.Close()
+		if err != nil {
+			continue
+		}
+
+		orphanedIndexes := orphanedIndexesByChecksum[hexFileChecksum]
+		if len(orphanedIndexes) == 0 {
+			continue
+		}
+		orphanedIndex := orphanedIndexes[0]
+		orphanedIndexesByChecksum[hexFileChecksum] = orphanedIndexes[1:]
+		if len(orphanedIndexesByChecksum[hexFileChecksum]) == 0 {
+			delete(orphanedIndexesByChecksum, hexFileChecksum)
+		}
+
+		moved[orphanedIndex] = true
+		results[i] = ChecksumFileVerificationResult{Path: result.Path, Status: Moved, Error: nil, MovedFrom: results[orphanedIndex].Path}
+
+		if relocateChecksumFiles {
+			if err := relocateChecksumFile(results[i].MovedFrom, results[i].Path); err != nil {
+				results[i].Error = err
+			}
+		}
+	}
+
+	var detected []ChecksumFileVerificationResult
+	for i, result := range results {
+		if moved[i] {
+			continue
+		}
+
+		switch result.Status {
+		case Moved:
+			fmt.Printf("- %s → %s 🚚", result.MovedFrom, result.Path)
+			if result.Error != nil {
+				fmt.Print(" | Error: ", result.Error)
+			}
+			fmt.Println()
+		case Orphaned:
+			fmt.Printf("- %s 🗑️\n", result.Path)
+		}
+
+		detected = append(detected, result)
+	}
+
+	return detected
+}
 
-	// Convert the checksum to a hexadecimal string
-	hexFileChecksum := hex.EncodeToString(fileChecksum)
+// relocateChecksumFile moves the checksum file, and its signature when it exists, from the old path of the file to the new one
+func relocateChecksumFile(oldFileAbsolutePath string, newFileAbsolutePath string) error {
+	oldChecksumFilePath := checksumFilePath(oldFileAbsolutePath)
+	newChecksumFilePath := checksumFilePath(newFileAbsolutePath)
+	if storeDirectory != "" {
+		if err := os.MkdirAll(filepath.Dir(newChecksumFilePath), 0o755); err != nil {
+			return err
+		}
+	}
 
-	checksumFileContentByteArray, err := os.ReadFile(fileAbsolutePath + ".sha512")
+	if err := os.Rename(oldChecksumFilePath, newChecksumFilePath); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(oldChecksumFilePath + signatureExtension); err == nil {
+		return os.Rename(oldChecksumFilePath+signatureExtension, newChecksumFilePath+signatureExtension)
+	}
+
+	return nil
+}
+
+// checkVerificationOutcome classifies the check status for the verification history
+func checkVerificationOutcome(status ChecksumFileVerificationStatus) verificationOutcome {
+	switch status {
+	case Match:
+		return verificationPassed
+	case NotMatch, CheckingFailed, BadSignature, Malformed, TimedOutVerification:
+		return verificationFailed
+	}
+	return verificationInconclusive
+}
+
+// checkExpectedChecksum compares the checksum of the file with the expected hexadecimal checksum
+func checkExpectedChecksum(fileAbsolutePath string, expected string, algorithm hashAlgorithm) ChecksumFileVerificationResult {
+	return checkExpectedChecksumWith(fileAbsolutePath, expected, algorithm, nil)
+}
+
+// verifyExpectedChecksum verifies the file against the expected checksum within the limits of --file-timeout and
+// --unstable-retries, like the files verified against their checksum file
+func verifyExpectedChecksum(fileAbsolutePath string, expected string, algorithm hashAlgorithm) ChecksumFileVerificationResult {
+	return verifyWithLimits(fileAbsolutePath, func(wrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+		return checkExpectedChecksumWith(fileAbsolutePath, expected, algorithm, wrap)
+	})
+}
+
+// checkExpectedChecksumWith verifies the file against the expected checksum, reading its content through wrap when it
+// is not nil, so the reads can be observed or controlled
+func checkExpectedChecksumWith(fileAbsolutePath string, expected string, algorithm hashAlgorithm, wrap func(io.Reader) io.Reader) ChecksumFileVerificationResult {
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		if _, special := err.(*specialFileError); special {
+			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: SpecialVerification, Error: err}
+		}
+		if os.IsPermission(err) {
+			return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: LockedVerification, Error: err}
+		}
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	}
+	defer file.Close()
+
+	fileInfo, err := os.Stat(fileAbsolutePath)
 	if err != nil {
 		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
 	}
 
-	checksumFileContentString := strings.TrimSpace(string(checksumFileContentByteArray))
+	// The hard links of a file already hashed reuse its SHA-512 checksum
+	var hexFileChecksum string
+	if algorithm.Name == sha512Algorithm.Name {
+		hexFileChecksum, err = hashLinkedFile(fileAbsolutePath, wrapReader(file, wrap))
+	} else {
+		hexFileChecksum, err = hashReaderWith(wrapReader(file, wrap), algorithm)
+	}
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	}
 
-	if strings.EqualFold(hexFileChecksum, checksumFileContentString) {
+	if err := modifiedWhileHashing(fileAbsolutePath, fileInfo); err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: UnstableVerification, Error: err}
+	}
+
+	if strings.EqualFold(hexFileChecksum, strings.TrimSpace(expected)) {
 		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Match, Error: nil}
 	}
 
 	return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotMatch, Error: nil}
 }
 
+// printResultsCheckingChecksumFiles prints the summary of the results, sorting them by path first
 func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult) {
+	sortByPath(results, func(result ChecksumFileVerificationResult) string { return result.Path })
+
 	if len(results) > 0 {
 		fmt.Println("Results:", len(results), "files processed")
 	}
@@ -203,6 +1299,15 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 	var notExistingResults []ChecksumFileVerificationResult
 	var lockedResults []ChecksumFileVerificationResult
 	var failedResults []ChecksumFileVerificationResult
+	var badSignatureResults []ChecksumFileVerificationResult
+	var movedResults []ChecksumFileVerificationResult
+	var orphanedResults []ChecksumFileVerificationResult
+	var skippedResults []ChecksumFileVerificationResult
+	var unchangedResults []ChecksumFileVerificationResult
+	var specialResults []ChecksumFileVerificationResult
+	var malformedResults []ChecksumFileVerificationResult
+	var unstableResults []ChecksumFileVerificationResult
+	var timedOutResults []ChecksumFileVerificationResult
 
 	for _, result := range results {
 		switch result.Status {
@@ -216,6 +1321,24 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 			lockedResults = append(lockedResults, result)
 		case CheckingFailed:
 			failedResults = append(failedResults, result)
+		case BadSignature:
+			badSignatureResults = append(badSignatureResults, result)
+		case Moved:
+			movedResults = append(movedResults, result)
+		case Orphaned:
+			orphanedResults = append(orphanedResults, result)
+		case SkippedVerification:
+			skippedResults = append(skippedResults, result)
+		case Unchanged:
+			unchangedResults = append(unchangedResults, result)
+		case SpecialVerification:
+			specialResults = append(specialResults, result)
+		case Malformed:
+			malformedResults = append(malformedResults, result)
+		case UnstableVerification:
+			unstableResults = append(unstableResults, result)
+		case TimedOutVerification:
+			timedOutResults = append(timedOutResults, result)
 		}
 	}
 
@@ -227,6 +1350,20 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 		fmt.Println("⚠️ :", len(notMatchedResults), "checksum files not match")
 		for _, notMatchedResult := range notMatchedResults {
 			fmt.Print("- ", notMatchedResult.Path)
+			if notMatchedResult.Error != nil {
+				fmt.Print(" | ", notMatchedResult.Error)
+			}
+			if notMatchedResult.QuarantinedTo != "" {
+				fmt.Print(" (quarantined in ", notMatchedResult.QuarantinedTo, ")")
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(malformedResults) > 0 {
+		fmt.Println("🧩 :", len(malformedResults), "checksum files empty, truncated or with an invalid checksum")
+		for _, malformedResult := range malformedResults {
+			fmt.Print("- ", malformedResult.Path, " | Error: ", malformedResult.Error)
 			fmt.Println()
 		}
 	}
@@ -239,6 +1376,63 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 		}
 	}
 
+	if len(movedResults) > 0 {
+		fmt.Println("🚚 :", len(movedResults), "files moved or renamed, with the same checksum as an orphaned checksum file")
+		for _, movedResult := range movedResults {
+			fmt.Print("- ", movedResult.MovedFrom, " → ", movedResult.Path)
+			if movedResult.Error != nil {
+				fmt.Print(" | Error: ", movedResult.Error)
+			} else if relocateChecksumFiles {
+				fmt.Print(" (checksum file relocated)")
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(orphanedResults) > 0 {
+		fmt.Println("🗑️ :", len(orphanedResults), "checksum files whose file no longer exists")
+		for _, orphanedResult := range orphanedResults {
+			fmt.Print("- ", checksumFilePath(orphanedResult.Path))
+			fmt.Println()
+		}
+	}
+
+	if len(specialResults) > 0 {
+		fmt.Println("🔌 :", len(specialResults), "special files skipped")
+		for _, specialResult := range specialResults {
+			fmt.Print("- ", specialResult.Path, " (", specialResult.Error, ")")
+			fmt.Println()
+		}
+	}
+
+	if len(unchangedResults) > 0 {
+		fmt.Println("💤 :", len(unchangedResults), "files not checked because they did not change since they matched")
+	}
+
+	if len(skippedResults) > 0 {
+		fmt.Println("⏭️ :", len(skippedResults), "files skipped")
+		for _, skippedResult := range skippedResults {
+			fmt.Print("- ", skippedResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(unstableResults) > 0 {
+		fmt.Println("🌀 :", len(unstableResults), "files modified while they were checked")
+		for _, unstableResult := range unstableResults {
+			fmt.Print("- ", unstableResult.Path, " | ", unstableResult.Error)
+			fmt.Println()
+		}
+	}
+
+	if len(timedOutResults) > 0 {
+		fmt.Println("⌛ :", len(timedOutResults), "files abandoned after --file-timeout")
+		for _, timedOutResult := range timedOutResults {
+			fmt.Print("- ", timedOutResult.Path, " | ", timedOutResult.Error)
+			fmt.Println()
+		}
+	}
+
 	if len(lockedResults) > 0 {
 		fmt.Println("🔒 :", len(lockedResults), "files could not be read due to permissions")
 		for _, lockedResult := range lockedResults {
@@ -247,6 +1441,14 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 		}
 	}
 
+	if len(badSignatureResults) > 0 {
+		fmt.Println("🔏 :", len(badSignatureResults), "checksum files with a missing or invalid signature")
+		for _, badSignatureResult := range badSignatureResults {
+			fmt.Print("- ", badSignatureResult.Path, " | Error: ", badSignatureResult.Error)
+			fmt.Println()
+		}
+	}
+
 	if len(failedResults) > 0 {
 		fmt.Println("❌ :", len(failedResults), "checksum files failed to check")
 		for _, failedResult := range failedResults {
@@ -258,6 +1460,7 @@ func printResultsCheckingChecksumFiles(results []ChecksumFileVerificationResult)
 
 func printErrorsCheckingChecksumFiles() {
 	if len(errorsCheckingChecksumFiles) > 0 {
+		sortErrors(errorsCheckingChecksumFiles)
 		fmt.Println()
 		fmt.Println("Errors:")
 
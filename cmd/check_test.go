@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCheckChecksumFile_NotFound(t *testing.T) {
@@ -59,7 +66,7 @@ func TestCheckChecksumFile_NotMatch(t *testing.T) {
 		t.Fatalf("write file: %v", err)
 	}
 
-	if err := os.WriteFile(filePath+".sha512", []byte("deadbeef"), 0o600); err != nil {
+	if err := os.WriteFile(filePath+".sha512", []byte(strings.Repeat("0", 128)), 0o600); err != nil {
 		t.Fatalf("write checksum file: %v", err)
 	}
 
@@ -114,3 +121,612 @@ func TestCheckChecksumFile_LockedFile(t *testing.T) {
 		t.Fatalf("expected error, got nil")
 	}
 }
+
+func TestCheckExpectedChecksum(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "debian.iso")
+	data := []byte("downloaded")
+
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	hash := sha256.Sum256(data)
+	expected := strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	algorithm, err := algorithmForDigest(expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if algorithm.Name != "sha256" {
+		t.Fatalf("expected algorithm sha256, got %s", algorithm.Name)
+	}
+
+	result := checkExpectedChecksum(filePath, expected, algorithm)
+	if result.Status != Match {
+		t.Fatalf("expected status %s, got %s", Match, result.Status)
+	}
+
+	result = checkExpectedChecksum(filePath, strings.Repeat("0", len(expected)), algorithm)
+	if result.Status != NotMatch {
+		t.Fatalf("expected status %s, got %s", NotMatch, result.Status)
+	}
+}
+
+func TestVerifyExpectedChecksum_FileTimeout(t *testing.T) {
+	fileTimeout = time.Nanosecond
+	defer func() { fileTimeout = 0 }()
+
+	filePath := filepath.Join(t.TempDir(), "video.mkv")
+	data := bytes.Repeat([]byte("frames"), 1<<20)
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	hash := sha512.Sum512(data)
+
+	// --expect abandons the file like the files verified against their checksum file
+	result := verifyExpectedChecksum(filePath, hex.EncodeToString(hash[:]), sha512Algorithm)
+	if result.Status != TimedOutVerification {
+		t.Fatalf("expected status %s, got %s (%v)", TimedOutVerification, result.Status, result.Error)
+	}
+
+	fileTimeout = time.Minute
+	result = verifyExpectedChecksum(filePath, hex.EncodeToString(hash[:]), sha512Algorithm)
+	if result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestDetectMovedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	oldPath := filepath.Join(tempDir, "old.txt")
+	newPath := filepath.Join(tempDir, "new.txt")
+	data := []byte("moved")
+
+	hash := sha512.Sum512(data)
+	if err := os.WriteFile(oldPath+".sha512", []byte(hex.EncodeToString(hash[:])), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	if err := os.WriteFile(newPath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	orphaned, ok := checkOrphanedChecksumFile(oldPath + ".sha512")
+	if !ok || orphaned.Status != Orphaned {
+		t.Fatalf("expected status %s, got %s", Orphaned, orphaned.Status)
+	}
+
+	relocateChecksumFiles = true
+	defer func() { relocateChecksumFiles = false }()
+
+	results := detectMovedFiles([]ChecksumFileVerificationResult{
+		orphaned,
+		{Path: newPath, Status: NotFound},
+	})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != Moved || results[0].MovedFrom != oldPath || results[0].Error != nil {
+		t.Fatalf("unexpected result: %+v", results[0])
+	}
+
+	if _, err := os.Stat(newPath + ".sha512"); err != nil {
+		t.Fatalf("expected the checksum file to be relocated: %v", err)
+	}
+	if result := checkChecksumFile(newPath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s", Match, result.Status)
+	}
+}
+
+func TestProcessPaths_Jobs(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := range 20 {
+		filePath := filepath.Join(tempDir, fmt.Sprintf("data-%d.txt", i))
+		if err := os.WriteFile(filePath, []byte(filePath), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	jobs = 4
+	defer func() { jobs = 1 }()
+
+	var errs []error
+	var creationResults []ChecksumFileCreationResult
+	processPaths([]string{tempDir}, &errs, func(filePath string) error {
+		return handleChecksumFileCreation(filePath, &creationResults)
+	})
+	if len(errs) != 0 || len(creationResults) != 20 {
+		t.Fatalf("expected 20 created checksum files, got %d (%v)", len(creationResults), errs)
+	}
+
+	var results []ChecksumFileVerificationResult
+	processPaths([]string{tempDir}, &errs, func(filePath string) error {
+		return handleChecksumFileVerification(filePath, &results, checkChecksumFile)
+	})
+	if len(errs) != 0 || len(results) != 20 {
+		t.Fatalf("expected 20 checked files, got %d (%v)", len(results), errs)
+	}
+	for _, result := range results {
+		if result.Status != Match {
+			t.Fatalf("expected status %s for %s, got %s", Match, result.Path, result.Status)
+		}
+	}
+}
+
+func TestCheckChecksumFile_SizeChanged(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	recordFileSize = true
+	defer func() { recordFileSize = false }()
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+
+	if err := os.WriteFile(filePath, []byte("hello, world"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	result := checkChecksumFileWith(filePath, func(io.Reader) io.Reader {
+		t.Fatalf("the file should not be read when its size changed")
+		return nil
+	})
+	if result.Status != NotMatch || result.Error == nil {
+		t.Fatalf("expected status %s with the size difference, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+}
+
+func TestWalkConcurrently(t *testing.T) {
+	tempDir := t.TempDir()
+	var expected []string
+	for _, directory := range []string{"a", "a/b", "a/b/c", "d", "e/f"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, directory), 0o700); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		for i := range 3 {
+			filePath := filepath.Join(tempDir, directory, fmt.Sprintf("data-%d.txt", i))
+			if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+			expected = append(expected, filePath)
+		}
+	}
+
+	rootInfo, err := os.Stat(tempDir)
+	if err != nil {
+		t.Fatalf("stat directory: %v", err)
+	}
+
+	var errs []error
+	var walked []string
+	walkConcurrently(tempDir, rootInfo, 4, &errs, func(filePath string) error {
+		appendLocked(&walked, filePath)
+		return nil
+	})
+
+	slices.Sort(expected)
+	slices.Sort(walked)
+	if len(errs) != 0 || !slices.Equal(walked, expected) {
+		t.Fatalf("expected %v, got %v (%v)", expected, walked, errs)
+	}
+}
+
+func TestWalkDirectory_ContinuesOnErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d/e.txt"} {
+		filePath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	var errs []error
+	var walked []string
+	walkPaths([]string{tempDir}, &errs, func(filePath string) error {
+		walked = append(walked, filepath.Base(filePath))
+		if filepath.Base(filePath) == "a.txt" {
+			// b.txt vanishes after the directory was read, and the handler of a.txt fails
+			if err := os.Remove(filepath.Join(tempDir, "b.txt")); err != nil {
+				t.Fatalf("remove file: %v", err)
+			}
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	if !slices.Equal(walked, []string{"a.txt", "c.txt", "e.txt"}) || len(errs) != 2 {
+		t.Fatalf("expected the walk to continue after the errors, got %v (%v)", walked, errs)
+	}
+}
+
+func TestProcessPaths_HDD(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, directory := range []string{"b", "a"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, directory), 0o700); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+		for i := range 5 {
+			if err := os.WriteFile(filepath.Join(tempDir, directory, fmt.Sprintf("data-%d.txt", i)), []byte("hello"), 0o600); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+		}
+	}
+
+	hddMode = true
+	jobs = 4
+	defer func() {
+		hddMode = false
+		jobs = 1
+	}()
+
+	var errs []error
+	var processed []string
+	running := 0
+	processPaths([]string{tempDir}, &errs, func(filePath string) error {
+		running++
+		defer func() { running-- }()
+		if running > 1 {
+			t.Errorf("expected the files to be read one at a time")
+		}
+		processed = append(processed, filePath)
+		return nil
+	})
+
+	if len(errs) != 0 || len(processed) != 10 {
+		t.Fatalf("expected 10 processed files, got %d (%v)", len(processed), errs)
+	}
+	for i, filePath := range processed {
+		expectedDirectory := "a"
+		if i >= 5 {
+			expectedDirectory = "b"
+		}
+		if filepath.Base(filepath.Dir(filePath)) != expectedDirectory {
+			t.Fatalf("expected the files sorted by directory, got %v", processed)
+		}
+	}
+}
+
+func TestIsOnOtherFileSystem(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc is a separate file system only on linux")
+	}
+
+	rootInfo, err := os.Stat("/")
+	if err != nil {
+		t.Fatalf("stat /: %v", err)
+	}
+	procInfo, err := os.Stat("/proc")
+	if err != nil {
+		t.Skip("/proc is not mounted")
+	}
+
+	if isOnOtherFileSystem(rootInfo, procInfo) {
+		t.Fatalf("expected no file system boundaries without --one-file-system")
+	}
+
+	oneFileSystem = true
+	defer func() { oneFileSystem = false }()
+	if !isOnOtherFileSystem(rootInfo, procInfo) {
+		t.Fatalf("expected /proc to be on another file system")
+	}
+	if isOnOtherFileSystem(rootInfo, rootInfo) {
+		t.Fatalf("expected / to be on its own file system")
+	}
+}
+
+func TestReadPathsFromList(t *testing.T) {
+	directory := t.TempDir()
+	existing := filepath.Join(directory, "a.txt")
+	if err := os.WriteFile(existing, []byte("a"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	listPath := filepath.Join(directory, "list.txt")
+	content := existing + "\n\n" + filepath.Join(directory, "missing.txt") + "\n" + existing + checksumFileExtension + "\n" + directory + "\n"
+	if err := os.WriteFile(listPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+
+	paths, errs := readPathsFromList(listPath)
+	if !slices.Equal(paths, []string{existing, directory}) {
+		t.Fatalf("expected the existing paths, got %v", paths)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), listPath+":3: ") {
+		t.Fatalf("expected the missing path reported with its line, got %v", errs)
+	}
+
+	if _, errs := readPathsFromList(filepath.Join(directory, "missing-list.txt")); len(errs) != 1 {
+		t.Fatalf("expected an error for a missing list, got %v", errs)
+	}
+}
+
+func TestReadPathsFromList_NullSeparated(t *testing.T) {
+	defer func() { nullSeparated = false }()
+
+	directory := t.TempDir()
+	names := []string{" leading space.txt", "new\nline.txt"}
+	var content []byte
+	var expected []string
+	for _, name := range names {
+		filePath := filepath.Join(directory, name)
+		if err := os.WriteFile(filePath, []byte(name), 0o600); err != nil {
+			t.Skipf("file name %q is not supported: %v", name, err)
+		}
+		content = append(append(content, filePath...), 0)
+		expected = append(expected, filePath)
+	}
+
+	listPath := filepath.Join(directory, "list")
+	if err := os.WriteFile(listPath, content, 0o600); err != nil {
+		t.Fatalf("write list: %v", err)
+	}
+
+	nullSeparated = true
+	paths, errs := readPathsFromList(listPath)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	if !slices.Equal(paths, expected) {
+		t.Fatalf("expected %q, got %q", expected, paths)
+	}
+}
+
+func TestCheckChecksumFile_UppercaseExtension(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "DATA.TXT")
+	content := []byte("copied from a FAT drive")
+	if err := os.WriteFile(filePath, content, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	sum := sha512.Sum512(content)
+	if err := os.WriteFile(filePath+".SHA512", []byte(hex.EncodeToString(sum[:])), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if !isChecksumFile(filePath+".SHA512") || !isChecksumFile(filePath+".Sha512") {
+		t.Fatalf("expected the extension to be recognized in any case")
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+	if result := createChecksumFile(filePath); result.Status != Existing {
+		t.Fatalf("expected status %s, got %s", Existing, result.Status)
+	}
+
+	var processed []string
+	var errs []error
+	processPaths([]string{tempDir}, &errs, func(filePath string) error {
+		if !isChecksumFile(filePath) {
+			processed = append(processed, filePath)
+		}
+		return nil
+	})
+	if !slices.Equal(processed, []string{filePath}) {
+		t.Fatalf("expected only the data file to be processed, got %v", processed)
+	}
+}
+
+func TestCheckChecksumFile_ClassifiesMismatch(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	recordFileSize = true
+	defer func() { recordFileSize = false }()
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+
+	// Same size and modification time, different content
+	if err := os.WriteFile(filePath, []byte("jello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Chtimes(filePath, fileInfo.ModTime(), fileInfo.ModTime()); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	result := checkChecksumFile(filePath)
+	if result.Status != NotMatch || result.Error == nil || !strings.Contains(result.Error.Error(), "bit rot") {
+		t.Fatalf("expected status %s reporting bit rot, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+
+	modifiedTime := fileInfo.ModTime().Add(time.Hour)
+	if err := os.Chtimes(filePath, modifiedTime, modifiedTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	result = checkChecksumFile(filePath)
+	if result.Status != NotMatch || result.Error == nil || !strings.Contains(result.Error.Error(), "modified after") {
+		t.Fatalf("expected status %s reporting a modified file, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+}
+
+func TestCheckChecksumFile_Base64Encoding(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	digestEncoding = base64Encoding
+	result := createChecksumFile(filePath)
+	digestEncoding = hexEncoding
+	if result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestParseChecksumFile_Tolerant(t *testing.T) {
+	for _, content := range []string{
+		"\ufeffabc",
+		"abc\r\n",
+		"# size: 5\r\nabc  \r\n",
+		"  abc\t\n\n",
+	} {
+		if parsed := parseChecksumFile(content); parsed.Checksum != "abc" {
+			t.Fatalf("expected the checksum abc in %q, got %q", content, parsed.Checksum)
+		}
+	}
+}
+
+func TestHandleChecksumFileVerification_ListedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	checksums := map[string]string{}
+	for name, data := range map[string]string{"a.jpg": "first", "b.jpg": "second"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(data), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		hash := sha512.Sum512([]byte(data))
+		checksums[name] = hex.EncodeToString(hash[:])
+	}
+	listing := fmt.Sprintf("%s  a.jpg\n%s  b.jpg\n", checksums["a.jpg"], strings.Repeat("0", 128))
+	checksumPath := filepath.Join(tempDir, "photos.sha512")
+	if err := os.WriteFile(checksumPath, []byte(listing), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	if err := handleChecksumFileVerification(checksumPath, &results, checkChecksumFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[filepath.Base(result.Path)] = result.Status
+	}
+	if len(results) != 2 || statuses["a.jpg"] != Match || statuses["b.jpg"] != NotMatch {
+		t.Fatalf("expected a.jpg to match and b.jpg not to match, got %v", results)
+	}
+
+	results = dropListedNotFound(append(results, ChecksumFileVerificationResult{Path: filepath.Join(tempDir, "a.jpg"), Status: NotFound}))
+	if len(results) != 2 {
+		t.Fatalf("expected the listed file not to be reported without a checksum file, got %v", results)
+	}
+}
+
+func TestHandleChecksumFileVerification_ListedFilesOutsideTheDirectory(t *testing.T) {
+	root := t.TempDir()
+	tree := filepath.Join(root, "tree")
+	outside := filepath.Join(root, "outside")
+	for _, directory := range []string{tree, outside} {
+		if err := os.Mkdir(directory, 0o700); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+	}
+	victimPath := filepath.Join(outside, "victim.txt")
+	if err := os.WriteFile(victimPath, []byte("victim"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	// A planted checksum file listing files outside the tree, with a checksum that does not match them
+	bogus := strings.Repeat("0", 128)
+	listing := fmt.Sprintf("%s  ../outside/victim.txt\n%s  %s\n", bogus, bogus, victimPath)
+	checksumPath := filepath.Join(tree, "planted.sha512")
+	if err := os.WriteFile(checksumPath, []byte(listing), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	if err := handleChecksumFileVerification(checksumPath, &results, checkChecksumFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected both entries to be reported, got %v", results)
+	}
+	for _, result := range results {
+		if result.Status != Malformed || result.Path != checksumPath {
+			t.Fatalf("expected the entries outside the directory to be malformed, got %+v", result)
+		}
+	}
+}
+
+func TestHandleChecksumFileVerification_ListedFilesFiltered(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tempDir, "sub"), 0o700); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	var listing strings.Builder
+	for _, name := range []string{"a.jpg", "own.jpg", "skipped.tmp", "sub/deep.jpg"} {
+		if err := os.WriteFile(filepath.Join(tempDir, filepath.FromSlash(name)), []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		fmt.Fprintf(&listing, "%s  %s\n", strings.Repeat("0", 128), name)
+	}
+	checksumPath := filepath.Join(tempDir, "photos.sha512")
+	if err := os.WriteFile(checksumPath, []byte(listing.String()), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	// A file with its own checksum file is verified on its own, not through the listing
+	if result := createChecksumFile(filepath.Join(tempDir, "own.jpg")); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	checkedDirectories = []string{tempDir}
+	excludePatterns = globPatterns{"*.tmp"}
+	maxDepth = 1
+	defer func() { checkedDirectories, excludePatterns, maxDepth = nil, nil, 0 }()
+
+	var results []ChecksumFileVerificationResult
+	if err := handleChecksumFileVerification(checksumPath, &results, checkChecksumFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "a.jpg" || results[0].Status != NotMatch {
+		t.Fatalf("expected only a.jpg to be verified through the listing, got %v", results)
+	}
+}
+
+func TestCheckExpectedChecksumWith_Unstable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "recording.wav")
+	if err := os.WriteFile(filePath, []byte("first take"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	hash := sha512.Sum512([]byte("first take"))
+
+	result := checkExpectedChecksumWith(filePath, hex.EncodeToString(hash[:]), sha512Algorithm, func(reader io.Reader) io.Reader {
+		return &appendingReader{reader: reader, path: filePath}
+	})
+	if result.Status != UnstableVerification || result.Error == nil {
+		t.Fatalf("expected status %s, got %s (%v)", UnstableVerification, result.Status, result.Error)
+	}
+}
+
+func TestCheckChecksumFile_Malformed(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"empty":     "",
+		"truncated": strings.Repeat("ab", 40),
+		"garbage":   "not a checksum at all",
+	} {
+		if err := os.WriteFile(filePath+checksumFileExtension, []byte(content), 0o600); err != nil {
+			t.Fatalf("write checksum file: %v", err)
+		}
+		result := checkChecksumFileWith(filePath, func(io.Reader) io.Reader {
+			t.Fatalf("%s: the file should not be read when its checksum file is malformed", name)
+			return nil
+		})
+		if result.Status != Malformed || result.Error == nil {
+			t.Errorf("%s: expected status %s with an error, got %s (%v)", name, Malformed, result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var resumeFromCheckpoint bool
+
+// checkpointDirectory is the directory where the checkpoints of the verifications are written
+var checkpointDirectory = defaultCheckpointDirectory()
+
+// partialReportPath is the file where the results of a check interrupted by Ctrl+C or SIGTERM are written
+var partialReportPath = defaultPartialReportPath()
+
+// partialReportPathUsage is the usage of the --partial-report flag
+const partialReportPathUsage = "file where the results of a check interrupted by Ctrl+C or SIGTERM are written as JSON, along with its checkpoint for --resume"
+
+// activeCheckpoint is the checkpoint where the results of the running verification are recorded
+var activeCheckpoint *checkpoint
+
+// checkpoint records the results of a verification as they are produced, one JSON line per file,
+// so an interrupted verification can be resumed without checking the same files again
+type checkpoint struct {
+	path      string
+	file      *os.File
+	processed map[string]bool
+}
+
+// checkpointResult is a line of the checkpoint
+type checkpointResult struct {
+	Path   string                         `json:"path"`
+	Status ChecksumFileVerificationStatus `json:"status"`
+	Error  string                         `json:"error,omitempty"`
+}
+
+// partialReport is the report of the files checked before the check was interrupted
+type partialReport struct {
+	StartedAt       time.Time          `json:"started_at"`
+	InterruptedAt   time.Time          `json:"interrupted_at"`
+	Checkpoint      string             `json:"checkpoint,omitempty"`
+	FilesChecked    int                `json:"files_checked"`
+	FilesMatched    int                `json:"files_matched"`
+	FilesMismatched int                `json:"files_mismatched"`
+	FilesFailed     int                `json:"files_failed"`
+	Results         []checkpointResult `json:"results"`
+	Errors          []string           `json:"errors"`
+}
+
+// defaultPartialReportPath returns interrupted-check.json in the user cache directory
+func defaultPartialReportPath() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "checksum-utils-interrupted-check.json")
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "interrupted-check.json")
+}
+
+// defaultCheckpointDirectory returns the checkpoints directory in the user cache directory
+func defaultCheckpointDirectory() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "checksum-utils-checkpoints")
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "checkpoints")
+}
+
+// checkpointPath returns the checkpoint of the verification of the paths, named after their absolute paths
+// so each set of paths has its own checkpoint
+func checkpointPath(paths []string) string {
+	absolutePaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if absolutePath, err := filepath.Abs(path); err == nil {
+			path = absolutePath
+		}
+		absolutePaths = append(absolutePaths, path)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(absolutePaths, "\x00")))
+	return filepath.Join(checkpointDirectory, hex.EncodeToString(sum[:16])+".jsonl")
+}
+
+// openCheckpoint opens the checkpoint of the verification of the paths. When resuming, the results already recorded
+// are returned and kept; otherwise the checkpoint starts empty.
+func openCheckpoint(paths []string, resume bool) (*checkpoint, []ChecksumFileVerificationResult, error) {
+	c := &checkpoint{path: checkpointPath(paths), processed: map[string]bool{}}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	var results []ChecksumFileVerificationResult
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		var err error
+		results, err = readCheckpoint(c.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+		for _, result := range results {
+			c.processed[result.Path] = true
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(c.path, flags, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.file = file
+
+	return c, results, nil
+}
+
+// readCheckpoint reads the results recorded in the checkpoint, ignoring the last line when the process
+// was killed while writing it
+func readCheckpoint(path string) ([]ChecksumFileVerificationResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var results []ChecksumFileVerificationResult
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var line checkpointResult
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+
+		result := ChecksumFileVerificationResult{Path: line.Path, Status: line.Status}
+		if line.Error != "" {
+			result.Error = errors.New(line.Error)
+		}
+		results = append(results, result)
+	}
+
+	return results, scanner.Err()
+}
+
+// isProcessed reports whether the file was already checked before resuming
+func (c *checkpoint) isProcessed(filePath string) bool {
+	fileAbsolutePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	return c.processed[fileAbsolutePath]
+}
+
+// newCheckpointResult returns the line of the checkpoint of the result
+func newCheckpointResult(result ChecksumFileVerificationResult) checkpointResult {
+	line := checkpointResult{Path: result.Path, Status: result.Status}
+	if result.Error != nil {
+		line.Error = result.Error.Error()
+	}
+	return line
+}
+
+// record appends the result to the checkpoint
+func (c *checkpoint) record(result ChecksumFileVerificationResult) error {
+	content, err := json.Marshal(newCheckpointResult(result))
+	if err != nil {
+		return err
+	}
+	_, err = c.file.Write(append(content, '\n'))
+	return err
+}
+
+// Close closes the checkpoint, removing it when the verification finished
+func (c *checkpoint) Close(finished bool) error {
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	if finished {
+		return os.Remove(c.path)
+	}
+	return nil
+}
+
+// checkPathsWithCheckpoint checks the paths like processPaths, recording the results in a checkpoint
+// and, with --resume, skipping the files checked by a previous interrupted verification of the same paths
+func checkPathsWithCheckpoint(paths []string, results *[]ChecksumFileVerificationResult) {
+	c, resumedResults, err := openCheckpoint(paths, resumeFromCheckpoint)
+	if err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		return
+	}
+	if resumeFromCheckpoint {
+		fmt.Printf("Resuming: %d files already checked\n", len(resumedResults))
+		*results = append(*results, resumedResults...)
+	}
+
+	walkedPaths := paths
+	if isSampling() {
+		sample, protectedFilesQuantity := sampleProtectedFiles(paths, &errorsCheckingChecksumFiles)
+		fmt.Printf("Sampling %d of %d files with a checksum file\n", len(sample), protectedFilesQuantity)
+		walkedPaths = sample
+	}
+	if staleFirst && historyCatalog != nil {
+		sortedFiles, err := sortStaleFirst(historyCatalog, walkedPaths, &errorsCheckingChecksumFiles)
+		if err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		} else {
+			walkedPaths = sortedFiles
+		}
+	}
+
+	setCheckedDirectories(paths)
+
+	activeCheckpoint = c
+	processPaths(walkedPaths, &errorsCheckingChecksumFiles, func(filePath string) error {
+		if isMaxDurationReached() {
+			stoppedByMaxDuration.Store(true)
+			return filepath.SkipAll
+		}
+		if isRunStopped(&errorsCheckingChecksumFiles) {
+			return filepath.SkipAll
+		}
+		if c.isProcessed(filePath) {
+			return nil
+		}
+		if incrementalCheck && activeVerificationCache != nil {
+			if fileAbsolutePath, err := filepath.Abs(filePath); err == nil && activeVerificationCache.isUnchanged(fileAbsolutePath) {
+				appendLocked(results, ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Unchanged})
+				return nil
+			}
+		}
+		return handleChecksumFileVerification(filePath, results, verifyChecksumFile)
+	})
+	activeCheckpoint = nil
+
+	// The checkpoint of a verification stopped by --max-duration, --max-errors or --fail-fast is kept, so it can be resumed
+	if err := c.Close(!stoppedByMaxDuration.Load() && !stoppedByMaxErrors.Load() && !stoppedByFailFast.Load()); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+}
+
+// flushInterruptedCheck is called when the check is interrupted by a signal: it syncs the checkpoint to disk, keeping
+// it for --resume, writes the results checked until then to the --partial-report file and records the check as
+// stopped in the state file. The files being checked are not waited for; they are checked again when resuming.
+func flushInterruptedCheck() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	results := resultsCheckingChecksumFiles
+	report := partialReport{StartedAt: checkStartedAt, InterruptedAt: time.Now(), Results: []checkpointResult{}, Errors: []string{}}
+	if activeCheckpoint != nil {
+		if err := activeCheckpoint.file.Sync(); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		} else {
+			report.Checkpoint = activeCheckpoint.path
+		}
+	}
+
+	summary := summarizeCheck(results)
+	report.FilesChecked, report.FilesMatched, report.FilesMismatched, report.FilesFailed = summary.Checked, summary.Matched, summary.Mismatched, summary.Failed
+	for _, result := range results {
+		report.Results = append(report.Results, newCheckpointResult(result))
+	}
+	sortByPath(report.Results, func(result checkpointResult) string { return result.Path })
+	for _, err := range errorsCheckingChecksumFiles {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	if err := writePartialReport(report); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	} else {
+		fmt.Println("Partial report written to", partialReportPath)
+	}
+	if report.Checkpoint != "" {
+		fmt.Println("Run the same command with --resume to continue")
+	}
+
+	if err := recordCheckFinished(results, errorsCheckingChecksumFiles, true); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+}
+
+// writePartialReport writes the report to the file given with --partial-report
+func writePartialReport(report partialReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(partialReportPath), 0o755); err != nil {
+		return err
+	}
+	return replaceFile(partialReportPath, string(content)+"\n")
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckPathsWithCheckpoint_Resume(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	defer func() { checkpointDirectory = defaultCheckpointDirectory() }()
+
+	tempDir := t.TempDir()
+	firstPath := filepath.Join(tempDir, "first.txt")
+	secondPath := filepath.Join(tempDir, "second.txt")
+	for _, path := range []string{firstPath, secondPath} {
+		if err := os.WriteFile(path, []byte(path), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(path); result.Status != Created {
+			t.Fatalf("expected status %s, got %s", Created, result.Status)
+		}
+	}
+
+	// An interrupted verification that only checked the first file, recorded as not matching
+	// so the test can tell that it is not checked again
+	c, _, err := openCheckpoint([]string{tempDir}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.record(ChecksumFileVerificationResult{Path: firstPath, Status: NotMatch}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Close(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resumeFromCheckpoint = true
+	defer func() { resumeFromCheckpoint = false }()
+
+	var results []ChecksumFileVerificationResult
+	checkPathsWithCheckpoint([]string{tempDir}, &results)
+
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	if len(results) != 2 || statuses[firstPath] != NotMatch || statuses[secondPath] != Match {
+		t.Fatalf("expected the first file from the checkpoint and the second one checked, got %+v", results)
+	}
+
+	if _, err := os.Stat(checkpointPath([]string{tempDir})); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the checkpoint to be removed after finishing, got %v", err)
+	}
+}
+
+func TestCheckPathsWithCheckpoint_MaxDuration(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	checkDeadline = time.Now().Add(-time.Second)
+	defer func() {
+		checkpointDirectory = defaultCheckpointDirectory()
+		checkDeadline = time.Time{}
+		stoppedByMaxDuration.Store(false)
+	}()
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "data.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	checkPathsWithCheckpoint([]string{tempDir}, &results)
+
+	if len(results) != 0 || !stoppedByMaxDuration.Load() {
+		t.Fatalf("expected the check to stop before checking any file, got %+v", results)
+	}
+	if _, err := os.Stat(checkpointPath([]string{tempDir})); err != nil {
+		t.Fatalf("expected the checkpoint to be kept: %v", err)
+	}
+}
+
+func TestCheckPathsWithCheckpoint_MaxErrors(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	maxErrors = 2
+	failedFilesCount.Store(0)
+	errorsCheckingChecksumFiles = nil
+	defer func() {
+		checkpointDirectory = defaultCheckpointDirectory()
+		maxErrors = 0
+		failedFilesCount.Store(0)
+		stoppedByMaxErrors.Store(false)
+		errorsCheckingChecksumFiles = nil
+	}()
+
+	// Checksum files that cannot be read, like the files of a disconnected mount
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if err := os.Mkdir(path+checksumFileExtension, 0o700); err != nil {
+			t.Fatalf("create directory: %v", err)
+		}
+	}
+
+	var results []ChecksumFileVerificationResult
+	checkPathsWithCheckpoint([]string{tempDir}, &results)
+
+	if len(results) != 2 || results[0].Status != CheckingFailed || !stoppedByMaxErrors.Load() {
+		t.Fatalf("expected the check to stop after 2 failed files, got %+v", results)
+	}
+	if _, err := os.Stat(checkpointPath([]string{tempDir})); err != nil {
+		t.Fatalf("expected the checkpoint to be kept: %v", err)
+	}
+}
+
+func TestCheckPathsWithCheckpoint_FailFast(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	failFast = true
+	errorsCheckingChecksumFiles = nil
+	defer func() {
+		checkpointDirectory = defaultCheckpointDirectory()
+		failFast = false
+		stoppedByFailFast.Store(false)
+	}()
+
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.iso", "b.iso", "c.iso"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(path); result.Status != Created {
+			t.Fatalf("expected status %s, got %s", Created, result.Status)
+		}
+	}
+	// The same size with another content, so the file is hashed
+	if err := os.WriteFile(filepath.Join(tempDir, "b.iso"), []byte("B.iso"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var results []ChecksumFileVerificationResult
+	checkPathsWithCheckpoint([]string{tempDir}, &results)
+
+	if len(results) != 2 || results[1].Status != NotMatch || !stoppedByFailFast.Load() {
+		t.Fatalf("expected the check to stop at the file that does not match, got %+v", results)
+	}
+}
+
+func TestFlushInterruptedCheck(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	previousPaths := [2]string{partialReportPath, checkStatePath}
+	partialReportPath = filepath.Join(t.TempDir(), "interrupted-check.json")
+	checkStatePath = filepath.Join(t.TempDir(), "last-check.json")
+	defer func() {
+		checkpointDirectory = defaultCheckpointDirectory()
+		partialReportPath, checkStatePath = previousPaths[0], previousPaths[1]
+		activeCheckpoint = nil
+		resultsCheckingChecksumFiles = nil
+	}()
+
+	c, _, err := openCheckpoint([]string{"/volume1"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close(false)
+	activeCheckpoint = c
+	if err := c.record(ChecksumFileVerificationResult{Path: "/volume1/a.raw", Status: Match}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{
+		{Path: "/volume1/a.raw", Status: Match},
+		{Path: "/volume1/b.raw", Status: CheckingFailed, Error: errors.New("input/output error")},
+	}
+
+	flushInterruptedCheck()
+
+	content, err := os.ReadFile(partialReportPath)
+	if err != nil {
+		t.Fatalf("expected the partial report to be written: %v", err)
+	}
+	var report partialReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Checkpoint != c.path || report.FilesMatched != 1 || report.FilesFailed != 1 || len(report.Results) != 2 || report.Results[1].Error != "input/output error" {
+		t.Fatalf("unexpected partial report %+v", report)
+	}
+
+	if results, err := readCheckpoint(c.path); err != nil || len(results) != 1 {
+		t.Fatalf("expected the checkpoint to be kept with its results, got %+v (%v)", results, err)
+	}
+	if state, err := readCheckState(checkStatePath); err != nil || state.State != failedCheckState {
+		t.Fatalf("expected the check recorded as failed, got %+v (%v)", state, err)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completePaths completes the path arguments with the files and directories that match what was typed,
+// leaving out the checksum files, their signatures and the PAR2 recovery data
+func completePaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	directory, prefix := filepath.Split(toComplete)
+	readDirectory := directory
+	if readDirectory == "" {
+		readDirectory = "."
+	}
+
+	entries, err := os.ReadDir(readDirectory)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	hasDirectories := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Hidden files are completed only when their name is being typed
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if isChecksumFile(name) {
+			continue
+		}
+
+		completion := directory + name
+		if entry.IsDir() || isDirectoryLink(readDirectory, entry) {
+			completion += string(filepath.Separator)
+			hasDirectories = true
+		}
+		completions = append(completions, completion)
+	}
+
+	// A space after a directory would prevent completing the files inside it
+	if hasDirectories {
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// isDirectoryLink reports whether the entry is a symbolic link to a directory
+func isDirectoryLink(directory string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	fileInfo, err := os.Stat(filepath.Join(directory, entry.Name()))
+	return err == nil && fileInfo.IsDir()
+}
+
+// completeAlgorithms completes the --algorithm flag with the names of the registered algorithms
+func completeAlgorithms(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, algorithm := range hashAlgorithms {
+		names = append(names, algorithm.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompletePaths(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"data.txt", "data.txt.sha512", "data.txt.sha512.asc", "data.txt.par2", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("hello"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "documents"), 0o700); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+
+	completions, _ := completePaths(checkCmd, nil, tempDir+string(filepath.Separator))
+
+	expected := []string{
+		filepath.Join(tempDir, "data.txt"),
+		filepath.Join(tempDir, "documents") + string(filepath.Separator),
+	}
+	slices.Sort(completions)
+	if !slices.Equal(completions, expected) {
+		t.Fatalf("expected %v, got %v", expected, completions)
+	}
+
+	completions, _ = completePaths(checkCmd, nil, filepath.Join(tempDir, ".h"))
+	if !slices.Equal(completions, []string{filepath.Join(tempDir, ".hidden")}) {
+		t.Fatalf("expected the hidden file, got %v", completions)
+	}
+}
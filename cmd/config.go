@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configPath is the configuration file with the default values of the flags
+var configPath string
+
+// defaultConfigPath returns the configuration file in the configuration directory of the user
+func defaultConfigPath() string {
+	configDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "checksum-utils.conf"
+	}
+	return filepath.Join(configDirectory, "checksum-utils", "config")
+}
+
+// applyConfig sets the flags of the command that were not given in the command line to the values of the
+// configuration file. Each line is the name of a flag and its value, like "suffix = .checksum"; the flags
+// that can be repeated take every line with their name, and the names of the flags of other commands are
+// ignored. A missing configuration file is not an error unless it was given with --config.
+func applyConfig(cmd *cobra.Command, path string, required bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	// The command line takes precedence, even over the lines of the flags that can be repeated
+	givenFlags := map[string]bool{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		givenFlags[flag.Name] = true
+	})
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected a line like \"name = value\"", path, lineNumber)
+		}
+		name = strings.TrimSpace(name)
+		value = unquoteConfigValue(strings.TrimSpace(value))
+
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || givenFlags[name] {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// unquoteConfigValue removes the double or single quotes around the value, so it can keep leading and trailing spaces
+func unquoteConfigValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
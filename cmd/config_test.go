@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyConfig(t *testing.T) {
+	var suffix string
+	var jobsCount int
+	var includes globPatterns
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVar(&suffix, "suffix", ".sha512", "")
+	cmd.Flags().IntVar(&jobsCount, "jobs", 1, "")
+	cmd.Flags().Var(&includes, "include", "")
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	content := "# defaults\nsuffix = \".checksum\"\njobs = 4\ninclude = *.raw\ninclude = *.jpg\nwebhook-url = https://example.com\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	if err := cmd.ParseFlags([]string{"--jobs", "2"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := applyConfig(cmd, configFile, true); err != nil {
+		t.Fatalf("apply config: %v", err)
+	}
+
+	if suffix != ".checksum" {
+		t.Fatalf("expected the suffix of the configuration file, got %q", suffix)
+	}
+	if jobsCount != 2 {
+		t.Fatalf("expected the command line to take precedence, got %d jobs", jobsCount)
+	}
+	if !slices.Equal(includes, globPatterns{"*.raw", "*.jpg"}) {
+		t.Fatalf("expected every include of the configuration file, got %v", includes)
+	}
+
+	if err := applyConfig(cmd, filepath.Join(t.TempDir(), "missing"), false); err != nil {
+		t.Fatalf("expected no error for a missing default configuration file, got %v", err)
+	}
+	if err := applyConfig(cmd, filepath.Join(t.TempDir(), "missing"), true); err == nil {
+		t.Fatalf("expected an error for a missing configuration file given with --config")
+	}
+
+	if err := os.WriteFile(configFile, []byte("jobs = many\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	invalidCmd := &cobra.Command{Use: "test"}
+	invalidCmd.Flags().IntVar(&jobsCount, "jobs", 1, "")
+	if err := applyConfig(invalidCmd, configFile, true); err == nil || !strings.HasPrefix(err.Error(), configFile+":1: ") {
+		t.Fatalf("expected an error with the line of the invalid value, got %v", err)
+	}
+}
+
+func TestCreateAndCheck_Suffix(t *testing.T) {
+	checksumFileExtension = ".checksum"
+	defer func() { checksumFileExtension = ".sha512" }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+	if _, err := os.Stat(filePath + ".checksum"); err != nil {
+		t.Fatalf("expected a .checksum file: %v", err)
+	}
+	if !isChecksumFile(filePath+".checksum") || isChecksumFile(filePath+".sha512") {
+		t.Fatalf("expected only the files with the configured suffix to be checksum files")
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
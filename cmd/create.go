@@ -16,13 +16,11 @@ limitations under the License.
 package cmd
 
 import (
-	"crypto/sha512"
-	"encoding/hex"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -31,22 +29,173 @@ import (
 var errorsCreatingChecksumFiles []error
 var resultsCreatingChecksumFiles []ChecksumFileCreationResult
 
+var signChecksumFiles bool
+var recordFileSize bool
+var onlyMissing bool
+var overwriteExisting bool
+var updateStale bool
+var matchModTime bool
+var appendNewline bool
+var writeHeader bool
+var signingKey string
+var dryRun bool
+
+// The formats of the content of the checksum files, chosen with --sidecar-format
+const (
+	bareSidecarFormat      = "bare"
+	coreutilsSidecarFormat = "coreutils"
+)
+
+// sidecarFormat is the format of the checksum files written by create and repair
+var sidecarFormat = bareSidecarFormat
+
+const sidecarFormatUsage = "content of the checksum files: bare, only the checksum, or coreutils, \"<checksum>  <file>\" lines that sha512sum -c can verify"
+
+const writeHeaderUsage = "start the checksum files and manifests with \"# \" comments telling the version of checksum-utils, the algorithm, the creation time and the host that created them"
+
+const appendNewlineUsage = "end the checksum files with a new line, as many other tools expect"
+
+const dryRunUsage = "list the checksum files that would be created or overwritten, without hashing the files nor writing anything"
+
+const matchModTimeUsage = "give the checksum files the modification time of their file, so backup tools treat them as a pair"
+
 // createCmd represents the create command
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create checksum files.",
-	Long: `Generate the checksum of the files and store them in checksum files with the extension .sha512.
+	Long: `Generate the checksum of the files and store them in checksum files with the extension .sha512,
+or the one given with --suffix.
 
 Example:
   checksum-utils create .
   checksum-utils create ./work
 	checksum-utils create ~/documents
   checksum-utils create /mnt/external-disk/budget.pdf
+  checksum-utils create --sign --sign-key admin@nas.local ~/documents
+  checksum-utils create --into-archives ./backups
+  checksum-utils create --include '*.raw' --exclude '*.tmp' ~/photos
+  checksum-utils create --max-depth 1 ~/projects
+  checksum-utils create --files-from recent.txt
+  checksum-utils create --only-missing /volume1/archive
+  checksum-utils create --force ~/documents
+  checksum-utils create --update-stale ~/projects
+  checksum-utils create --match-mtime ~/projects
+  checksum-utils create --only-ext mkv,flac,raw /volume1/media
+  checksum-utils create --store-dir /volume1/.checksums /volume1/photos
+  checksum-utils create --hidden-sidecar /volume1/share
+  checksum-utils create --store xattr /volume1/photos
+  checksum-utils create --store ads D:\Photos
+  checksum-utils create --sidecar-format coreutils ~/photos
+  checksum-utils create --algorithms sha512,sha256 ~/photos
+  checksum-utils create --header ~/documents
+  checksum-utils create --tag-manifest /volume1/photos
+  checksum-utils create --newer-than 2024-01-01 /volume1/ingest
+  checksum-utils create --par2 --par2-redundancy 15 ~/photos
+  checksum-utils create s3://offsite-backup/photos
+  checksum-utils create --s3-metadata s3://offsite-backup/photos
+  checksum-utils create davs://juan@cloud.example.com/remote.php/dav/files/juan/Photos
+  checksum-utils create rclone:b2-archive:photos
+  checksum-utils create --remote-copy b2://offsite-backup/photos /volume1/photos
 `,
-	Args: cobra.MinimumNArgs(0),
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completePaths,
 	Run: func(cmd *cobra.Command, args []string) {
 		printHeader()
 
+		if err := acquireRunLocks(args); err != nil {
+			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+			printErrorsCreatingChecksumFiles()
+			os.Exit(lockedExitCode)
+		}
+		defer releaseRunLocks()
+
+		if (overwriteExisting || updateStale) && onlyMissing {
+			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--force and --update-stale cannot be used with --only-missing"))
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+
+		if len(multiHashAlgorithmNames) > 0 {
+			if _, err := multiHashAlgorithms(); err != nil {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+				printErrorsCreatingChecksumFiles()
+				return
+			}
+			if signChecksumFiles || onlyMissing || updateStale || storeMode != sidecarStoreMode || storeDirectory != "" {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--algorithms cannot be used with --sign, --only-missing, --update-stale, --store or --store-dir"))
+				printErrorsCreatingChecksumFiles()
+				return
+			}
+		}
+
+		if dryRun && (len(multiHashAlgorithmNames) > 0 || signChecksumFiles || createPar2 || createTagManifest || intoArchives || remoteCopy != "") {
+			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--dry-run cannot be used with --algorithms, --sign, --par2, --tag-manifest, --into-archives or --remote-copy"))
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+
+		if createTagManifest && (storeMode != sidecarStoreMode || storeDirectory != "") {
+			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--tag-manifest cannot be used with --store or --store-dir"))
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+
+		if signChecksumFiles {
+			if err := ensureGPG(); err != nil {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+				printErrorsCreatingChecksumFiles()
+				return
+			}
+		}
+
+		if createPar2 {
+			if err := ensurePar2(); err != nil {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+				printErrorsCreatingChecksumFiles()
+				return
+			}
+		}
+
+		if remoteCopy != "" {
+			if len(args) != 1 {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--remote-copy requires the local directory of the copy"))
+				printErrorsCreatingChecksumFiles()
+				return
+			}
+
+			fmt.Println()
+			fmt.Println("Processing", args[0], "→", remoteCopy)
+
+			resultsCreatingChecksumFiles = []ChecksumFileCreationResult{}
+			if err := runRemoteCopyCreation(args[0], &resultsCreatingChecksumFiles); err != nil {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+			}
+			printResultsCreatingChecksumFiles(resultsCreatingChecksumFiles)
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+
+		args, remoteArgs := splitRemoteArgs(args)
+		if dryRun && len(remoteArgs) > 0 {
+			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, fmt.Errorf("--dry-run cannot be used with remote paths like %s", remoteArgs[0]))
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+		for _, remoteArg := range remoteArgs {
+			fmt.Println()
+			fmt.Println("Processing", remoteArg)
+
+			resultsCreatingChecksumFiles = []ChecksumFileCreationResult{}
+			if err := runRemoteCreation(remoteArg, &resultsCreatingChecksumFiles); err != nil {
+				errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
+			}
+			printResultsCreatingChecksumFiles(resultsCreatingChecksumFiles)
+		}
+		if len(remoteArgs) > 0 && len(args) == 0 {
+			printErrorsCreatingChecksumFiles()
+			return
+		}
+
 		paths, expandErrors, hadGlob := gatherPaths(args)
 		for _, err := range expandErrors {
 			errorsCreatingChecksumFiles = append(errorsCreatingChecksumFiles, err)
@@ -55,6 +204,7 @@ Example:
 			printErrorsCreatingChecksumFiles()
 			return
 		}
+		startRunStatus(paths)
 
 		if hadGlob || len(paths) > 1 {
 			fmt.Println()
@@ -63,6 +213,11 @@ Example:
 			processPaths(paths, &errorsCreatingChecksumFiles, func(filePath string) error {
 				return handleChecksumFileCreation(filePath, &resultsCreatingChecksumFiles)
 			})
+			if createTagManifest {
+				for _, path := range paths {
+					handleTagManifestCreation(path, &resultsCreatingChecksumFiles)
+				}
+			}
 			printResultsCreatingChecksumFiles(resultsCreatingChecksumFiles)
 		} else {
 			for _, path := range paths {
@@ -73,32 +228,103 @@ Example:
 				processPaths([]string{path}, &errorsCreatingChecksumFiles, func(filePath string) error {
 					return handleChecksumFileCreation(filePath, &resultsCreatingChecksumFiles)
 				})
+				if createTagManifest {
+					handleTagManifestCreation(path, &resultsCreatingChecksumFiles)
+				}
 
 				printResultsCreatingChecksumFiles(resultsCreatingChecksumFiles)
 			}
 		}
 
 		printErrorsCreatingChecksumFiles()
+
+		if stoppedByMaxErrors.Load() {
+			printStoppedByMaxErrors()
+			os.Exit(maxErrorsExitCode)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(createCmd)
+
+	createCmd.Flags().StringVar(&checksumFileExtension, "suffix", checksumFileExtension, checksumFileExtensionUsage)
+	createCmd.Flags().StringVar(&storeDirectory, "store-dir", "", storeDirectoryUsage)
+	createCmd.Flags().StringVar(&storeMode, "store", sidecarStoreMode, storeModeUsage)
+	createCmd.Flags().BoolVar(&hiddenSidecars, "hidden-sidecar", false, hiddenSidecarsUsage)
+	createCmd.Flags().BoolVar(&onlyMissing, "only-missing", false, "only create the missing checksum files, counting the files that already have one without listing them or creating their PAR2 recovery data and archive manifests")
+	createCmd.Flags().StringVar(&digestEncoding, "encoding", hexEncoding, digestEncodingUsage)
+	createCmd.Flags().StringVar(&sidecarFormat, "sidecar-format", bareSidecarFormat, sidecarFormatUsage)
+	createCmd.Flags().StringSliceVar(&multiHashAlgorithmNames, "algorithms", nil, "store the checksums of several algorithms, like sha512,sha256, in a "+multiHashExtension+" file next to each file instead of its checksum file")
+	createCmd.Flags().BoolVar(&overwriteExisting, "force", false, "regenerate the checksum files that already exist instead of skipping them")
+	createCmd.Flags().BoolVar(&updateStale, "update-stale", false, "regenerate the checksum files that already exist when their file was modified after them")
+	createCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, dryRunUsage)
+	createCmd.Flags().BoolVar(&matchModTime, "match-mtime", false, matchModTimeUsage)
+	createCmd.Flags().BoolVar(&appendNewline, "newline", false, appendNewlineUsage)
+	createCmd.Flags().BoolVar(&writeHeader, "header", false, writeHeaderUsage)
+	createCmd.Flags().BoolVar(&createTagManifest, "tag-manifest", false, "also store the checksums of the checksum files of each directory in its "+tagManifestName+", so check detects changes to the checksum files themselves")
+	createCmd.Flags().BoolVar(&recordFileSize, "record-size", false, "also record the size and the modification time of the files in their checksum files, so check detects changed sizes without reading the files and tells apart modified files from bit rot")
+	createCmd.Flags().StringVar(&filesFrom, "files-from", "", filesFromUsage)
+	createCmd.Flags().BoolVarP(&nullSeparated, "null", "0", false, nullSeparatedUsage)
+	createCmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "number of files hashed, and of directories read, at the same time")
+	createCmd.Flags().BoolVar(&hddMode, "hdd", false, hddModeUsage)
+	createCmd.Flags().BoolVarP(&oneFileSystem, "one-file-system", "x", false, oneFileSystemUsage)
+	createCmd.Flags().Var(&includePatterns, "include", includePatternsUsage)
+	createCmd.Flags().Var(&excludePatterns, "exclude", excludePatternsUsage)
+	createCmd.Flags().StringSliceVar(&onlyExtensions, "only-ext", nil, onlyExtensionsUsage)
+	createCmd.Flags().Var(&includeRegexps, "include-regex", includeRegexpsUsage)
+	createCmd.Flags().Var(&excludeRegexps, "exclude-regex", excludeRegexpsUsage)
+	createCmd.Flags().Var(&excludeRules, "exclude-from", excludeFromUsage)
+	createCmd.Flags().BoolVar(&excludeCommon, "exclude-common", false, excludeCommonUsage)
+	createCmd.Flags().IntVar(&maxDepth, "max-depth", 0, maxDepthUsage)
+	createCmd.Flags().BoolVar(&skipHidden, "skip-hidden", false, skipHiddenUsage)
+	createCmd.Flags().BoolVar(&includeSnapshots, "include-snapshots", false, includeSnapshotsUsage)
+	createCmd.Flags().Var(&minFileSize, "min-size", minFileSizeUsage)
+	createCmd.Flags().Var(&maxFileSize, "max-size", maxFileSizeUsage)
+	createCmd.Flags().Var(&newerThan, "newer-than", newerThanUsage)
+	createCmd.Flags().Var(&olderThan, "older-than", olderThanUsage)
+	createCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, followSymlinksUsage)
+	createCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	createCmd.Flags().StringVar(&lockScope, "lock", noLockScope, lockScopeUsage)
+	createCmd.Flags().DurationVar(&lockWait, "lock-wait", 0, lockWaitUsage)
+	createCmd.Flags().IntVar(&unstableRetries, "unstable-retries", 0, unstableRetriesUsage)
+	createCmd.Flags().DurationVar(&fileTimeout, "file-timeout", 0, fileTimeoutUsage)
+	createCmd.Flags().IntVar(&maxErrors, "max-errors", 0, maxErrorsUsage)
+	createCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	createCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	createCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	createCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	createCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+	createCmd.Flags().BoolVar(&signChecksumFiles, "sign", false, "create a detached GPG signature (.sha512.asc) for each created checksum file, members manifest and tag manifest")
+	createCmd.Flags().StringVar(&signingKey, "sign-key", "", "GPG key used by --sign instead of the default secret key")
+	createCmd.Flags().BoolVar(&createPar2, "par2", false, "also create PAR2 recovery data (.par2) for each file, so repair --par2 can restore it when it gets corrupted")
+	createCmd.Flags().IntVar(&par2Redundancy, "par2-redundancy", 10, "percentage of each file that its PAR2 recovery data is able to restore")
+	createCmd.Flags().BoolVar(&intoArchives, "into-archives", false, "also store the checksums of the members of .tar, .tar.gz and .zip archives in a manifest (.members.sha512)")
+	createCmd.Flags().StringVar(&remoteCopy, "remote-copy", "", remoteCopyUsage)
+	createCmd.Flags().BoolVar(&s3MetadataStore, "s3-metadata", false, "keep the checksums of the s3:// objects in their x-amz-meta-sha512 metadata, copying each object onto itself, instead of in checksum objects")
+	createCmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "URL of the S3 compatible service used by s3:// paths, like a MinIO server (default: $AWS_ENDPOINT_URL_S3 or AWS)")
 }
 
 type ChecksumFileCreationStatus string
 
 const (
-	Created        ChecksumFileCreationStatus = "Created"
-	Existing       ChecksumFileCreationStatus = "Existing"
-	Failed         ChecksumFileCreationStatus = "Failed"
-	LockedCreation ChecksumFileCreationStatus = "Locked"
+	Created          ChecksumFileCreationStatus = "Created"
+	Existing         ChecksumFileCreationStatus = "Existing"
+	Overwritten      ChecksumFileCreationStatus = "Overwritten"
+	Failed           ChecksumFileCreationStatus = "Failed"
+	LockedCreation   ChecksumFileCreationStatus = "Locked"
+	SpecialCreation  ChecksumFileCreationStatus = "Special"
+	UnstableCreation ChecksumFileCreationStatus = "Unstable"
+	TimedOutCreation ChecksumFileCreationStatus = "TimedOut"
+	WouldCreate      ChecksumFileCreationStatus = "WouldCreate"
+	WouldOverwrite   ChecksumFileCreationStatus = "WouldOverwrite"
 )
 
 type ChecksumFileCreationResult struct {
-	Path   string
-	Status ChecksumFileCreationStatus
-	Error  error
+	Path        string
+	Status      ChecksumFileCreationStatus
+	Error       error
+	HashedBytes int64
 }
 
 func handleChecksumFileCreation(filePath string, results *[]ChecksumFileCreationResult) error {
@@ -107,17 +333,78 @@ func handleChecksumFileCreation(filePath string, results *[]ChecksumFileCreation
 		return err
 	}
 
-	ext := filepath.Ext(fileAbsolutePath)
-	if ext == ".sha512" {
+	if isChecksumFile(fileAbsolutePath) {
 		return nil
 	}
 
+	if isRunStopped(&errorsCreatingChecksumFiles) {
+		return filepath.SkipAll
+	}
+
+	// The files that already have a checksum file are only counted, without listing them or creating their
+	// PAR2 recovery data and archive manifests
+	if onlyMissing {
+		if stored, err := hasStoredChecksum(fileAbsolutePath); err == nil && stored {
+			appendLocked(results, ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Existing})
+			return nil
+		}
+	}
+
+	reportChecksumFileCreation(fileAbsolutePath, results, func(fileAbsolutePath string) ChecksumFileCreationResult {
+		result := retryUnstable(func() ChecksumFileCreationResult {
+			return withFileTimeout(func(wrap func(io.Reader) io.Reader) ChecksumFileCreationResult {
+				return createChecksumFileWith(fileAbsolutePath, wrap)
+			}, func(err error) ChecksumFileCreationResult {
+				return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: TimedOutCreation, Error: err}
+			})
+		}, func(result ChecksumFileCreationResult) bool {
+			return result.Status == UnstableCreation
+		})
+		if (result.Status == Created || result.Status == Overwritten) && signChecksumFiles {
+			if err := signFile(checksumFilePath(fileAbsolutePath), signingKey); err != nil {
+				result = ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+			}
+		} else if result.Status == Overwritten {
+			checksumFile := checksumFilePath(fileAbsolutePath)
+			if _, err := os.Stat(checksumFile + signatureExtension); err == nil {
+				appendLocked(&errorsCreatingChecksumFiles, fmt.Errorf("the signature of %s is no longer valid, create it again with --force --sign", checksumFile))
+			}
+		}
+		if (result.Status == Created || result.Status == Existing || result.Status == Overwritten) && createPar2 && !hasPar2(fileAbsolutePath) {
+			if err := createPar2Files(fileAbsolutePath, par2Redundancy); err != nil {
+				result = ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+			}
+		}
+		return result
+	})
+
+	if intoArchives && isArchive(fileAbsolutePath) {
+		reportChecksumFileCreation(fileAbsolutePath+archiveManifestExtension, results, func(string) ChecksumFileCreationResult {
+			return createArchiveManifest(fileAbsolutePath)
+		})
+	}
+
+	return nil
+}
+
+// reportChecksumFileCreation creates the checksum file showing the progress, and prints the result
+func reportChecksumFileCreation(fileAbsolutePath string, results *[]ChecksumFileCreationResult, create func(string) ChecksumFileCreationResult) {
 	prefix := fmt.Sprintf("- %s ", fileAbsolutePath)
-	spinner := startProgress(prefix)
+	// The spinners of several jobs would overwrite each other
+	spinner := &progressSpinner{}
+	if jobs <= 1 {
+		spinner = startProgress(prefix)
+	}
+	activeRunStatus.beginFile(fileAbsolutePath)
 	start := time.Now()
-	result := createChecksumFile(fileAbsolutePath)
+	result := create(fileAbsolutePath)
 	elapsed := time.Since(start)
+	activeRunStatus.finishFile(fileAbsolutePath)
 	spinner.Stop()
+	countFailedFile(result.Status == Failed || result.Status == LockedCreation || result.Status == TimedOutCreation)
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 
 	*results = append(*results, result)
 
@@ -131,30 +418,64 @@ func handleChecksumFileCreation(filePath string, results *[]ChecksumFileCreation
 		fmt.Print("✅")
 	case Existing:
 		fmt.Print("⏭️")
+	case Overwritten:
+		fmt.Print("🔄")
 	case LockedCreation:
 		fmt.Print("🔒")
 	case Failed:
 		fmt.Print("❌")
+	case SpecialCreation:
+		fmt.Print("🔌")
+	case UnstableCreation:
+		fmt.Print("🌀")
+	case TimedOutCreation:
+		fmt.Print("⌛")
+	case WouldCreate:
+		fmt.Print("🆕")
+	case WouldOverwrite:
+		fmt.Print("♻️")
+	}
+	if (result.Status == WouldCreate || result.Status == WouldOverwrite) && storeMode != xattrStoreMode {
+		fmt.Print(" → ", checksumFilePath(fileAbsolutePath))
 	}
 
-	if result.Status != Existing && result.Status != LockedCreation {
+	if result.Status != Existing && result.Status != LockedCreation && result.Status != SpecialCreation && result.Status != WouldCreate && result.Status != WouldOverwrite {
 		fmt.Printf(" (%s)", formatDuration(elapsed))
 	}
 	fmt.Println()
-
-	return nil
 }
 
 func createChecksumFile(fileAbsolutePath string) ChecksumFileCreationResult {
-	// Checksum file
-	if _, err := os.Stat(fileAbsolutePath + ".sha512"); err == nil {
-		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Existing, Error: nil}
-	} else if !errors.Is(err, os.ErrNotExist) {
+	return createChecksumFileWith(fileAbsolutePath, nil)
+}
+
+// createChecksumFileWith creates the checksum file of the file, reading its content through wrap when it is not nil,
+// so the reads can be observed or controlled
+func createChecksumFileWith(fileAbsolutePath string, wrap func(io.Reader) io.Reader) ChecksumFileCreationResult {
+	if len(multiHashAlgorithmNames) > 0 {
+		return createMultiHashFile(fileAbsolutePath)
+	}
+
+	// Checksum file, regenerated when it exists with --force, or with --update-stale when the file is newer
+	stored, err := hasStoredChecksum(fileAbsolutePath)
+	if err != nil {
 		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
 	}
+	if stored && !overwriteExisting {
+		stale, err := isStoredChecksumStale(fileAbsolutePath)
+		if err != nil {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+		}
+		if !stale {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Existing, Error: nil}
+		}
+	}
 
-	file, err := os.Open(fileAbsolutePath)
+	file, err := openDataFile(fileAbsolutePath)
 	if err != nil {
+		if _, special := err.(*specialFileError); special {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: SpecialCreation, Error: err}
+		}
 		if os.IsPermission(err) {
 			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: LockedCreation, Error: err}
 		}
@@ -163,65 +484,237 @@ func createChecksumFile(fileAbsolutePath string) ChecksumFileCreationResult {
 
 	defer file.Close()
 
-	// Create a new SHA512 hash object
-	hash := sha512.New()
-
-	// Copy the file content to the hash object
-	if _, err := io.Copy(hash, file); err != nil {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
 		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
 	}
 
-	// Get the checksum as a byte slice
-	fileChecksum := hash.Sum(nil)
-
-	// Convert the checksum to a hexadecimal string
-	hexFileChecksum := hex.EncodeToString(fileChecksum)
+	// The file can be read, so its checksum file would be written
+	if dryRun {
+		if stored {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: WouldOverwrite, HashedBytes: fileInfo.Size()}
+		}
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: WouldCreate, HashedBytes: fileInfo.Size()}
+	}
 
-	// Create checksum file
-	checksumFile, err := os.Create(fileAbsolutePath + ".sha512")
+	counter := &countingReader{reader: wrapReader(file, wrap)}
+	hexFileChecksum, err := hashLinkedFile(fileAbsolutePath, counter)
 	if err != nil {
 		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
 	}
 
-	defer checksumFile.Close()
+	// The checksum of a file being written would not match it once it is complete
+	if err := modifiedWhileHashing(fileAbsolutePath, fileInfo); err != nil {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: UnstableCreation, Error: err}
+	}
 
-	// Write the file checksum on the checksum file
-	if _, err := checksumFile.WriteString(hexFileChecksum); err != nil {
+	store := writeChecksumFile
+	if stored {
+		store = replaceStoredChecksum
+	}
+	if err := store(fileAbsolutePath, hexFileChecksum); err != nil {
 		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
 	}
 
-	return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Created, Error: nil}
+	status := Created
+	if stored {
+		status = Overwritten
+	}
+	return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: status, Error: nil, HashedBytes: counter.count}
+}
+
+// isStoredChecksumStale reports whether the file was modified after its checksum was stored, with --update-stale
+func isStoredChecksumStale(fileAbsolutePath string) (bool, error) {
+	if !updateStale {
+		return false, nil
+	}
+
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return false, err
+	}
+	checksumModTime, err := storedChecksumModTime(fileAbsolutePath)
+	if err != nil {
+		return false, err
+	}
+	return fileInfo.ModTime().After(checksumModTime), nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// writeChecksumFile stores the checksum in the checksum file of the file, or in its extended attributes with
+// --store xattr, replacing it when it exists
+func writeChecksumFile(fileAbsolutePath string, hexFileChecksum string) error {
+	if storeMode == xattrStoreMode {
+		return writeChecksumAttributes(fileAbsolutePath, hexFileChecksum)
+	}
+
+	content := encodeDigest(hexFileChecksum)
+	if sidecarFormat == coreutilsSidecarFormat {
+		content = coreutilsChecksumLine(content, filepath.Base(fileAbsolutePath))
+	} else if appendNewline {
+		content += "\n"
+	}
+	if recordFileSize {
+		fileInfo, err := os.Stat(fileAbsolutePath)
+		if err != nil {
+			return err
+		}
+		content = fmt.Sprintf("%s%d\n%s%s\n%s", sizeMetadataPrefix, fileInfo.Size(), modTimeMetadataPrefix, fileInfo.ModTime().UTC().Format(time.RFC3339Nano), content)
+	}
+	if writeHeader {
+		content = metadataHeader(sha512Algorithm.Name) + content
+	}
+
+	// Create checksum file
+	checksumFileAbsolutePath := checksumFilePath(fileAbsolutePath)
+	if storeDirectory != "" {
+		if err := os.MkdirAll(filepath.Dir(checksumFileAbsolutePath), 0o755); err != nil {
+			return err
+		}
+	}
+
+	if err := replaceChecksumFile(checksumFileAbsolutePath, content); err != nil {
+		return err
+	}
+	if hiddenSidecars {
+		if err := setHiddenAttribute(checksumFileAbsolutePath); err != nil {
+			return err
+		}
+	}
+
+	// With --match-mtime, the checksum file gets the modification time of the file
+	if matchModTime {
+		fileInfo, err := os.Stat(fileAbsolutePath)
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(checksumFileAbsolutePath, time.Time{}, fileInfo.ModTime())
+	}
+	return nil
 }
 
+// replaceChecksumFile writes the content of the checksum file, replacing it when it exists
+func replaceChecksumFile(checksumFileAbsolutePath string, content string) error {
+	// The alternate data streams cannot be renamed into place
+	if storeMode == adsStoreMode {
+		return os.WriteFile(checksumFileAbsolutePath, []byte(content), 0o644)
+	}
+
+	// An interrupted run never leaves the checksum file truncated or empty
+	return replaceFile(checksumFileAbsolutePath, content)
+}
+
+// replaceFile writes the content on a temporary file that replaces the file, so the readers of the file never see it
+// partially written
+func replaceFile(fileAbsolutePath string, content string) error {
+	temporaryFile, err := os.CreateTemp(filepath.Dir(fileAbsolutePath), "."+filepath.Base(fileAbsolutePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	if _, err := temporaryFile.WriteString(content); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Chmod(0o644); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Sync(); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFile.Name(), fileAbsolutePath)
+}
+
+// printResultsCreatingChecksumFiles prints the summary of the results, sorting them by path first
 func printResultsCreatingChecksumFiles(results []ChecksumFileCreationResult) {
+	sortByPath(results, func(result ChecksumFileCreationResult) string { return result.Path })
+
 	if len(results) > 0 {
 		fmt.Println("Results:", len(results), "files processed")
 	}
 
 	var createdChecksumFilesQuantity = 0
+	var hashedBytes int64 = 0
 	var existingChecksumFilesQuantity = 0
+	var overwrittenChecksumFilesQuantity = 0
+	var overwrittenBytes int64 = 0
 	var lockedChecksumFilesQuantity = 0
 	var failedResults []ChecksumFileCreationResult
+	var specialResults []ChecksumFileCreationResult
+	var unstableResults []ChecksumFileCreationResult
+	var timedOutResults []ChecksumFileCreationResult
+	var wouldCreateQuantity, wouldOverwriteQuantity = 0, 0
+	var wouldHashBytes int64 = 0
 
 	for _, result := range results {
 		switch result.Status {
 		case Created:
 			createdChecksumFilesQuantity++
+			hashedBytes += result.HashedBytes
 		case Existing:
 			existingChecksumFilesQuantity++
+		case Overwritten:
+			overwrittenChecksumFilesQuantity++
+			overwrittenBytes += result.HashedBytes
 		case LockedCreation:
 			lockedChecksumFilesQuantity++
 		case Failed:
 			failedResults = append(failedResults, result)
+		case SpecialCreation:
+			specialResults = append(specialResults, result)
+		case UnstableCreation:
+			unstableResults = append(unstableResults, result)
+		case TimedOutCreation:
+			timedOutResults = append(timedOutResults, result)
+		case WouldCreate:
+			wouldCreateQuantity++
+			wouldHashBytes += result.HashedBytes
+		case WouldOverwrite:
+			wouldOverwriteQuantity++
+			wouldHashBytes += result.HashedBytes
 		}
 	}
 
 	if createdChecksumFilesQuantity > 0 {
-		fmt.Println("✅ :", createdChecksumFilesQuantity, "checksum files created successfully")
+		fmt.Println("✅ :", createdChecksumFilesQuantity, "checksum files created successfully,", formatBytes(hashedBytes), "hashed")
+	}
+
+	if overwrittenChecksumFilesQuantity > 0 {
+		fmt.Println("🔄 :", overwrittenChecksumFilesQuantity, "existing checksum files overwritten,", formatBytes(overwrittenBytes), "hashed")
+	}
+
+	if wouldCreateQuantity > 0 {
+		fmt.Println("🆕 :", wouldCreateQuantity, "checksum files would be created")
+	}
+
+	if wouldOverwriteQuantity > 0 {
+		fmt.Println("♻️ :", wouldOverwriteQuantity, "existing checksum files would be overwritten")
+	}
+
+	if wouldCreateQuantity+wouldOverwriteQuantity > 0 {
+		fmt.Println("   ", formatBytes(wouldHashBytes), "would be hashed, nothing was written (--dry-run)")
 	}
 
 	if existingChecksumFilesQuantity > 0 {
-		fmt.Println("⏭️ :", existingChecksumFilesQuantity, "files already have an existing checksum file")
+		fmt.Println("⏭️ :", existingChecksumFilesQuantity, "files skipped because they already have a checksum file")
 	}
 
 	if lockedChecksumFilesQuantity > 0 {
@@ -235,6 +728,30 @@ func printResultsCreatingChecksumFiles(results []ChecksumFileCreationResult) {
 		}
 	}
 
+	if len(specialResults) > 0 {
+		fmt.Println("🔌 :", len(specialResults), "special files skipped")
+		for _, specialResult := range specialResults {
+			fmt.Print("- ", specialResult.Path, " (", specialResult.Error, ")")
+			fmt.Println()
+		}
+	}
+
+	if len(unstableResults) > 0 {
+		fmt.Println("🌀 :", len(unstableResults), "files modified while they were hashed, without a checksum file")
+		for _, unstableResult := range unstableResults {
+			fmt.Print("- ", unstableResult.Path, " | ", unstableResult.Error)
+			fmt.Println()
+		}
+	}
+
+	if len(timedOutResults) > 0 {
+		fmt.Println("⌛ :", len(timedOutResults), "files abandoned after --file-timeout, without a checksum file")
+		for _, timedOutResult := range timedOutResults {
+			fmt.Print("- ", timedOutResult.Path, " | ", timedOutResult.Error)
+			fmt.Println()
+		}
+	}
+
 	if len(failedResults) > 0 {
 		fmt.Println("❌ :", len(failedResults), "checksum files failed to create")
 		for _, failedResult := range failedResults {
@@ -246,6 +763,7 @@ func printResultsCreatingChecksumFiles(results []ChecksumFileCreationResult) {
 
 func printErrorsCreatingChecksumFiles() {
 	if len(errorsCreatingChecksumFiles) > 0 {
+		sortErrors(errorsCreatingChecksumFiles)
 		fmt.Println()
 		fmt.Println("Errors:")
 
@@ -254,3 +772,23 @@ func printErrorsCreatingChecksumFiles() {
 		}
 	}
 }
+
+// coreutilsChecksumLine returns the line of sha512sum for the file, escaping the backslashes and new lines of its
+// name as sha512sum does
+func coreutilsChecksumLine(hexFileChecksum string, fileName string) string {
+	if !strings.ContainsAny(fileName, "\\\n") {
+		return fmt.Sprintf("%s  %s\n", hexFileChecksum, fileName)
+	}
+	escapedName := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(fileName)
+	return fmt.Sprintf("\\%s  %s\n", hexFileChecksum, escapedName)
+}
+
+// metadataHeader returns the "# " comments written by --header before the checksums, telling how they were created.
+// They are ignored when the checksum files and manifests are read.
+func metadataHeader(algorithmNames ...string) string {
+	header := fmt.Sprintf("# checksum-utils %s\n# algorithm: %s\n# created: %s\n", version, strings.Join(algorithmNames, ", "), time.Now().UTC().Format(time.RFC3339))
+	if hostname, err := os.Hostname(); err == nil {
+		header += "# host: " + hostname + "\n"
+	}
+	return header
+}
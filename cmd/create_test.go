@@ -3,10 +3,14 @@ package cmd
 import (
 	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreateChecksumFile_CreatesAndWritesChecksum(t *testing.T) {
@@ -162,3 +166,340 @@ func TestCreateChecksumFile_ExistingChecksumUnreadableDataFile(t *testing.T) {
 		t.Fatalf("checksum file should not be overwritten")
 	}
 }
+
+func TestHandleChecksumFileCreation_OnlyMissing(t *testing.T) {
+	onlyMissing = true
+	defer func() { onlyMissing = false }()
+
+	tempDir := t.TempDir()
+	existingPath := filepath.Join(tempDir, "existing.txt")
+	missingPath := filepath.Join(tempDir, "missing.txt")
+	for _, filePath := range []string{existingPath, missingPath} {
+		if err := os.WriteFile(filePath, []byte("content of "+filepath.Base(filePath)), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	if err := os.WriteFile(existingPath+checksumFileExtension, []byte("previous checksum"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	var results []ChecksumFileCreationResult
+	for _, filePath := range []string{existingPath, missingPath} {
+		if err := handleChecksumFileCreation(filePath, &results); err != nil {
+			t.Fatalf("handle %s: %v", filePath, err)
+		}
+	}
+
+	if len(results) != 2 || results[0].Status != Existing || results[1].Status != Created {
+		t.Fatalf("expected the existing checksum file skipped and the missing one created, got %+v", results)
+	}
+	if expected := int64(len("content of missing.txt")); results[1].HashedBytes != expected {
+		t.Fatalf("expected %d bytes hashed, got %d", expected, results[1].HashedBytes)
+	}
+}
+
+func TestCreateChecksumFile_CoreutilsFormat(t *testing.T) {
+	sidecarFormat = coreutilsSidecarFormat
+	defer func() { sidecarFormat = bareSidecarFormat }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	data := []byte("hello checksum")
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	checksumBytes, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	hash := sha512.Sum512(data)
+	expected := hex.EncodeToString(hash[:]) + "  data.txt\n"
+	if string(checksumBytes) != expected {
+		t.Fatalf("checksum content mismatch: expected %q, got %q", expected, string(checksumBytes))
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestCoreutilsChecksumLine_EscapedName(t *testing.T) {
+	if got, want := coreutilsChecksumLine("abc", "a\\b\nc"), "\\abc  a\\\\b\\nc\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := parseChecksumFile(coreutilsChecksumLine("abc", "a\\b\nc")); got.Checksum != "abc" {
+		t.Fatalf("expected the checksum abc, got %q", got.Checksum)
+	}
+}
+
+func TestCreateChecksumFile_ForceOverwritesExisting(t *testing.T) {
+	overwriteExisting = true
+	defer func() { overwriteExisting = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	data := []byte("hello checksum")
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filePath+".sha512", []byte("stale"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	result := createChecksumFile(filePath)
+	if result.Status != Overwritten {
+		t.Fatalf("expected status %s, got %s (%v)", Overwritten, result.Status, result.Error)
+	}
+	if result.HashedBytes != int64(len(data)) {
+		t.Fatalf("expected %d hashed bytes, got %d", len(data), result.HashedBytes)
+	}
+
+	checksumBytes, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	hash := sha512.Sum512(data)
+	if expected := hex.EncodeToString(hash[:]); string(checksumBytes) != expected {
+		t.Fatalf("checksum content mismatch: expected %q, got %q", expected, string(checksumBytes))
+	}
+}
+
+func TestCreateChecksumFile_UpdateStale(t *testing.T) {
+	updateStale = true
+	defer func() { updateStale = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("draft"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	if result := createChecksumFile(filePath); result.Status != Existing {
+		t.Fatalf("expected status %s for an up to date checksum file, got %s (%v)", Existing, result.Status, result.Error)
+	}
+
+	if err := os.WriteFile(filePath, []byte("final"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	modifiedTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filePath, modifiedTime, modifiedTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Overwritten {
+		t.Fatalf("expected status %s for a stale checksum file, got %s (%v)", Overwritten, result.Status, result.Error)
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestWriteChecksumFile_LeavesNoTemporaryFile(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filePath+".sha512", []byte("stale"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if err := writeChecksumFile(filePath, "abc"); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected only the file and its checksum file, got %d entries", len(entries))
+	}
+	if checksumBytes, err := os.ReadFile(filePath + ".sha512"); err != nil || string(checksumBytes) != "abc" {
+		t.Fatalf("expected the checksum file to be replaced, got %q (%v)", checksumBytes, err)
+	}
+}
+
+func TestCreateChecksumFile_MatchModTime(t *testing.T) {
+	matchModTime = true
+	defer func() { matchModTime = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	modTime := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	checksumFileInfo, err := os.Stat(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("stat checksum file: %v", err)
+	}
+	if !checksumFileInfo.ModTime().Equal(modTime) {
+		t.Fatalf("expected the checksum file modified on %s, got %s", modTime, checksumFileInfo.ModTime())
+	}
+}
+
+func TestWriteChecksumFile_Newline(t *testing.T) {
+	appendNewline = true
+	defer func() { appendNewline = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := writeChecksumFile(filePath, "abc"); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	if checksumBytes, err := os.ReadFile(filePath + ".sha512"); err != nil || string(checksumBytes) != "abc\n" {
+		t.Fatalf("expected the checksum followed by a new line, got %q (%v)", checksumBytes, err)
+	}
+}
+
+func TestCreateChecksumFile_Header(t *testing.T) {
+	writeHeader = true
+	defer func() { writeHeader = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	checksumBytes, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	if !strings.HasPrefix(string(checksumBytes), "# checksum-utils "+version+"\n# algorithm: sha512\n# created: ") {
+		t.Fatalf("expected the metadata header, got %q", checksumBytes)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestCreateChecksumFile_HiddenSidecar(t *testing.T) {
+	hiddenSidecars = true
+	defer func() { hiddenSidecars = false }()
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	hiddenPath := filepath.Join(tempDir, ".data.txt.sha512")
+	if _, err := os.Stat(hiddenPath); err != nil {
+		t.Fatalf("expected the hidden checksum file: %v", err)
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		t.Fatalf("remove file: %v", err)
+	}
+	if result, orphaned := checkOrphanedChecksumFile(hiddenPath); !orphaned || result.Path != filePath {
+		t.Fatalf("expected the hidden checksum file of %s to be orphaned, got %v %s", filePath, orphaned, result.Path)
+	}
+}
+
+func TestCreateChecksumFile_ForceMultiEntry(t *testing.T) {
+	overwriteExisting = true
+	defer func() { overwriteExisting = false }()
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "a")
+	if err := os.WriteFile(filePath, []byte("modified"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	otherHash := sha512.Sum512([]byte("other"))
+	otherLine := hex.EncodeToString(otherHash[:]) + "  b\n"
+	if err := os.WriteFile(filePath+".sha512", []byte(strings.Repeat("0", 128)+"  a\n"+otherLine), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Overwritten {
+		t.Fatalf("expected status %s, got %s (%v)", Overwritten, result.Status, result.Error)
+	}
+
+	// Only the line of the file is rewritten, the checksum of the other listed file is kept
+	content, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	hash := sha512.Sum512([]byte("modified"))
+	if expected := hex.EncodeToString(hash[:]) + "  a\n" + otherLine; string(content) != expected {
+		t.Fatalf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestCreateChecksumFile_DryRun(t *testing.T) {
+	dryRun, overwriteExisting = true, true
+	defer func() { dryRun, overwriteExisting = false, false }()
+
+	tempDir := t.TempDir()
+	newPath := filepath.Join(tempDir, "new.txt")
+	existingPath := filepath.Join(tempDir, "existing.txt")
+	for _, path := range []string{newPath, existingPath} {
+		if err := os.WriteFile(path, []byte("hello checksum"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	if err := os.WriteFile(existingPath+".sha512", []byte("existing"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if result := createChecksumFile(newPath); result.Status != WouldCreate {
+		t.Fatalf("expected status %s, got %s", WouldCreate, result.Status)
+	}
+	if result := createChecksumFile(existingPath); result.Status != WouldOverwrite {
+		t.Fatalf("expected status %s, got %s", WouldOverwrite, result.Status)
+	}
+
+	if _, err := os.Stat(newPath + ".sha512"); !os.IsNotExist(err) {
+		t.Fatalf("expected no checksum file to be created, got %v", err)
+	}
+	if content, err := os.ReadFile(existingPath + ".sha512"); err != nil || string(content) != "existing" {
+		t.Fatalf("expected the checksum file to be kept, got %q (%v)", content, err)
+	}
+}
+
+func TestPrintResultsCreatingChecksumFiles_SortedByPath(t *testing.T) {
+	jobs = 4
+	defer func() { jobs = 1 }()
+
+	tempDir := t.TempDir()
+	for i := range 20 {
+		path := filepath.Join(tempDir, fmt.Sprintf("file-%02d.txt", i))
+		if err := os.WriteFile(path, []byte(path), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	var results []ChecksumFileCreationResult
+	processPaths([]string{tempDir}, &errorsCreatingChecksumFiles, func(filePath string) error {
+		return handleChecksumFileCreation(filePath, &results)
+	})
+	printResultsCreatingChecksumFiles(results)
+
+	if len(results) != 20 || !slices.IsSortedFunc(results, func(a ChecksumFileCreationResult, b ChecksumFileCreationResult) int {
+		return strings.Compare(a.Path, b.Path)
+	}) {
+		t.Fatalf("expected the 20 results sorted by path, got %+v", results)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// emailRecipients is set by --email-to in check
+var emailRecipients []string
+
+// smtpServer, smtpUsername, smtpPassword and smtpSender are the settings of the SMTP server used by --email-to,
+// usually set in the configuration file
+var smtpServer string
+var smtpUsername string
+var smtpPassword string
+var smtpSender string
+
+// smtpImplicitTLSPort is the port of the SMTP servers that expect TLS from the start instead of STARTTLS
+const smtpImplicitTLSPort = "465"
+
+// validateEmailSettings returns an error when --email-to is used without the settings needed to send the report
+func validateEmailSettings() error {
+	if len(emailRecipients) == 0 {
+		return nil
+	}
+	if smtpServer == "" {
+		return fmt.Errorf("--email-to requires --smtp-server")
+	}
+	if _, _, err := net.SplitHostPort(smtpServer); err != nil {
+		return fmt.Errorf("--smtp-server %q is not a host and a port, like smtp.example.com:587", smtpServer)
+	}
+	if emailSender() == "" {
+		return fmt.Errorf("--email-to requires --smtp-from or --smtp-username")
+	}
+	return nil
+}
+
+// emailSender returns the address the reports are sent from: --smtp-from, or else the SMTP username
+func emailSender() string {
+	if smtpSender != "" {
+		return smtpSender
+	}
+	if strings.Contains(smtpUsername, "@") {
+		return smtpUsername
+	}
+	return ""
+}
+
+// sendEmailReport sends the report of the check to the addresses given with --email-to
+func sendEmailReport(results []ChecksumFileVerificationResult, errs []error) error {
+	if len(emailRecipients) == 0 {
+		return nil
+	}
+
+	message := formatEmailReport(results, errs, emailSender(), emailRecipients, time.Now())
+	if err := sendEmail(emailSender(), emailRecipients, message); err != nil {
+		return fmt.Errorf("the email report could not be sent: %w", err)
+	}
+	return nil
+}
+
+// sendEmail sends the message through the SMTP server given with --smtp-server, with STARTTLS when the server
+// supports it or TLS from the start on port 465, authenticating when --smtp-username is given
+func sendEmail(from string, to []string, message []byte) error {
+	host, port, err := net.SplitHostPort(smtpServer)
+	if err != nil {
+		return err
+	}
+
+	password := smtpPassword
+	if password == "" {
+		password = os.Getenv("SMTP_PASSWORD")
+	}
+	var auth smtp.Auth
+	if smtpUsername != "" {
+		auth = smtp.PlainAuth("", smtpUsername, password, host)
+	}
+
+	if port != smtpImplicitTLSPort {
+		return smtp.SendMail(smtpServer, auth, from, to, message)
+	}
+
+	connection, err := tls.Dial("tcp", smtpServer, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(connection, host)
+	if err != nil {
+		connection.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// formatEmailReport returns the email with the report of the check finished at finishedAt: the summary in the
+// subject, so the problems are seen from the inbox, and the files that did not match or failed in the body
+func formatEmailReport(results []ChecksumFileVerificationResult, errs []error, from string, to []string, finishedAt time.Time) []byte {
+	summary := summarizeCheck(results)
+	host := hostname()
+	subject := checkHeadline(results, errs)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Check finished on %s at %s, after %s.\r\n\r\n", host, finishedAt.Format(time.RFC1123Z), formatDuration(finishedAt.Sub(checkStartedAt)))
+	fmt.Fprintf(&body, "Files checked: %d\r\n", summary.Checked)
+	fmt.Fprintf(&body, "Files matched: %d\r\n", summary.Matched)
+	fmt.Fprintf(&body, "Files not matched: %d\r\n", summary.Mismatched)
+	fmt.Fprintf(&body, "Files failed: %d\r\n", summary.Failed)
+	fmt.Fprintf(&body, "Data hashed: %s\r\n", formatBytes(hashedBytesTotal.Load()))
+
+	writeSection := func(title string, statuses ...ChecksumFileVerificationStatus) {
+		var lines []string
+		for _, result := range results {
+			for _, status := range statuses {
+				if result.Status != status {
+					continue
+				}
+				line := "- " + result.Path
+				if result.Error != nil {
+					line += " | " + result.Error.Error()
+				}
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&body, "\r\n%s:\r\n%s\r\n", title, strings.Join(lines, "\r\n"))
+		}
+	}
+	writeSection("Not matched", NotMatch)
+	writeSection("Failed", CheckingFailed, LockedVerification, BadSignature, Malformed, TimedOutVerification)
+	if len(errs) > 0 {
+		fmt.Fprint(&body, "\r\nErrors:\r\n")
+		for _, err := range errs {
+			fmt.Fprintf(&body, "- %s\r\n", err)
+		}
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&message, "Date: %s\r\n", finishedAt.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(body.String())
+	return []byte(message.String())
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatEmailReport(t *testing.T) {
+	results := []ChecksumFileVerificationResult{
+		{Path: "/volume1/a.raw", Status: Match},
+		{Path: "/volume1/b.raw", Status: NotMatch, Error: errors.New("probable bit rot")},
+	}
+
+	message := string(formatEmailReport(results, nil, "nas@example.com", []string{"admin@example.com", "backup@example.com"}, time.Now()))
+
+	for _, expected := range []string{
+		"From: nas@example.com\r\n",
+		"To: admin@example.com, backup@example.com\r\n",
+		": 1 files do not match, 0 failed, 0 errors\r\n",
+		"Files matched: 1\r\n",
+		"Not matched:\r\n- /volume1/b.raw | probable bit rot\r\n",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("expected the email to contain %q, got:\n%s", expected, message)
+		}
+	}
+}
+
+func TestValidateEmailSettings(t *testing.T) {
+	defer func() { emailRecipients, smtpServer, smtpSender = nil, "", "" }()
+
+	emailRecipients = []string{"admin@example.com"}
+	if err := validateEmailSettings(); err == nil {
+		t.Fatalf("expected --email-to without --smtp-server to be rejected")
+	}
+	smtpServer = "smtp.example.com"
+	if err := validateEmailSettings(); err == nil {
+		t.Fatalf("expected --smtp-server without a port to be rejected")
+	}
+	smtpServer = "smtp.example.com:587"
+	if err := validateEmailSettings(); err == nil {
+		t.Fatalf("expected --email-to without a sender to be rejected")
+	}
+	smtpSender = "nas@example.com"
+	if err := validateEmailSettings(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSendEmailReport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer connection.Close()
+
+		reader := bufio.NewReader(connection)
+		reply := func(line string) { connection.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				inData = true
+				reply("354 Go ahead")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	emailRecipients = []string{"admin@example.com"}
+	smtpServer = listener.Addr().String()
+	smtpSender = "nas@example.com"
+	defer func() { emailRecipients, smtpServer, smtpSender = nil, "", "" }()
+
+	if err := sendEmailReport([]ChecksumFileVerificationResult{{Path: "/volume1/a.raw", Status: Match}}, nil); err != nil {
+		t.Fatalf("send email: %v", err)
+	}
+
+	select {
+	case message := <-received:
+		if !strings.Contains(message, ": 1 files match\r\n") {
+			t.Fatalf("unexpected email:\n%s", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the email was not received")
+	}
+}
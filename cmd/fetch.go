@@ -0,0 +1,222 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var fetchExpected string
+var fetchOutputPath string
+
+// errFetchedChecksumNotMatch is returned when the downloaded file does not match the expected checksum
+var errFetchedChecksumNotMatch = errors.New("the downloaded file does not match the expected checksum, it was discarded")
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Download a file and verify its checksum.",
+	Long: `Download a file, computing its checksum while it is written, and keep it only when the checksum matches.
+--expect takes the hexadecimal checksum of the file (md5, sha1, sha256, sha384 or sha512),
+or the URL of a manifest like SHA512SUMS that lists the file.
+
+Example:
+  checksum-utils fetch https://example.com/debian.iso --expect 9b71d224bd62f378...
+  checksum-utils fetch https://example.com/debian.iso --expect https://example.com/SHA512SUMS
+  checksum-utils fetch https://example.com/debian.iso --expect https://example.com/SHA512SUMS -o ~/downloads/debian.iso
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printHeader()
+
+		fileURL := args[0]
+		failFetch := func(err error) {
+			fmt.Println()
+			fmt.Println("Error: ", err)
+			os.Exit(1)
+		}
+
+		fileName, err := fileNameFromURL(fileURL)
+		if err != nil {
+			failFetch(err)
+		}
+
+		outputPath := fetchOutputPath
+		if outputPath == "" {
+			outputPath = fileName
+		}
+		outputAbsolutePath, err := filepath.Abs(outputPath)
+		if err != nil {
+			failFetch(err)
+		}
+
+		expected, algorithm, err := resolveExpectedChecksum(fetchExpected, fileName)
+		if err != nil {
+			failFetch(err)
+		}
+
+		fmt.Println()
+		fmt.Println("Downloading", fileURL, "("+algorithm.Name+")")
+
+		prefix := fmt.Sprintf("- %s ", outputAbsolutePath)
+		spinner := startProgress(prefix)
+		start := time.Now()
+		err = fetchFile(fileURL, outputAbsolutePath, expected, algorithm)
+		elapsed := time.Since(start)
+		spinner.Stop()
+
+		if spinner.Enabled() {
+			clearProgressLine(prefix)
+		} else {
+			fmt.Print(prefix)
+		}
+
+		switch {
+		case err == nil:
+			fmt.Printf("✅ (%s)\n", formatDuration(elapsed))
+		case errors.Is(err, errFetchedChecksumNotMatch):
+			fmt.Printf("⚠️ (%s)\n", formatDuration(elapsed))
+			failFetch(err)
+		default:
+			fmt.Println("❌")
+			failFetch(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+
+	fetchCmd.Flags().StringVar(&fetchExpected, "expect", "", "expected hexadecimal checksum of the file, or URL of a manifest like SHA512SUMS that lists it")
+	fetchCmd.Flags().StringVarP(&fetchOutputPath, "output", "o", "", "path where the file is saved (default: the file name of the URL in the current directory)")
+	fetchCmd.MarkFlagRequired("expect")
+}
+
+// fileNameFromURL returns the last element of the path of the URL
+func fileNameFromURL(fileURL string) (string, error) {
+	parsedURL, err := url.Parse(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := path.Base(parsedURL.Path)
+	if fileName == "." || fileName == "/" {
+		return "", fmt.Errorf("cannot get the file name of %s, use --output", fileURL)
+	}
+
+	return fileName, nil
+}
+
+// isURL reports whether the value is an http or https URL
+func isURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+// resolveExpectedChecksum returns the checksum given with --expect and its algorithm,
+// downloading the manifest and looking for the file in it when --expect is a URL
+func resolveExpectedChecksum(expected string, fileName string) (string, hashAlgorithm, error) {
+	expected = strings.TrimSpace(expected)
+
+	if !isURL(expected) {
+		algorithm, err := algorithmForDigest(expected)
+		return expected, algorithm, err
+	}
+
+	response, err := http.Get(expected)
+	if err != nil {
+		return "", hashAlgorithm{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", hashAlgorithm{}, fmt.Errorf("%s: %s", expected, response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", hashAlgorithm{}, err
+	}
+
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		return "", hashAlgorithm{}, fmt.Errorf("%s: %w", expected, err)
+	}
+
+	for _, entry := range entries {
+		if path.Base(filepath.ToSlash(entry.Path)) == fileName {
+			return entry.Checksum, entry.Algorithm, nil
+		}
+	}
+
+	return "", hashAlgorithm{}, fmt.Errorf("%s is not listed in %s", fileName, expected)
+}
+
+// fetchFile downloads the file into a temporary file next to the output path, computing its checksum while it is written,
+// and moves it to the output path only when the checksum matches
+func fetchFile(fileURL string, outputAbsolutePath string, expected string, algorithm hashAlgorithm) error {
+	if _, err := os.Lstat(outputAbsolutePath); err == nil {
+		return fmt.Errorf("%s already exists", outputAbsolutePath)
+	}
+
+	response, err := http.Get(fileURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", fileURL, response.Status)
+	}
+
+	temporaryFile, err := os.CreateTemp(filepath.Dir(outputAbsolutePath), "."+filepath.Base(outputAbsolutePath)+".*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	hasher := algorithm.New()
+	if _, err := io.Copy(io.MultiWriter(temporaryFile, hasher), response.Body); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	// os.CreateTemp creates the file readable only by its owner, the downloaded file is readable like any other
+	if err := temporaryFile.Chmod(0o644); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), expected) {
+		return errFetchedChecksumNotMatch
+	}
+
+	return os.Rename(temporaryFile.Name(), outputAbsolutePath)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFetchFile(t *testing.T) {
+	data := []byte("debian")
+	hash := sha512.Sum512(data)
+	checksum := hex.EncodeToString(hash[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/debian.iso":
+			w.Write(data)
+		case "/SHA512SUMS":
+			fmt.Fprintf(w, "%s  other.iso\n%s  debian.iso\n", strings.Repeat("0", 128), checksum)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fileName, err := fileNameFromURL(server.URL + "/debian.iso")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, algorithm, err := resolveExpectedChecksum(server.URL+"/SHA512SUMS", fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected != checksum || algorithm.Name != "sha512" {
+		t.Fatalf("unexpected checksum %s (%s)", expected, algorithm.Name)
+	}
+
+	tempDir := t.TempDir()
+	outputPath := filepath.Join(tempDir, fileName)
+	if err := fetchFile(server.URL+"/debian.iso", outputPath, expected, algorithm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(outputPath); err != nil || string(content) != string(data) {
+		t.Fatalf("unexpected downloaded file: %q (%v)", content, err)
+	}
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("stat downloaded file: %v", err)
+	}
+	if runtime.GOOS != "windows" && fileInfo.Mode().Perm() != 0o644 {
+		t.Fatalf("expected the downloaded file to be readable by everyone, got %v", fileInfo.Mode())
+	}
+
+	otherPath := filepath.Join(tempDir, "other.iso")
+	err = fetchFile(server.URL+"/debian.iso", otherPath, strings.Repeat("0", 128), algorithm)
+	if !errors.Is(err, errFetchedChecksumNotMatch) {
+		t.Fatalf("expected %v, got %v", errFetchedChecksumNotMatch, err)
+	}
+	if _, err := os.Stat(otherPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the not matching download to be discarded, got %v", err)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the downloaded file, got %d entries", len(entries))
+	}
+}
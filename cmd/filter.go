@@ -0,0 +1,501 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// globPatterns is a list of glob patterns given by repeating a flag, like --include '*.raw' --include '*.jpg'
+type globPatterns []string
+
+func (p *globPatterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *globPatterns) Set(value string) error {
+	if _, err := filepath.Match(filepath.ToSlash(value), ""); err != nil {
+		return fmt.Errorf("%q is not a valid glob pattern", value)
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+func (p *globPatterns) Type() string {
+	return "pattern"
+}
+
+// includePatterns limits the walks to the files matching any of them
+var includePatterns globPatterns
+
+// excludePatterns skips the files and directories matching any of them during the walks
+var excludePatterns globPatterns
+
+// regexpPatterns is a list of regular expressions given by repeating a flag
+type regexpPatterns []*regexp.Regexp
+
+func (p *regexpPatterns) String() string {
+	expressions := make([]string, 0, len(*p))
+	for _, expression := range *p {
+		expressions = append(expressions, expression.String())
+	}
+	return strings.Join(expressions, ",")
+}
+
+func (p *regexpPatterns) Set(value string) error {
+	expression, err := regexp.Compile(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid regular expression: %w", value, err)
+	}
+	*p = append(*p, expression)
+	return nil
+}
+
+func (p *regexpPatterns) Type() string {
+	return "regexp"
+}
+
+// matchesAnyRegexp reports whether the path relative to the walked directory, with / as separator, matches
+// any of the regular expressions
+func matchesAnyRegexp(expressions regexpPatterns, relativePath string) bool {
+	relativePath = filepath.ToSlash(relativePath)
+	for _, expression := range expressions {
+		if expression.MatchString(relativePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// includeRegexps limits the walks to the files whose relative path matches any of them
+var includeRegexps regexpPatterns
+
+// excludeRegexps skips the files and directories whose relative path matches any of them during the walks
+var excludeRegexps regexpPatterns
+
+const includeRegexpsUsage = "only process the files whose path relative to the walked directory, with / as separator, matches this regular expression (can be repeated)"
+const excludeRegexpsUsage = "skip the files and directories whose path relative to the walked directory, with / as separator, matches this regular expression (can be repeated)"
+
+// onlyExtensions limits the walks to the files with one of these extensions, given with or without the dot
+var onlyExtensions []string
+
+const onlyExtensionsUsage = "only process the files with one of these extensions, in any case, like mkv,flac,raw"
+
+// hasOnlyExtension reports whether the file has one of the --only-ext extensions
+func hasOnlyExtension(path string) bool {
+	extension := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, onlyExtension := range onlyExtensions {
+		if strings.EqualFold(extension, strings.TrimPrefix(onlyExtension, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeCommon skips the commonExclusions during the walks
+var excludeCommon bool
+
+// commonExclusions are the names of the version control directories, dependency caches and the junk files
+// created by file managers and NAS indexers, which pollute the checksum files and the reports of deleted files
+var commonExclusions = globPatterns{
+	".git", ".svn", ".hg", ".bzr", "CVS",
+	"node_modules", "__pycache__",
+	"@eaDir", "#recycle", "#snapshot", ".@__thumb",
+	".DS_Store", "._*", ".AppleDouble", ".Spotlight-V100", ".Trashes", ".fseventsd", ".TemporaryItems",
+	"Thumbs.db", "ehthumbs.db", "desktop.ini", "$RECYCLE.BIN", "System Volume Information",
+}
+
+const excludeCommonUsage = "skip version control directories, node_modules and junk like .DS_Store, Thumbs.db, desktop.ini and @eaDir"
+
+// excludeRules skips the files and directories matching the rules of the --exclude-from files during the walks
+var excludeRules ignoreRules
+
+// maxDepth limits the walks to the files this number of directories deep, 1 being the files of the walked
+// directory itself, or no limit when it is 0
+var maxDepth int
+
+// skipHidden skips the dotfiles and dot-directories, and the files with the hidden attribute on Windows, during the walks
+var skipHidden bool
+
+const skipHiddenUsage = "skip the hidden files and directories: the ones whose name starts with a dot, and the ones with the hidden attribute on Windows"
+
+// includeSnapshots walks the snapshot directories, skipped by default
+var includeSnapshots bool
+
+const includeSnapshotsUsage = "walk the read-only snapshot directories of ZFS, Btrfs and NAS, like .zfs, .snapshot, .snapshots and @snapshot, skipped by default"
+
+// snapshotDirectories are the names of the directories exposing the read-only snapshots of ZFS, Btrfs (as created by
+// snapper) and NAS: walking them processes every file once per snapshot, and create reports them as Locked or Existing
+var snapshotDirectories = []string{".zfs", ".snapshot", ".snapshots", "@snapshot", "@snapshots", "#snapshot", "~snapshot"}
+
+// isSnapshotDirectory reports whether the directory exposes snapshots
+func isSnapshotDirectory(path string) bool {
+	name := filepath.Base(path)
+	for _, snapshotDirectory := range snapshotDirectories {
+		if strings.EqualFold(name, snapshotDirectory) {
+			return true
+		}
+	}
+	return false
+}
+
+// minFileSize and maxFileSize skip the files smaller or larger than them during the walks, when they are not 0
+var minFileSize byteSize
+var maxFileSize byteSize
+
+const minFileSizeUsage = "skip the files smaller than this size, like 1M"
+const maxFileSizeUsage = "skip the files larger than this size, like 500G (default: no limit)"
+
+// pointInTime is a time given as a flag, a date like 2024-01-01, a date and time, or an age like 30d before now
+type pointInTime struct {
+	value string
+	time  time.Time
+}
+
+func (p *pointInTime) String() string {
+	return p.value
+}
+
+func (p *pointInTime) Set(value string) error {
+	parsed, err := parsePointInTime(value, time.Now())
+	if err != nil {
+		return err
+	}
+	p.value = value
+	p.time = parsed
+	return nil
+}
+
+func (p *pointInTime) Type() string {
+	return "time"
+}
+
+// ageUnits are the units of the ages, from seconds to weeks
+var ageUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parsePointInTime parses a date like 2024-01-01, a date and time like 2024-01-01 12:30:00 or 2024-01-01T12:30:00Z,
+// in local time unless it has a time zone, or an age like 30d, 12h or 2w before now
+func parsePointInTime(value string, now time.Time) (time.Time, error) {
+	trimmed := strings.TrimSpace(value)
+
+	if len(trimmed) > 1 {
+		if unit, ok := ageUnits[strings.ToLower(trimmed[len(trimmed)-1:])]; ok {
+			if number, err := strconv.Atoi(trimmed[:len(trimmed)-1]); err == nil && number >= 0 {
+				return now.Add(-time.Duration(number) * unit), nil
+			}
+		}
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, trimmed); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.DateTime, "2006-01-02T15:04:05"} {
+		if parsed, err := time.ParseInLocation(layout, trimmed, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a valid time, use a date like 2024-01-01, a date and time like 2024-01-01 12:30:00, or an age like 30d", value)
+}
+
+// newerThan and olderThan skip the files modified before or after them during the walks, when they are set
+var newerThan pointInTime
+var olderThan pointInTime
+
+const newerThanUsage = "skip the files modified before this date, like 2024-01-01, or this age, like 7d"
+const olderThanUsage = "skip the files modified after this date, like 2024-01-01, or this age, like 30d"
+
+const maxDepthUsage = "only process the files up to this number of directories deep, 1 being the files of the walked directory itself (default: no limit)"
+
+const includePatternsUsage = "only process the files whose name, or path relative to the walked directory when it has a /, matches this glob, like '*.raw' (can be repeated)"
+const excludePatternsUsage = "skip the files and directories whose name, or path relative to the walked directory when it has a /, matches this glob, like '*.tmp' (can be repeated)"
+
+// matchesAnyPattern reports whether the name, or the path relative to the walked directory for the patterns with a /,
+// matches any of the patterns
+func matchesAnyPattern(patterns globPatterns, relativePath string) bool {
+	relativePath = filepath.ToSlash(relativePath)
+	name := relativePath[strings.LastIndex(relativePath, "/")+1:]
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		subject := name
+		if strings.Contains(pattern, "/") {
+			subject = relativePath
+			pattern = strings.TrimPrefix(pattern, "/")
+		}
+		if matched, _ := filepath.Match(pattern, subject); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isFilteredOut reports whether the walk skips the entry of the walked directory root: the hidden entries with
+// --skip-hidden, the snapshot directories without --include-snapshots, the --store-dir directory, the directories at --max-depth or matching --exclude, --exclude-regex, --exclude-common or the
+// --exclude-from rules, and the files matching them, not matching --include or --include-regex, without one of the
+// --only-ext extensions, or outside the --min-size, --max-size, --newer-than and --older-than ranges. Checksum files
+// are filtered as the files they protect, so their data file is still found when it is missing.
+func isFilteredOut(root string, path string, isDir bool) bool {
+	if isDir && !includeSnapshots && isSnapshotDirectory(path) {
+		return true
+	}
+
+	if len(includePatterns) == 0 && len(excludePatterns) == 0 && storeDirectory == "" && len(includeRegexps) == 0 && len(excludeRegexps) == 0 && len(excludeRules.rules) == 0 && !excludeCommon && len(onlyExtensions) == 0 && maxDepth <= 0 && !skipHidden && minFileSize == 0 && maxFileSize == 0 && newerThan.value == "" && olderThan.value == "" {
+		return false
+	}
+
+	// The checksum files named with --hidden-sidecar are hidden only when their file is
+	if skipHidden && (isDir && isHidden(path) || !isDir && isHidden(trimChecksumFileExtension(path))) {
+		return true
+	}
+
+	if isDir && isStoreDirectory(path) {
+		return true
+	}
+
+	relativePath, err := filepath.Rel(root, path)
+	if err != nil {
+		relativePath = filepath.Base(path)
+	}
+
+	if isDir && maxDepth > 0 && strings.Count(filepath.ToSlash(relativePath), "/")+1 >= maxDepth {
+		return true
+	}
+
+	if !isDir {
+		relativePath = trimChecksumFileExtension(relativePath)
+	}
+
+	if matchesAnyPattern(excludePatterns, relativePath) || matchesAnyRegexp(excludeRegexps, relativePath) || excludeRules.ignores(relativePath, isDir) {
+		return true
+	}
+	if excludeCommon && matchesAnyPattern(commonExclusions, relativePath) {
+		return true
+	}
+	if isDir {
+		return false
+	}
+	if len(includePatterns) > 0 && !matchesAnyPattern(includePatterns, relativePath) {
+		return true
+	}
+	if len(includeRegexps) > 0 && !matchesAnyRegexp(includeRegexps, relativePath) {
+		return true
+	}
+	if len(onlyExtensions) > 0 && !hasOnlyExtension(relativePath) {
+		return true
+	}
+	return isOutsideFileRanges(trimChecksumFileExtension(path))
+}
+
+// isFilteredOutBelow reports whether the walk of the directory root skips the file, because it is filtered out or is
+// inside a directory that is
+func isFilteredOutBelow(root string, path string) bool {
+	for directory := filepath.Dir(path); strings.HasPrefix(directory, root+string(filepath.Separator)); directory = filepath.Dir(directory) {
+		if isFilteredOut(root, directory, true) {
+			return true
+		}
+	}
+	return isFilteredOut(root, path, false)
+}
+
+// isOutsideFileRanges reports whether the file is smaller than --min-size, larger than --max-size, or modified
+// before --newer-than or after --older-than. Missing files are kept, so the checksum files of deleted files
+// are still reported.
+func isOutsideFileRanges(path string) bool {
+	if minFileSize == 0 && maxFileSize == 0 && newerThan.value == "" && olderThan.value == "" {
+		return false
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if fileInfo.Size() < int64(minFileSize) || (maxFileSize > 0 && fileInfo.Size() > int64(maxFileSize)) {
+		return true
+	}
+	if newerThan.value != "" && fileInfo.ModTime().Before(newerThan.time) {
+		return true
+	}
+	return olderThan.value != "" && fileInfo.ModTime().After(olderThan.time)
+}
+
+// ignoreRule is a line of a gitignore-style file
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	// negated rules (!pattern) include again what a previous rule excluded
+	negated bool
+	// directoryOnly rules (pattern/) only match directories
+	directoryOnly bool
+	// anchored rules, the ones with a / before their end, match the path relative to the walked directory
+	// instead of the name of the file or directory
+	anchored bool
+}
+
+// ignoreRules are the rules of the files given to --exclude-from, read like a .gitignore at the root of the walked
+// directories: the last rule that matches a path decides whether it is skipped
+type ignoreRules struct {
+	files []string
+	rules []ignoreRule
+}
+
+func (r *ignoreRules) String() string {
+	return strings.Join(r.files, ",")
+}
+
+func (r *ignoreRules) Set(value string) error {
+	rules, err := readIgnoreFile(value)
+	if err != nil {
+		return err
+	}
+	r.files = append(r.files, value)
+	r.rules = append(r.rules, rules...)
+	return nil
+}
+
+func (r *ignoreRules) Type() string {
+	return "file"
+}
+
+const excludeFromUsage = "skip the files and directories matching the rules of this file, with the syntax of .gitignore relative to the walked directory, like .checksumignore (can be repeated)"
+
+// ignores reports whether the path relative to the walked directory is skipped by the rules
+func (r *ignoreRules) ignores(relativePath string, isDir bool) bool {
+	relativePath = filepath.ToSlash(relativePath)
+	name := relativePath[strings.LastIndex(relativePath, "/")+1:]
+
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.directoryOnly && !isDir {
+			continue
+		}
+		subject := name
+		if rule.anchored {
+			subject = relativePath
+		}
+		if rule.pattern.MatchString(subject) {
+			ignored = !rule.negated
+		}
+	}
+	return ignored
+}
+
+// readIgnoreFile reads the rules of a gitignore-style file
+func readIgnoreFile(path string) ([]ignoreRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		rule, ok, err := parseIgnoreRule(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseIgnoreRule parses a line of a gitignore-style file, reporting false for blank lines and comments
+func parseIgnoreRule(line string) (ignoreRule, bool, error) {
+	line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " ")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false, nil
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negated = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.directoryOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false, nil
+	}
+
+	pattern, err := ignorePatternToRegexp(line)
+	if err != nil {
+		return ignoreRule{}, false, err
+	}
+	rule.pattern = pattern
+	return rule, true, nil
+}
+
+// ignorePatternToRegexp converts a gitignore glob to a regular expression: * and ? do not match /,
+// and ** matches any number of directories
+func ignorePatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch character := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case character == '*':
+			expression.WriteString("[^/]*")
+		case character == '?':
+			expression.WriteString("[^/]")
+		case character == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("%q has an unclosed [", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + class + "]")
+			i += end + 1
+		case character == '\\' && i+1 < len(pattern):
+			i++
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.Compile(expression.String())
+}
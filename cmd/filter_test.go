@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIsFilteredOut(t *testing.T) {
+	defer func() { includePatterns, excludePatterns = nil, nil }()
+
+	root := filepath.Join("volume1", "photos")
+	includePatterns = globPatterns{"*.raw", "2024/*.jpg"}
+	excludePatterns = globPatterns{"*.tmp", "cache"}
+
+	cases := []struct {
+		path     string
+		isDir    bool
+		filtered bool
+	}{
+		{"wedding.raw", false, false},
+		{"wedding.raw.sha512", false, false},
+		{"wedding.jpg", false, true},
+		{"2024/wedding.jpg", false, false},
+		{"2024/wedding.jpg.sha512", false, false},
+		{"upload.raw.tmp", false, true},
+		{"2024", true, false},
+		{"cache", true, true},
+	}
+	for _, c := range cases {
+		if filtered := isFilteredOut(root, filepath.Join(root, filepath.FromSlash(c.path)), c.isDir); filtered != c.filtered {
+			t.Fatalf("expected %s filtered out %v, got %v", c.path, c.filtered, filtered)
+		}
+	}
+}
+
+func TestProcessPaths_IncludeExclude(t *testing.T) {
+	defer func() { includePatterns, excludePatterns = nil, nil }()
+
+	directory := t.TempDir()
+	for _, name := range []string{"a.raw", "b.tmp", "c.jpg", filepath.Join("cache", "d.raw"), filepath.Join("2024", "e.raw")} {
+		filePath := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(name), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if err := includePatterns.Set("*.raw"); err != nil {
+		t.Fatalf("set include: %v", err)
+	}
+	if err := excludePatterns.Set("cache"); err != nil {
+		t.Fatalf("set exclude: %v", err)
+	}
+	if err := excludePatterns.Set("[bad"); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+
+	for _, walkers := range []int{1, 4} {
+		jobs = walkers
+		var files []string
+		var errs []error
+		processPaths([]string{directory}, &errs, func(filePath string) error {
+			relativePath, _ := filepath.Rel(directory, filePath)
+			appendLocked(&files, filepath.ToSlash(relativePath))
+			return nil
+		})
+		jobs = 1
+
+		slices.Sort(files)
+		if !slices.Equal(files, []string{"2024/e.raw", "a.raw"}) {
+			t.Fatalf("expected only the .raw files outside cache with %d jobs, got %v", walkers, files)
+		}
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	ignoreFile := filepath.Join(t.TempDir(), ".checksumignore")
+	content := "# temporary files\n*.tmp\n\n.Trash-*/\n/exports/**/*.jpg\n!/exports/final/**\n\\#notes\n"
+	if err := os.WriteFile(ignoreFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+
+	var rules ignoreRules
+	if err := rules.Set(ignoreFile); err != nil {
+		t.Fatalf("read ignore file: %v", err)
+	}
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"upload.tmp", false, true},
+		{"photos/upload.tmp", false, true},
+		{".Trash-1000", true, true},
+		{".Trash-1000", false, false},
+		{"exports/2024/cover.jpg", false, true},
+		{"exports/cover.jpg", false, true},
+		{"exports/final/cover.jpg", false, false},
+		{"photos/exports/cover.jpg", false, false},
+		{"#notes", false, true},
+		{"photos/wedding.raw", false, false},
+	}
+	for _, c := range cases {
+		if ignored := rules.ignores(c.path, c.isDir); ignored != c.ignored {
+			t.Fatalf("expected %s ignored %v, got %v", c.path, c.ignored, ignored)
+		}
+	}
+
+	if err := os.WriteFile(ignoreFile, []byte("[unclosed\n"), 0o600); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+	if err := rules.Set(ignoreFile); err == nil {
+		t.Fatalf("expected an error for an unclosed [")
+	}
+}
+
+func TestProcessPaths_MaxDepth(t *testing.T) {
+	defer func() { maxDepth = 0 }()
+
+	directory := t.TempDir()
+	for _, name := range []string{"a.txt", filepath.Join("b", "c.txt"), filepath.Join("b", "d", "e.txt")} {
+		filePath := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(name), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	expected := map[int][]string{
+		0: {"a.txt", "b/c.txt", "b/d/e.txt"},
+		1: {"a.txt"},
+		2: {"a.txt", "b/c.txt"},
+	}
+	for depth, expectedFiles := range expected {
+		maxDepth = depth
+		var files []string
+		var errs []error
+		processPaths([]string{directory}, &errs, func(filePath string) error {
+			relativePath, _ := filepath.Rel(directory, filePath)
+			appendLocked(&files, filepath.ToSlash(relativePath))
+			return nil
+		})
+
+		slices.Sort(files)
+		if !slices.Equal(files, expectedFiles) {
+			t.Fatalf("expected %v with --max-depth %d, got %v", expectedFiles, depth, files)
+		}
+	}
+}
+
+func TestIsFilteredOut_SkipHidden(t *testing.T) {
+	defer func() { skipHidden = false }()
+
+	root := t.TempDir()
+	cases := map[string]bool{
+		".git":                 true,
+		".Trash-1000":          true,
+		"photos/.DS_Store":     true,
+		".hidden.raw.sha512":   true,
+		"photos/wedding.raw":   false,
+		"photos/version.2.txt": false,
+	}
+	for path, hidden := range cases {
+		filePath := filepath.Join(root, filepath.FromSlash(path))
+		if isFilteredOut(root, filePath, false) {
+			t.Fatalf("expected %s not filtered out without --skip-hidden", path)
+		}
+		skipHidden = true
+		if filtered := isFilteredOut(root, filePath, false); filtered != hidden {
+			t.Fatalf("expected %s filtered out %v, got %v", path, hidden, filtered)
+		}
+		skipHidden = false
+	}
+}
+
+func TestIsFilteredOut_Snapshots(t *testing.T) {
+	defer func() { includeSnapshots = false }()
+
+	root := filepath.Join("tank", "photos")
+	cases := []struct {
+		path     string
+		isDir    bool
+		filtered bool
+	}{
+		{".zfs", true, true},
+		{"2024/.snapshot", true, true},
+		{".snapshots", true, true},
+		{"@snapshot", true, true},
+		{"2024/snapshot", true, false},
+		{"2024/.snapshot", false, false},
+	}
+	for _, c := range cases {
+		path := filepath.Join(root, filepath.FromSlash(c.path))
+		if filtered := isFilteredOut(root, path, c.isDir); filtered != c.filtered {
+			t.Fatalf("expected %s filtered out %v, got %v", c.path, c.filtered, filtered)
+		}
+		includeSnapshots = true
+		if isFilteredOut(root, path, c.isDir) {
+			t.Fatalf("expected %s not filtered out with --include-snapshots", c.path)
+		}
+		includeSnapshots = false
+	}
+}
+
+func TestIsFilteredOut_Size(t *testing.T) {
+	defer func() { minFileSize, maxFileSize = 0, 0 }()
+
+	root := t.TempDir()
+	sizes := map[string]int{"small.txt": 10, "medium.txt": 1000, "large.txt": 100000}
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(root, name), make([]byte, size), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if err := minFileSize.Set("1KB"); err != nil {
+		t.Fatalf("set min size: %v", err)
+	}
+	if err := maxFileSize.Set("10K"); err != nil {
+		t.Fatalf("set max size: %v", err)
+	}
+
+	cases := map[string]bool{
+		"small.txt":         true,
+		"medium.txt":        false,
+		"large.txt":         true,
+		"medium.txt.sha512": false,
+		"large.txt.sha512":  true,
+		"deleted.txt":       false,
+	}
+	for name, filtered := range cases {
+		if isFilteredOut(root, filepath.Join(root, name), false) != filtered {
+			t.Fatalf("expected %s filtered out %v", name, filtered)
+		}
+	}
+}
+
+func TestParsePointInTime(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"30d":                  now.AddDate(0, 0, -30),
+		"2w":                   now.AddDate(0, 0, -14),
+		"12h":                  now.Add(-12 * time.Hour),
+		"2024-01-01":           time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		"2024-01-01 12:30:00":  time.Date(2024, 1, 1, 12, 30, 0, 0, time.Local),
+		"2024-01-01T12:30:00Z": time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+	}
+	for value, expected := range cases {
+		parsed, err := parsePointInTime(value, now)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		if !parsed.Equal(expected) {
+			t.Fatalf("expected %s for %s, got %s", expected, value, parsed)
+		}
+	}
+
+	for _, value := range []string{"", "d", "30x", "yesterday", "2024-13-01"} {
+		if _, err := parsePointInTime(value, now); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestIsFilteredOut_ModTime(t *testing.T) {
+	defer func() { newerThan, olderThan = pointInTime{}, pointInTime{} }()
+
+	root := t.TempDir()
+	modTimes := map[string]time.Time{
+		"old.txt":    time.Now().AddDate(-2, 0, 0),
+		"recent.txt": time.Now().Add(-time.Hour),
+	}
+	for name, modTime := range modTimes {
+		filePath := filepath.Join(root, name)
+		if err := os.WriteFile(filePath, []byte(name), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+			t.Fatalf("chtimes %s: %v", name, err)
+		}
+	}
+
+	if err := newerThan.Set("7d"); err != nil {
+		t.Fatalf("set newer than: %v", err)
+	}
+	if !isFilteredOut(root, filepath.Join(root, "old.txt"), false) || isFilteredOut(root, filepath.Join(root, "recent.txt"), false) {
+		t.Fatalf("expected only the old file filtered out with --newer-than")
+	}
+
+	newerThan = pointInTime{}
+	if err := olderThan.Set("1d"); err != nil {
+		t.Fatalf("set older than: %v", err)
+	}
+	if isFilteredOut(root, filepath.Join(root, "old.txt"), false) || !isFilteredOut(root, filepath.Join(root, "recent.txt"), false) {
+		t.Fatalf("expected only the recent file filtered out with --older-than")
+	}
+}
+
+func TestIsFilteredOut_Regexp(t *testing.T) {
+	defer func() { includeRegexps, excludeRegexps = nil, nil }()
+
+	if err := includeRegexps.Set(`^(19|20)[0-9]{2}/.*\.(raw|dng)$`); err != nil {
+		t.Fatalf("set include regex: %v", err)
+	}
+	if err := excludeRegexps.Set(`(^|/)drafts?(/|$)`); err != nil {
+		t.Fatalf("set exclude regex: %v", err)
+	}
+	if err := excludeRegexps.Set(`(unclosed`); err == nil {
+		t.Fatalf("expected an error for an invalid regular expression")
+	}
+
+	root := filepath.Join("volume1", "photos")
+	cases := []struct {
+		path     string
+		isDir    bool
+		filtered bool
+	}{
+		{"2024/wedding.raw", false, false},
+		{"2024/wedding.raw.sha512", false, false},
+		{"1999/trip/beach.dng", false, false},
+		{"2024/wedding.jpg", false, true},
+		{"misc/wedding.raw", false, true},
+		{"2024/drafts", true, true},
+		{"2024/drafts/cover.raw", false, true},
+		{"2024", true, false},
+	}
+	for _, c := range cases {
+		if filtered := isFilteredOut(root, filepath.Join(root, filepath.FromSlash(c.path)), c.isDir); filtered != c.filtered {
+			t.Fatalf("expected %s filtered out %v, got %v", c.path, c.filtered, filtered)
+		}
+	}
+}
+
+func TestIsFilteredOut_ExcludeCommon(t *testing.T) {
+	defer func() { excludeCommon = false }()
+
+	root := filepath.Join("volume1", "projects")
+	cases := []struct {
+		path     string
+		isDir    bool
+		filtered bool
+	}{
+		{".git", true, true},
+		{"app/node_modules", true, true},
+		{"photos/@eaDir", true, true},
+		{"photos/.DS_Store", false, true},
+		{"photos/._wedding.raw", false, true},
+		{"photos/Thumbs.db", false, true},
+		{"photos/wedding.raw", false, false},
+		{"app/git", true, false},
+	}
+	for _, c := range cases {
+		path := filepath.Join(root, filepath.FromSlash(c.path))
+		if isFilteredOut(root, path, c.isDir) {
+			t.Fatalf("expected %s not filtered out without --exclude-common", c.path)
+		}
+		excludeCommon = true
+		if filtered := isFilteredOut(root, path, c.isDir); filtered != c.filtered {
+			t.Fatalf("expected %s filtered out %v, got %v", c.path, c.filtered, filtered)
+		}
+		excludeCommon = false
+	}
+}
+
+func TestIsFilteredOut_OnlyExtensions(t *testing.T) {
+	defer func() { onlyExtensions = nil }()
+
+	onlyExtensions = []string{"mkv", ".FLAC"}
+	root := filepath.Join("volume1", "media")
+	cases := map[string]bool{
+		"movie.mkv":        false,
+		"MOVIE.MKV":        false,
+		"album/track.flac": false,
+		"movie.mkv.sha512": false,
+		"project.prproj":   true,
+		"movie.mkv.part":   true,
+		"notes":            true,
+		"album/cover.jpg":  true,
+	}
+	for path, filtered := range cases {
+		if isFilteredOut(root, filepath.Join(root, filepath.FromSlash(path)), false) != filtered {
+			t.Fatalf("expected %s filtered out %v", path, filtered)
+		}
+	}
+	if isFilteredOut(root, filepath.Join(root, "album"), true) {
+		t.Fatalf("expected the directories not filtered out by --only-ext")
+	}
+}
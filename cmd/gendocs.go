@@ -0,0 +1,78 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var manDirectory string
+var markdownDirectory string
+
+// genDocsCmd represents the gen-docs command
+var genDocsCmd = &cobra.Command{
+	Use:   "gen-docs",
+	Short: "Generate the man pages and the markdown reference.",
+	Long: `Generate a man page and a markdown reference page for every command, with all their flags,
+so packages can ship proper manuals.
+
+Example:
+  checksum-utils gen-docs
+  checksum-utils gen-docs --man-dir /usr/share/man/man1 --markdown-dir ./docs
+`,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Without the generation date, the pages are the same on every build
+		rootCmd.DisableAutoGenTag = true
+
+		if manDirectory != "" {
+			if err := os.MkdirAll(manDirectory, 0o755); err != nil {
+				return err
+			}
+			header := &doc.GenManHeader{
+				Title:   "CHECKSUM-UTILS",
+				Section: "1",
+				Source:  "Checksum-Utils " + version,
+				Manual:  "Checksum-Utils Manual",
+			}
+			if err := doc.GenManTree(rootCmd, header, manDirectory); err != nil {
+				return err
+			}
+		}
+
+		if markdownDirectory != "" {
+			if err := os.MkdirAll(markdownDirectory, 0o755); err != nil {
+				return err
+			}
+			if err := doc.GenMarkdownTree(rootCmd, markdownDirectory); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(genDocsCmd)
+
+	genDocsCmd.Flags().StringVar(&manDirectory, "man-dir", "./out/man", "directory where the man pages are written, empty to skip them")
+	genDocsCmd.Flags().StringVar(&markdownDirectory, "markdown-dir", "./out/docs", "directory where the markdown reference is written, empty to skip it")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenDocs(t *testing.T) {
+	tempDir := t.TempDir()
+	manDirectory = filepath.Join(tempDir, "man")
+	markdownDirectory = filepath.Join(tempDir, "docs")
+	defer func() {
+		manDirectory = "./out/man"
+		markdownDirectory = "./out/docs"
+	}()
+
+	if err := genDocsCmd.RunE(genDocsCmd, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(manDirectory, "checksum-utils.1"),
+		filepath.Join(manDirectory, "checksum-utils-check.1"),
+		filepath.Join(manDirectory, "checksum-utils-catalog-update.1"),
+		filepath.Join(markdownDirectory, "checksum-utils_create.md"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to be generated: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(manDirectory, "checksum-utils-gen-docs.1")); err == nil {
+		t.Fatalf("the hidden gen-docs command should not be documented")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// fileIdentity identifies the content of a file with several hard links; the size and modification time
+// make sure that a content changed since it was hashed is hashed again
+type fileIdentity struct {
+	Device  uint64
+	Inode   uint64
+	Size    int64
+	ModTime int64
+}
+
+// hardlinkChecksums are the checksums of the files with several hard links, so each content is hashed once
+var hardlinkChecksums = struct {
+	sync.Mutex
+	byIdentity map[fileIdentity]string
+}{byIdentity: map[fileIdentity]string{}}
+
+// hashLinkedFile returns the checksum of the content of the file read from the reader, or the checksum of another
+// hard link of the same content when it was already hashed, which saves reading the same content several times
+// in backup trees like Time Machine or rsnapshot ones
+func hashLinkedFile(fileAbsolutePath string, reader io.Reader) (string, error) {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return hashReader(reader)
+	}
+	identity, linked := hardlinkIdentity(fileInfo)
+	if !linked {
+		return hashReader(reader)
+	}
+
+	hardlinkChecksums.Lock()
+	checksum, hashed := hardlinkChecksums.byIdentity[identity]
+	hardlinkChecksums.Unlock()
+	if hashed {
+		return checksum, nil
+	}
+
+	checksum, err = hashReader(reader)
+	if err != nil {
+		return "", err
+	}
+
+	hardlinkChecksums.Lock()
+	hardlinkChecksums.byIdentity[identity] = checksum
+	hardlinkChecksums.Unlock()
+	return checksum, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashLinkedFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links are not detected on windows")
+	}
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	linkPath := filepath.Join(tempDir, "link.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Link(filePath, linkPath); err != nil {
+		t.Fatalf("create hard link: %v", err)
+	}
+
+	checksum, err := hashLinkedFile(filePath, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The content of the link must not be read again
+	linkChecksum, err := hashLinkedFile(linkPath, iotest.ErrReader(errors.New("read again")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if linkChecksum != checksum {
+		t.Fatalf("expected the checksum of the first link %s, got %s", checksum, linkChecksum)
+	}
+}
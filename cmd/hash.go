@@ -0,0 +1,298 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdinPath is the path argument that makes hash read the data from stdin
+const stdinPath = "-"
+
+var hashAlgorithmName string
+
+// The encodings of the checksums, chosen with --encoding
+const (
+	hexEncoding      = "hex"
+	upperHexEncoding = "HEX"
+	base64Encoding   = "base64"
+)
+
+// digestEncoding is the encoding of the checksums printed by hash and written to the checksum files
+var digestEncoding = hexEncoding
+
+const digestEncodingUsage = "encoding of the checksums: hex, HEX for uppercase hexadecimal, or base64"
+
+// hashCmd represents the hash command
+var hashCmd = &cobra.Command{
+	Use:   "hash",
+	Short: "Print files checksum.",
+	Long: `Compute the checksum of the files and print it, one line per file.
+Use "-" as path to read the data from stdin, so streams that never hit the disk can be hashed too.
+
+Example:
+  checksum-utils hash ./budget.pdf
+  checksum-utils hash ./work/*.raw
+  checksum-utils hash --algorithm sha256 ./debian.iso
+  checksum-utils hash --encoding base64 ./budget.pdf
+  checksum-utils hash --known-good NSRLFile.txt --known-bad malware.sha256 ./ingest/*
+  checksum-utils hash --known-good NSRLFile.txt --vt-lookup ./ingest/*
+  tar -c ./work | checksum-utils hash -
+`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		algorithm, err := algorithmByName(hashAlgorithmName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: ", err)
+			os.Exit(1)
+		}
+
+		if len(knownGoodHashSets) > 0 || len(knownBadHashSets) > 0 || virusTotalLookup {
+			runHashSetMatching(args, algorithm)
+			return
+		}
+
+		failed := false
+
+		for _, path := range args {
+			checksum, err := hashPath(path, algorithm)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error: ", err)
+				failed = true
+				continue
+			}
+
+			fmt.Printf("%s  %s\n", encodeDigest(checksum), path)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hashCmd)
+
+	hashCmd.Flags().StringVar(&hashAlgorithmName, "algorithm", sha512Algorithm.Name, "algorithm of the checksums: md5, sha1, sha256, sha384 or sha512")
+	hashCmd.Flags().StringVar(&digestEncoding, "encoding", hexEncoding, digestEncodingUsage)
+	hashCmd.Flags().StringSliceVar(&knownGoodHashSets, "known-good", nil, "tag the files whose checksum is in these hash sets of known good files, like NSRLFile.txt of the NSRL")
+	hashCmd.Flags().StringSliceVar(&knownBadHashSets, "known-bad", nil, "tag the files whose checksum is in these hash sets of known bad files, exiting with code 2 when any is found")
+	hashCmd.Flags().BoolVar(&virusTotalLookup, "vt-lookup", false, "look up the SHA256 checksum of the files that are not --known-good in VirusTotal, with the API key of $VT_API_KEY, without uploading them")
+	hashCmd.Flags().IntVar(&virusTotalRequestsPerMinute, "vt-requests-per-minute", virusTotalRequestsPerMinute, "maximum number of lookups per minute allowed by the API key of VirusTotal")
+	hashCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	hashCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	hashCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	hashCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	hashCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	hashCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+	hashCmd.RegisterFlagCompletionFunc("algorithm", completeAlgorithms)
+}
+
+// hashPath returns the hexadecimal checksum of the file, or of stdin when the path is "-", using the algorithm
+func hashPath(path string, algorithm hashAlgorithm) (string, error) {
+	if path == stdinPath {
+		return hashReaderWith(os.Stdin, algorithm)
+	}
+
+	file, err := openDataFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return hashReaderWith(file, algorithm)
+}
+
+// encodeDigest returns the hexadecimal checksum in the --encoding encoding
+func encodeDigest(hexDigest string) string {
+	switch digestEncoding {
+	case upperHexEncoding:
+		return strings.ToUpper(hexDigest)
+	case base64Encoding:
+		digest, err := hex.DecodeString(hexDigest)
+		if err != nil {
+			return hexDigest
+		}
+		return base64.StdEncoding.EncodeToString(digest)
+	default:
+		return hexDigest
+	}
+}
+
+// decodeDigest returns the checksum in hexadecimal, converting it when it is encoded in base64,
+// so the checksums are compared whatever their encoding
+func decodeDigest(digest string) string {
+	if _, err := hex.DecodeString(digest); err == nil {
+		return digest
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(digest); err == nil {
+		return hex.EncodeToString(decoded)
+	}
+	return digest
+}
+
+// hashAlgorithm is a checksum algorithm known by checksum-utils
+type hashAlgorithm struct {
+	Name string
+	New  func() hash.Hash
+}
+
+// sha512Algorithm is the algorithm used for the checksum files
+var sha512Algorithm = hashAlgorithm{Name: "sha512", New: sha512.New}
+
+// hashAlgorithms are the algorithms that can be recognized from the length of a hexadecimal digest
+var hashAlgorithms = []hashAlgorithm{
+	sha512Algorithm,
+	{Name: "sha384", New: sha512.New384},
+	{Name: "sha256", New: sha256.New},
+	{Name: "sha1", New: sha1.New},
+	{Name: "md5", New: md5.New},
+}
+
+// algorithmByName returns the registered algorithm with the name
+func algorithmByName(name string) (hashAlgorithm, error) {
+	for _, algorithm := range hashAlgorithms {
+		if algorithm.Name == name {
+			return algorithm, nil
+		}
+	}
+
+	return hashAlgorithm{}, fmt.Errorf("%q is not a supported algorithm", name)
+}
+
+// algorithmForDigest returns the algorithm that produces hexadecimal digests like the given one
+func algorithmForDigest(hexDigest string) (hashAlgorithm, error) {
+	if _, err := hex.DecodeString(hexDigest); err != nil {
+		return hashAlgorithm{}, fmt.Errorf("%q is not a hexadecimal checksum", hexDigest)
+	}
+
+	for _, algorithm := range hashAlgorithms {
+		if len(hexDigest) == algorithm.New().Size()*2 {
+			return algorithm, nil
+		}
+	}
+
+	return hashAlgorithm{}, fmt.Errorf("%q does not have the length of a supported checksum", hexDigest)
+}
+
+// hashReader consumes the reader and returns its hexadecimal SHA512 checksum
+func hashReader(reader io.Reader) (string, error) {
+	return hashReaderWith(reader, sha512Algorithm)
+}
+
+// hashReaderWith consumes the reader and returns its hexadecimal checksum using the algorithm
+func hashReaderWith(reader io.Reader, algorithm hashAlgorithm) (string, error) {
+	// Create a new hash object
+	hash := algorithm.New()
+
+	// Copy the content to the hash object
+	if err := copyDoubleBuffered(hash, throttle(countHashedBytes(reader))); err != nil {
+		return "", err
+	}
+
+	// Convert the checksum to a hexadecimal string
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// bufferSize is the size of the reads of the hashed data, larger than the 32 KiB of io.Copy
+// because larger reads are much faster on network shares
+var bufferSize byteSize = 1 << 20
+
+// bufferSizeUsage is the usage of the --buffer-size flag of the commands that hash files
+const bufferSizeUsage = "size of the reads of the hashed files, like 4MiB"
+
+// readChunk is a buffer filled by the reading goroutine of copyDoubleBuffered
+type readChunk struct {
+	data []byte
+	err  error
+}
+
+// copyDoubleBuffered copies the reader to the writer, reading the next buffer while the previous one is written,
+// so the reads of large files overlap with their hashing instead of waiting for each other.
+// Data that fits in a single buffer is copied without starting the reading goroutine.
+func copyDoubleBuffered(writer io.Writer, reader io.Reader) error {
+	first := make([]byte, bufferSize)
+	n, err := io.ReadFull(reader, first)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		_, err := writer.Write(first[:n])
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	free := make(chan []byte, 2)
+	free <- make([]byte, bufferSize)
+	filled := make(chan readChunk)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(filled)
+		for {
+			var buffer []byte
+			select {
+			case buffer = <-free:
+			case <-done:
+				return
+			}
+
+			n, err := io.ReadFull(reader, buffer)
+			select {
+			case filled <- readChunk{data: buffer[:n], err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	if _, err := writer.Write(first); err != nil {
+		return err
+	}
+	free <- first
+
+	for chunk := range filled {
+		if _, err := writer.Write(chunk.data); err != nil {
+			return err
+		}
+		if chunk.err == io.EOF || chunk.err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if chunk.err != nil {
+			return chunk.err
+		}
+		free <- chunk.data[:cap(chunk.data)]
+	}
+
+	return nil
+}
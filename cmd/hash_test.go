@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashReader(t *testing.T) {
+	data := "streamed data"
+
+	checksum, err := hashReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hash := sha512.Sum512([]byte(data))
+	expected := hex.EncodeToString(hash[:])
+	if checksum != expected {
+		t.Fatalf("checksum mismatch: expected %q, got %q", expected, checksum)
+	}
+}
+
+func TestHashPath_Stdin(t *testing.T) {
+	data := []byte("piped data")
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create pipe: %v", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("write pipe: %v", err)
+	}
+	_ = writer.Close()
+
+	originalStdin := os.Stdin
+	os.Stdin = reader
+	defer func() {
+		os.Stdin = originalStdin
+		_ = reader.Close()
+	}()
+
+	checksum, err := hashPath(stdinPath, sha512Algorithm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hash := sha512.Sum512(data)
+	expected := hex.EncodeToString(hash[:])
+	if checksum != expected {
+		t.Fatalf("checksum mismatch: expected %q, got %q", expected, checksum)
+	}
+}
+
+func TestHashPath_MissingFile(t *testing.T) {
+	if _, err := hashPath(filepath.Join(t.TempDir(), "missing.txt"), sha512Algorithm); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestAlgorithmForDigest(t *testing.T) {
+	cases := map[string]string{
+		strings.Repeat("a", 128): "sha512",
+		strings.Repeat("a", 96):  "sha384",
+		strings.Repeat("a", 64):  "sha256",
+		strings.Repeat("a", 40):  "sha1",
+		strings.Repeat("a", 32):  "md5",
+	}
+
+	for digest, name := range cases {
+		algorithm, err := algorithmForDigest(digest)
+		if err != nil {
+			t.Fatalf("unexpected error for %d characters: %v", len(digest), err)
+		}
+		if algorithm.Name != name {
+			t.Fatalf("expected algorithm %s for %d characters, got %s", name, len(digest), algorithm.Name)
+		}
+	}
+
+	for _, digest := range []string{"", "xyz", strings.Repeat("a", 30)} {
+		if _, err := algorithmForDigest(digest); err == nil {
+			t.Fatalf("expected error for %q, got nil", digest)
+		}
+	}
+}
+
+func TestCopyDoubleBuffered(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), int(bufferSize)/2)
+
+	for _, size := range []int{0, 10, int(bufferSize), len(data)} {
+		var copied bytes.Buffer
+		if err := copyDoubleBuffered(&copied, bytes.NewReader(data[:size])); err != nil {
+			t.Fatalf("unexpected error copying %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(copied.Bytes(), data[:size]) {
+			t.Fatalf("expected %d bytes to be copied, got %d", size, copied.Len())
+		}
+	}
+
+	readErr := errors.New("read error")
+	reader := io.MultiReader(bytes.NewReader(data), iotest.ErrReader(readErr))
+	if err := copyDoubleBuffered(io.Discard, reader); !errors.Is(err, readErr) {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1048576": 1 << 20,
+		"4MiB":    4 << 20,
+		"4m":      4 << 20,
+		"1.5K":    1536,
+		"80MB":    80_000_000,
+		"2 GiB":   2 << 30,
+	}
+	for value, expected := range tests {
+		size, err := parseSize(value)
+		if err != nil || size != expected {
+			t.Fatalf("expected %d for %q, got %d (%v)", expected, value, size, err)
+		}
+	}
+
+	for _, value := range []string{"", "0", "-1M", "4XB", "MiB"} {
+		if _, err := parseSize(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestHashPath_ReadaheadIOEngine(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data.bin")
+	data := bytes.Repeat([]byte("0123456789"), 5<<20)
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	selectedIOEngine = readaheadIOEngine
+	defer func() { selectedIOEngine = standardIOEngine }()
+
+	checksum, err := hashPath(filePath, sha512Algorithm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hash := sha512.Sum512(data)
+	if checksum != hex.EncodeToString(hash[:]) {
+		t.Fatalf("expected the checksum of the whole file, got %s", checksum)
+	}
+}
+
+func TestEncodeDigest(t *testing.T) {
+	defer func() { digestEncoding = hexEncoding }()
+
+	hash := sha512.Sum512([]byte("data"))
+	hexDigest := hex.EncodeToString(hash[:])
+
+	for _, encoding := range []string{hexEncoding, upperHexEncoding, base64Encoding} {
+		digestEncoding = encoding
+		encoded := encodeDigest(hexDigest)
+		if !strings.EqualFold(decodeDigest(encoded), hexDigest) {
+			t.Fatalf("%s: expected %s to decode to %s, got %s", encoding, encoded, hexDigest, decodeDigest(encoded))
+		}
+	}
+
+	digestEncoding = upperHexEncoding
+	if encoded := encodeDigest(hexDigest); encoded != strings.ToUpper(hexDigest) {
+		t.Fatalf("expected the uppercase checksum, got %s", encoded)
+	}
+}
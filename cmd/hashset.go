@@ -0,0 +1,220 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// knownGoodHashSets and knownBadHashSets are the files of the hash sets given with --known-good and --known-bad
+var knownGoodHashSets []string
+var knownBadHashSets []string
+
+// knownBadExitCode is the exit code of hash when a file is in a known bad hash set
+const knownBadExitCode = 2
+
+// The tags printed after the checksums of the files found in the hash sets
+const (
+	knownGoodTag = "[known-good]"
+	knownBadTag  = "[known-bad]"
+)
+
+// hashSet is a set of known checksums, by algorithm, like the NSRL reference data set of known software
+// or a list of checksums of malware
+type hashSet map[string]map[string]bool
+
+// loadHashSets reads the checksums of the hash set files. Every hexadecimal checksum of a known length found in a
+// line is added, so plain lists of checksums, manifests like SHA256SUMS and CSV files like NSRLFile.txt can be used.
+// Blank lines and lines starting with "#" are ignored.
+func loadHashSets(paths []string) (hashSet, error) {
+	set := hashSet{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			for _, field := range strings.FieldsFunc(line, isHashSetSeparator) {
+				algorithm, err := algorithmForDigest(field)
+				if err != nil {
+					continue
+				}
+				if set[algorithm.Name] == nil {
+					set[algorithm.Name] = map[string]bool{}
+				}
+				set[algorithm.Name][strings.ToLower(field)] = true
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return set, nil
+}
+
+// isHashSetSeparator reports whether the character separates the fields of the lines of the hash sets
+func isHashSetSeparator(r rune) bool {
+	switch r {
+	case ' ', '\t', ',', ';', '"', '*', '(', ')', '=':
+		return true
+	}
+	return false
+}
+
+// algorithms returns the algorithms of the checksums of the hash set
+func (s hashSet) algorithms() []hashAlgorithm {
+	var algorithms []hashAlgorithm
+	for _, algorithm := range hashAlgorithms {
+		if len(s[algorithm.Name]) > 0 {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	return algorithms
+}
+
+// contains reports whether any of the hexadecimal checksums of a file, by algorithm, is in the hash set
+func (s hashSet) contains(checksums map[string]string) bool {
+	for name, checksum := range checksums {
+		if s[name][strings.ToLower(checksum)] {
+			return true
+		}
+	}
+	return false
+}
+
+// hashSetAlgorithms returns the algorithm, followed by the other algorithms of the hash sets
+func hashSetAlgorithms(algorithm hashAlgorithm, sets ...hashSet) []hashAlgorithm {
+	algorithms := []hashAlgorithm{algorithm}
+	for _, set := range sets {
+		for _, setAlgorithm := range set.algorithms() {
+			algorithms = appendAlgorithm(algorithms, setAlgorithm)
+		}
+	}
+	return algorithms
+}
+
+// appendAlgorithm appends the algorithm to the algorithms when it is not one of them yet
+func appendAlgorithm(algorithms []hashAlgorithm, algorithm hashAlgorithm) []hashAlgorithm {
+	for _, existing := range algorithms {
+		if existing.Name == algorithm.Name {
+			return algorithms
+		}
+	}
+	return append(algorithms, algorithm)
+}
+
+// hashPathWithAll returns the hexadecimal checksums of the file, or of stdin when the path is "-", by algorithm,
+// reading it once
+func hashPathWithAll(path string, algorithms []hashAlgorithm) (map[string]string, error) {
+	reader := io.Reader(os.Stdin)
+	if path != stdinPath {
+		file, err := openDataFile(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	checksums, err := hashReaderWithAll(reader, algorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	checksumsByAlgorithm := make(map[string]string, len(algorithms))
+	for i, algorithm := range algorithms {
+		checksumsByAlgorithm[algorithm.Name] = checksums[i]
+	}
+	return checksumsByAlgorithm, nil
+}
+
+// runHashSetMatching prints the checksum of each file tagged with the hash sets that contain it and, with
+// --vt-lookup, with the report of VirusTotal for the files that are not known good. It exits with knownBadExitCode
+// when a file is in a known bad hash set or detected as malicious by VirusTotal.
+func runHashSetMatching(paths []string, algorithm hashAlgorithm) {
+	knownGood, err := loadHashSets(knownGoodHashSets)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err)
+		os.Exit(1)
+	}
+	knownBad, err := loadHashSets(knownBadHashSets)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err)
+		os.Exit(1)
+	}
+	algorithms := hashSetAlgorithms(algorithm, knownGood, knownBad)
+
+	var virusTotal *virusTotalClient
+	if virusTotalLookup {
+		virusTotal, err = newVirusTotalClient()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: ", err)
+			os.Exit(1)
+		}
+		algorithms = appendAlgorithm(algorithms, virusTotalAlgorithm)
+	}
+
+	failed := false
+	foundKnownBad := false
+
+	for _, path := range paths {
+		checksums, err := hashPathWithAll(path, algorithms)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: ", err)
+			failed = true
+			continue
+		}
+
+		line := fmt.Sprintf("%s  %s", encodeDigest(checksums[algorithm.Name]), path)
+		if knownBad.contains(checksums) {
+			line += "  " + knownBadTag
+			foundKnownBad = true
+		} else if knownGood.contains(checksums) {
+			line += "  " + knownGoodTag
+		}
+		if virusTotal != nil && !knownGood.contains(checksums) {
+			report, err := virusTotal.lookup(checksums[virusTotalAlgorithm.Name])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error: ", err)
+				failed = true
+			} else {
+				line += "  " + report.String()
+				foundKnownBad = foundKnownBad || report.Malicious > 0
+			}
+		}
+		fmt.Println(line)
+	}
+
+	if foundKnownBad {
+		os.Exit(knownBadExitCode)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashSets(t *testing.T) {
+	tempDir := t.TempDir()
+	sha1Digest := sha1.Sum([]byte("setup.exe"))
+	md5Digest := md5.Sum([]byte("setup.exe"))
+	sha256Digest := sha256.Sum256([]byte("invoice.pdf.exe"))
+
+	nsrlPath := filepath.Join(tempDir, "NSRLFile.txt")
+	nsrl := `"SHA-1","MD5","CRC32","FileName","FileSize","ProductCode","OpSystemCode","SpecialCode"` + "\n" +
+		`"` + hex.EncodeToString(sha1Digest[:]) + `","` + hex.EncodeToString(md5Digest[:]) + `","0A1B2C3D","setup.exe",9,1,"WIN",""` + "\n"
+	if err := os.WriteFile(nsrlPath, []byte(nsrl), 0o600); err != nil {
+		t.Fatalf("write hash set: %v", err)
+	}
+	badPath := filepath.Join(tempDir, "malware.sha256")
+	if err := os.WriteFile(badPath, []byte("# known malware\n"+hex.EncodeToString(sha256Digest[:])+"  invoice.pdf.exe\n"), 0o600); err != nil {
+		t.Fatalf("write hash set: %v", err)
+	}
+
+	knownGood, err := loadHashSets([]string{nsrlPath})
+	if err != nil {
+		t.Fatalf("load hash set: %v", err)
+	}
+	knownBad, err := loadHashSets([]string{badPath})
+	if err != nil {
+		t.Fatalf("load hash set: %v", err)
+	}
+
+	algorithms := hashSetAlgorithms(sha512Algorithm, knownGood, knownBad)
+	names := ""
+	for _, algorithm := range algorithms {
+		names += algorithm.Name + " "
+	}
+	if names != "sha512 sha1 md5 sha256 " {
+		t.Fatalf("unexpected algorithms %q", names)
+	}
+
+	for _, test := range []struct {
+		content   string
+		knownGood bool
+		knownBad  bool
+	}{
+		{content: "setup.exe", knownGood: true},
+		{content: "invoice.pdf.exe", knownBad: true},
+		{content: "holidays.jpg"},
+	} {
+		filePath := filepath.Join(tempDir, "file")
+		if err := os.WriteFile(filePath, []byte(test.content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		checksums, err := hashPathWithAll(filePath, algorithms)
+		if err != nil {
+			t.Fatalf("hash file: %v", err)
+		}
+		if knownGood.contains(checksums) != test.knownGood || knownBad.contains(checksums) != test.knownBad {
+			t.Errorf("%s: expected known good %v and known bad %v", test.content, test.knownGood, test.knownBad)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// isHidden reports whether the file is a dotfile or has the hidden attribute
+func isHidden(path string) bool {
+	if strings.HasPrefix(filepath.Base(path), ".") {
+		return true
+	}
+
+	pathPointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attributes, err := syscall.GetFileAttributes(pathPointer)
+	return err == nil && attributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
+
+// setHiddenAttribute gives the file the hidden attribute
+func setHiddenAttribute(path string) error {
+	pathPointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attributes, err := syscall.GetFileAttributes(pathPointer)
+	if err != nil {
+		return err
+	}
+	return syscall.SetFileAttributes(pathPointer, attributes|syscall.FILE_ATTRIBUTE_HIDDEN)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the verification history of files.",
+	Long: `Show the results recorded in the catalog for the files: when they were last verified,
+when they failed for the first time, and every verification made by audit or by check --record-history.
+
+Example:
+  checksum-utils history ~/documents/budget.pdf
+  checksum-utils history --catalog /volume1/catalog.db /volume1/photos/wedding.raw
+`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePaths,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := openCatalog(catalogPath)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		for _, arg := range args {
+			fileAbsolutePath, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+
+			records, err := c.History(fileAbsolutePath)
+			if err != nil {
+				return err
+			}
+
+			printHistory(fileAbsolutePath, records)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVar(&catalogPath, "catalog", defaultCatalogPath(), "path of the catalog database")
+}
+
+// recordVerification adds the result to the history of the file in the catalog,
+// and updates its last verification time when the verification passed
+func recordVerification(c *catalog, fileAbsolutePath string, command string, status string, outcome verificationOutcome, resultError error) error {
+	now := time.Now()
+
+	record := verificationRecord{Path: fileAbsolutePath, VerifiedAt: now, Command: command, Status: status, Outcome: outcome}
+	if resultError != nil {
+		record.Error = resultError.Error()
+	}
+
+	if err := c.RecordVerification(record); err != nil {
+		return err
+	}
+
+	if outcome == verificationPassed {
+		return c.MarkVerified(fileAbsolutePath, now)
+	}
+
+	return nil
+}
+
+func printHistory(fileAbsolutePath string, records []verificationRecord) {
+	fmt.Println(fileAbsolutePath)
+
+	if len(records) == 0 {
+		fmt.Println("No verifications recorded")
+		fmt.Println()
+		return
+	}
+
+	var lastPassed, firstFailed *verificationRecord
+	for i := range records {
+		switch records[i].Outcome {
+		case verificationPassed:
+			lastPassed = &records[i]
+		case verificationFailed:
+			if firstFailed == nil {
+				firstFailed = &records[i]
+			}
+		}
+	}
+
+	if lastPassed != nil {
+		fmt.Printf("Last verified: %s (%s, %s)\n", lastPassed.VerifiedAt.Format(time.DateTime), lastPassed.Command, lastPassed.Status)
+	} else {
+		fmt.Println("Last verified: never")
+	}
+
+	if firstFailed != nil {
+		fmt.Printf("First failed:  %s (%s, %s)\n", firstFailed.VerifiedAt.Format(time.DateTime), firstFailed.Command, firstFailed.Status)
+	} else {
+		fmt.Println("First failed:  never")
+	}
+
+	fmt.Println("History:")
+	for _, record := range records {
+		fmt.Print("- ", record.VerifiedAt.Format(time.DateTime), " ", record.Command, " ", record.Status)
+		if record.Error != "" {
+			fmt.Print(" | Error: ", record.Error)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
+
+// sortStaleFirst returns the files of the paths sorted by the last time they were verified according to the catalog,
+// the files that were never verified first, so the files that have not been checked the longest are checked first
+func sortStaleFirst(c *catalog, paths []string, errorsList *[]error) ([]string, error) {
+	lastVerifications, err := c.LastVerifications()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	processPaths(paths, errorsList, func(filePath string) error {
+		if isChecksumFile(filePath) {
+			return nil
+		}
+		if fileAbsolutePath, err := filepath.Abs(filePath); err == nil {
+			appendLocked(&files, fileAbsolutePath)
+		}
+		return nil
+	})
+
+	slices.SortStableFunc(files, func(a string, b string) int {
+		return lastVerifications[a].Compare(lastVerifications[b])
+	})
+	return files, nil
+}
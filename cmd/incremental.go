@@ -0,0 +1,173 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var incrementalCheck bool
+
+// verificationCachePath is the file where the files that matched their checksum file are remembered
+var verificationCachePath = defaultVerificationCachePath()
+
+// activeVerificationCache is the cache updated by the running verification
+var activeVerificationCache *verificationCache
+
+// verificationCacheEntry is the state of a file, and of its checksum file, when it matched its checksum file
+type verificationCacheEntry struct {
+	Size            int64
+	ModTime         int64
+	ChecksumModTime int64
+}
+
+// verificationCache remembers the files that matched their checksum file, so check --incremental can skip
+// the ones that did not change since then
+type verificationCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]verificationCacheEntry
+}
+
+// defaultVerificationCachePath returns verified.tsv in the user cache directory
+func defaultVerificationCachePath() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "checksum-utils-verified.tsv")
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "verified.tsv")
+}
+
+// loadVerificationCache reads the cache, one "size modification-time checksum-modification-time path" line per file,
+// starting with an empty cache when it does not exist
+func loadVerificationCache(path string) (*verificationCache, error) {
+	c := &verificationCache{path: path, entries: map[string]verificationCacheEntry{}}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+
+		var entry verificationCacheEntry
+		var sizeErr, modTimeErr, checksumModTimeErr error
+		entry.Size, sizeErr = strconv.ParseInt(fields[0], 10, 64)
+		entry.ModTime, modTimeErr = strconv.ParseInt(fields[1], 10, 64)
+		entry.ChecksumModTime, checksumModTimeErr = strconv.ParseInt(fields[2], 10, 64)
+		if sizeErr != nil || modTimeErr != nil || checksumModTimeErr != nil {
+			continue
+		}
+		c.entries[fields[3]] = entry
+	}
+
+	return c, scanner.Err()
+}
+
+// currentCacheEntry returns the current state of the file and of its checksum file
+func currentCacheEntry(fileAbsolutePath string) (verificationCacheEntry, error) {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return verificationCacheEntry{}, err
+	}
+	checksumModTime, err := storedChecksumModTime(fileAbsolutePath)
+	if err != nil {
+		return verificationCacheEntry{}, err
+	}
+
+	return verificationCacheEntry{
+		Size:            fileInfo.Size(),
+		ModTime:         fileInfo.ModTime().UnixNano(),
+		ChecksumModTime: checksumModTime.UnixNano(),
+	}, nil
+}
+
+// isUnchanged reports whether the file matched its checksum file and neither of them changed since then
+func (c *verificationCache) isUnchanged(fileAbsolutePath string) bool {
+	c.mu.Lock()
+	cached, ok := c.entries[fileAbsolutePath]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	current, err := currentCacheEntry(fileAbsolutePath)
+	return err == nil && current == cached
+}
+
+// update remembers the file when it matched its checksum file, and forgets it otherwise
+func (c *verificationCache) update(result ChecksumFileVerificationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if result.Status != Match {
+		delete(c.entries, result.Path)
+		return
+	}
+
+	entry, err := currentCacheEntry(result.Path)
+	if err != nil {
+		delete(c.entries, result.Path)
+		return
+	}
+	c.entries[result.Path] = entry
+}
+
+// save writes the cache to a temporary file that replaces the previous one, so it is never left half written
+func (c *verificationCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	temporaryFile, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	writer := bufio.NewWriter(temporaryFile)
+	for path, entry := range c.entries {
+		fmt.Fprintf(writer, "%d\t%d\t%d\t%s\n", entry.Size, entry.ModTime, entry.ChecksumModTime, path)
+	}
+	if err := writer.Flush(); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFile.Name(), c.path)
+}
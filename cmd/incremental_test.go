@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerificationCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "verified.tsv")
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+
+	cache, err := loadVerificationCache(cachePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.update(checkChecksumFile(filePath))
+	if err := cache.save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache, err = loadVerificationCache(cachePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cache.isUnchanged(filePath) {
+		t.Fatalf("expected the file to be unchanged since it matched")
+	}
+
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatalf("change modification time: %v", err)
+	}
+	if cache.isUnchanged(filePath) {
+		t.Fatalf("expected the file to be changed after its modification time changed")
+	}
+}
+
+func TestCheckPathsWithCheckpoint_Incremental(t *testing.T) {
+	checkpointDirectory = t.TempDir()
+	activeVerificationCache = &verificationCache{entries: map[string]verificationCacheEntry{}}
+	incrementalCheck = true
+	defer func() {
+		checkpointDirectory = defaultCheckpointDirectory()
+		activeVerificationCache = nil
+		incrementalCheck = false
+	}()
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+
+	for _, expected := range []ChecksumFileVerificationStatus{Match, Unchanged} {
+		var results []ChecksumFileVerificationResult
+		checkPathsWithCheckpoint([]string{tempDir}, &results)
+		if len(results) != 1 || results[0].Status != expected {
+			t.Fatalf("expected status %s, got %+v", expected, results)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !unix
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "os"
+
+// fileInode returns 0, the file index of NTFS is not available from the information of os.Lstat,
+// so the files of each directory are sorted by name
+func fileInode(fileInfo os.FileInfo) uint64 {
+	return 0
+}
+
+// hardlinkIdentity reports no identity, hard links are not detected on this system
+func hardlinkIdentity(fileInfo os.FileInfo) (fileIdentity, bool) {
+	return fileIdentity{}, false
+}
+
+// fileDevice reports no device, the walks never leave the volume of the directory on this system
+func fileDevice(fileInfo os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns the inode number of the file, or 0 when it is unknown
+func fileInode(fileInfo os.FileInfo) uint64 {
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+	return 0
+}
+
+// hardlinkIdentity returns the identity of the file when it has several hard links
+func hardlinkIdentity(fileInfo os.FileInfo) (fileIdentity, bool) {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink <= 1 {
+		return fileIdentity{}, false
+	}
+	return fileIdentity{Device: uint64(stat.Dev), Inode: uint64(stat.Ino), Size: fileInfo.Size(), ModTime: fileInfo.ModTime().UnixNano()}, true
+}
+
+// fileDevice returns the device of the file system of the file
+func fileDevice(fileInfo os.FileInfo) (uint64, bool) {
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev), true
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ioEngine selects how the local files are read
+type ioEngine string
+
+const (
+	// standardIOEngine reads the files with plain reads, leaving the read-ahead to the system
+	standardIOEngine ioEngine = "standard"
+	// readaheadIOEngine tells the system that the files are read sequentially and asks it to read ahead
+	// of the hashing, so the disk is busy while the previous blocks are hashed. Only available on Linux.
+	readaheadIOEngine ioEngine = "readahead"
+)
+
+// ioEngines are the engines available on this system
+var ioEngines = append([]ioEngine{standardIOEngine}, platformIOEngines...)
+
+// selectedIOEngine is the engine used to read the local files
+var selectedIOEngine = standardIOEngine
+
+// ioEngineUsage is the usage of the --io-engine flag of the commands that hash files
+var ioEngineUsage = fmt.Sprintf("how the files are read: %s", joinIOEngines(ioEngines))
+
+func (e *ioEngine) String() string {
+	return string(*e)
+}
+
+func (e *ioEngine) Set(value string) error {
+	for _, engine := range ioEngines {
+		if string(engine) == value {
+			*e = engine
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not an I/O engine of this system, use %s", value, joinIOEngines(ioEngines))
+}
+
+func (e *ioEngine) Type() string {
+	return "engine"
+}
+
+func joinIOEngines(engines []ioEngine) string {
+	names := make([]string, 0, len(engines))
+	for _, engine := range engines {
+		names = append(names, string(engine))
+	}
+	return strings.Join(names, " or ")
+}
+
+// openLocalDataFile opens the local file with the selected I/O engine
+func openLocalDataFile(file *os.File) io.ReadCloser {
+	if selectedIOEngine == readaheadIOEngine {
+		return newReadaheadFile(file)
+	}
+	return file
+}
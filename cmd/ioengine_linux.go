@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// readaheadWindow is how far ahead of the hashing the system is asked to read
+const readaheadWindow = 32 << 20
+
+// platformIOEngines are the engines that are only available on Linux
+var platformIOEngines = []ioEngine{readaheadIOEngine}
+
+// readaheadFile asks the system to read the next window of the file while the current one is hashed
+type readaheadFile struct {
+	*os.File
+	offset  int64
+	advised int64
+}
+
+func newReadaheadFile(file *os.File) *readaheadFile {
+	// The advices only change how the file is cached, so their errors are ignored
+	unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+	return &readaheadFile{File: file}
+}
+
+func (f *readaheadFile) Read(p []byte) (int, error) {
+	if f.offset+readaheadWindow/2 >= f.advised {
+		unix.Fadvise(int(f.Fd()), f.advised, readaheadWindow, unix.FADV_WILLNEED)
+		f.advised += readaheadWindow
+	}
+
+	n, err := f.File.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The scopes of --lock
+const (
+	noLockScope     = "none"
+	pathsLockScope  = "paths"
+	globalLockScope = "global"
+)
+
+// lockScope is what a running check or create locks, so overlapping scheduled runs do not hash the same files
+// at the same time: nothing, each of its paths, or every run of the user
+var lockScope = noLockScope
+
+const lockScopeUsage = "lock the paths (paths) or every run (global) while running, so another run of check or create on them waits for --lock-wait or exits with code 4"
+
+// lockWait is how long a run waits for the locks held by another run before giving up
+var lockWait time.Duration
+
+const lockWaitUsage = "how long to wait for the locks of --lock held by another run, like 2h, before exiting with code 4 (default: exit at once)"
+
+// lockedExitCode is the exit code of a run that could not take its locks
+const lockedExitCode = 4
+
+// lockDirectory is the directory of the lock files
+var lockDirectory = defaultLockDirectory()
+
+// lockPollInterval is the interval between the attempts to take a lock held by another run
+var lockPollInterval = time.Second
+
+// heldLocks are the lock files of the run, kept open until it exits, when the system releases their locks
+var heldLocks []*os.File
+
+// lockHeldError is returned when a lock is held by another run
+type lockHeldError struct {
+	target string
+	pid    string
+}
+
+func (e *lockHeldError) Error() string {
+	holder := "another run"
+	if e.pid != "" {
+		holder = "another run (pid " + e.pid + ")"
+	}
+	return fmt.Sprintf("%s of checksum-utils is already processing %s, wait for it to finish or use --lock-wait", holder, e.target)
+}
+
+// defaultLockDirectory returns the locks directory in the user cache directory
+func defaultLockDirectory() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "checksum-utils-locks")
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "locks")
+}
+
+// validateLockScope checks the value of --lock
+func validateLockScope() error {
+	if lockScope != noLockScope && lockScope != pathsLockScope && lockScope != globalLockScope {
+		return fmt.Errorf("--lock must be %s, %s or %s, not %q", noLockScope, pathsLockScope, globalLockScope, lockScope)
+	}
+	return nil
+}
+
+// lockTargets returns what the run over the paths locks with --lock: the absolute paths, sorted so two runs
+// take their locks in the same order, or the whole user for a global lock
+func lockTargets(paths []string) []string {
+	switch lockScope {
+	case globalLockScope:
+		return []string{"every path"}
+	case pathsLockScope:
+		var targets []string
+		for _, path := range paths {
+			if absolutePath, err := filepath.Abs(path); err == nil && !isRemoteURL(path) {
+				path = absolutePath
+			}
+			if !slices.Contains(targets, path) {
+				targets = append(targets, path)
+			}
+		}
+		slices.Sort(targets)
+		return targets
+	}
+	return nil
+}
+
+// lockFilePath returns the lock file of the target, named after it
+func lockFilePath(target string) string {
+	if lockScope == globalLockScope {
+		return filepath.Join(lockDirectory, "global.lock")
+	}
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(lockDirectory, hex.EncodeToString(sum[:16])+".lock")
+}
+
+// acquireRunLocks takes the locks of the run over the paths with --lock, waiting up to --lock-wait for the ones
+// held by another run
+func acquireRunLocks(paths []string) error {
+	targets := lockTargets(paths)
+	if len(targets) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(lockDirectory, 0o755); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(lockWait)
+	announced := false
+	for _, target := range targets {
+		for {
+			file, err := acquireLockFile(lockFilePath(target), target)
+			if err == nil {
+				heldLocks = append(heldLocks, file)
+				break
+			}
+
+			var held *lockHeldError
+			if !errors.As(err, &held) || !time.Now().Before(deadline) {
+				return err
+			}
+			if !announced {
+				fmt.Println("Waiting:", held)
+				announced = true
+			}
+			time.Sleep(min(lockPollInterval, time.Until(deadline)))
+		}
+	}
+	return nil
+}
+
+// acquireLockFile opens the lock file and locks it, recording the process id of the run holding it
+func acquireLockFile(path string, target string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLockFile(file)
+	if err != nil || !locked {
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, &lockHeldError{target: target, pid: readLockHolder(path)}
+	}
+
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return file, nil
+}
+
+// readLockHolder returns the process id recorded in the lock file, or an empty string
+func readLockHolder(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// releaseRunLocks releases the locks of the run
+func releaseRunLocks() {
+	for _, file := range heldLocks {
+		file.Close()
+	}
+	heldLocks = nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAcquireRunLocks(t *testing.T) {
+	lockDirectory = t.TempDir()
+	lockScope = pathsLockScope
+	lockWait, lockPollInterval = 50*time.Millisecond, 10*time.Millisecond
+	defer func() {
+		releaseRunLocks()
+		lockDirectory = defaultLockDirectory()
+		lockScope = noLockScope
+		lockWait, lockPollInterval = 0, time.Second
+	}()
+
+	root := t.TempDir()
+	if err := acquireRunLocks([]string{root}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another run of the same path waits for --lock-wait and gives up, telling which process holds the lock
+	_, err := acquireLockFile(lockFilePath(root), root)
+	var held *lockHeldError
+	if !errors.As(err, &held) || held.pid != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("expected the lock held by this process, got %v", err)
+	}
+	previousLocks := heldLocks
+	heldLocks = nil
+	start := time.Now()
+	if err := acquireRunLocks([]string{root}); !errors.As(err, &held) || time.Since(start) < lockWait {
+		t.Fatalf("expected to wait for the lock and give up, got %v after %s", err, time.Since(start))
+	}
+	heldLocks = previousLocks
+
+	// A run of another path is not blocked
+	file, err := acquireLockFile(lockFilePath(t.TempDir()), "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file.Close()
+
+	releaseRunLocks()
+	if err := acquireRunLocks([]string{root}); err != nil {
+		t.Fatalf("expected the released lock to be taken, got %v", err)
+	}
+}
+
+func TestLockTargets(t *testing.T) {
+	defer func() { lockScope = noLockScope }()
+
+	if targets := lockTargets([]string{"/volume1"}); len(targets) != 0 {
+		t.Fatalf("expected no lock without --lock, got %v", targets)
+	}
+
+	lockScope = pathsLockScope
+	targets := lockTargets([]string{"/volume2", "/volume1", "/volume2/", "s3://bucket/photos"})
+	volume1, _ := filepath.Abs("/volume1")
+	volume2, _ := filepath.Abs("/volume2")
+	expected := []string{volume1, volume2, "s3://bucket/photos"}
+	slices.Sort(expected)
+	if !slices.Equal(targets, expected) {
+		t.Fatalf("expected %v, got %v", expected, targets)
+	}
+
+	lockScope = globalLockScope
+	if targets := lockTargets([]string{"/volume1", "/volume2"}); len(targets) != 1 || lockFilePath(targets[0]) != filepath.Join(lockDirectory, "global.lock") {
+		t.Fatalf("expected a single global lock, got %v", targets)
+	}
+
+	lockScope = "volume"
+	if err := validateLockScope(); err == nil {
+		t.Fatalf("expected an error for an unknown scope")
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// manifestEntry is a line of a manifest like SHA512SUMS: the checksum of a file
+type manifestEntry struct {
+	Path      string
+	Checksum  string
+	Algorithm hashAlgorithm
+}
+
+// bsdManifestLine matches the BSD style lines, like "SHA512 (debian.iso) = 9b71d2..."
+var bsdManifestLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) = ([0-9A-Fa-f]+)$`)
+
+// parseManifest reads the entries of a manifest in the format of sha512sum and similar tools,
+// "<checksum>  <file>" or "<checksum> *<file>", also accepting the BSD style "SHA512 (<file>) = <checksum>".
+// Blank lines and lines starting with "#" are ignored.
+func parseManifest(content string) ([]manifestEntry, error) {
+	var entries []manifestEntry
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var checksum, path string
+		if matches := bsdManifestLine.FindStringSubmatch(line); matches != nil {
+			path, checksum = matches[2], matches[3]
+		} else {
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: expected \"<checksum>  <file>\"", lineNumber)
+			}
+			checksum = fields[0]
+			path = strings.TrimPrefix(strings.TrimPrefix(fields[1], " "), "*")
+		}
+
+		if path == "" {
+			return nil, fmt.Errorf("line %d: missing file name", lineNumber)
+		}
+
+		algorithm, err := algorithmForDigest(checksum)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		entries = append(entries, manifestEntry{Path: path, Checksum: checksum, Algorithm: algorithm})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// resolveManifestEntries makes the relative paths of the entries absolute, relative to the base directory,
+// usually the directory of the manifest, and named like the file on disk when it uses another Unicode normalization form
+func resolveManifestEntries(entries []manifestEntry, baseDirectory string) []manifestEntry {
+	resolved := make([]manifestEntry, 0, len(entries))
+	for _, entry := range entries {
+		path := filepath.FromSlash(entry.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDirectory, path)
+		}
+		entry.Path = resolveNormalizationVariant(path)
+		resolved = append(resolved, entry)
+	}
+
+	return resolved
+}
+
+// localManifestEntries returns the entries of a manifest downloaded from a URL that are inside the base directory, and
+// Malformed results for the absolute ones and the ones leaving it, so the server cannot choose which files outside
+// the tree are hashed and reported
+func localManifestEntries(entries []manifestEntry, manifestURL string) ([]manifestEntry, []ChecksumFileVerificationResult) {
+	var local []manifestEntry
+	var rejected []ChecksumFileVerificationResult
+	for _, entry := range entries {
+		if !filepath.IsLocal(filepath.FromSlash(entry.Path)) {
+			rejected = append(rejected, ChecksumFileVerificationResult{Path: manifestURL, Status: Malformed, Error: fmt.Errorf("%q is not a file inside the directory of the manifest", entry.Path)})
+			continue
+		}
+		local = append(local, entry)
+	}
+
+	return local, rejected
+}
+
+// downloadManifest downloads the manifest at the URL into the directory, with its detached signature when signatures
+// are verified: the one given with --signature when it is a URL, or the first one published next to the manifest.
+// It returns the path of the downloaded manifest.
+func downloadManifest(manifestURL string, directory string) (string, error) {
+	fileName, err := fileNameFromURL(manifestURL)
+	if err != nil {
+		return "", err
+	}
+	manifestAbsolutePath := filepath.Join(directory, fileName)
+
+	if found, err := downloadURL(manifestURL, manifestAbsolutePath); err != nil {
+		return "", err
+	} else if !found {
+		return "", fmt.Errorf("%s was not found", manifestURL)
+	}
+
+	if !verifySignatures {
+		return manifestAbsolutePath, nil
+	}
+
+	if isURL(manifestSignaturePath) {
+		signatureAbsolutePath := filepath.Join(directory, fileName+manifestSignatureExtensions[0])
+		if found, err := downloadURL(manifestSignaturePath, signatureAbsolutePath); err != nil {
+			return "", err
+		} else if !found {
+			return "", fmt.Errorf("%s was not found", manifestSignaturePath)
+		}
+		manifestSignaturePath = signatureAbsolutePath
+		return manifestAbsolutePath, nil
+	}
+	if manifestSignaturePath != "" {
+		return manifestAbsolutePath, nil
+	}
+
+	for _, extension := range manifestSignatureExtensions {
+		found, err := downloadURL(manifestURL+extension, manifestAbsolutePath+extension)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return manifestAbsolutePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("no signature found for %s, tried the extensions %s", manifestURL, strings.Join(manifestSignatureExtensions, ", "))
+}
+
+// downloadURL saves the content at the URL in the file, reporting whether it was found
+func downloadURL(fileURL string, fileAbsolutePath string) (bool, error) {
+	response, err := http.Get(fileURL)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s: %s", fileURL, response.Status)
+	}
+
+	file, err := os.Create(fileAbsolutePath)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(file, response.Body); err != nil {
+		file.Close()
+		return false, fmt.Errorf("%s: %w", fileURL, err)
+	}
+
+	return true, file.Close()
+}
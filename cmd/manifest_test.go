@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	sha512Checksum := strings.Repeat("a", 128)
+	sha256Checksum := strings.Repeat("b", 64)
+	content := "# comment\n" +
+		sha512Checksum + "  debian.iso\n" +
+		"\n" +
+		sha512Checksum + " *firmware/image with spaces.bin\n" +
+		"SHA256 (notes.txt) = " + sha256Checksum + "\n"
+
+	entries, err := parseManifest(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []manifestEntry{
+		{Path: "debian.iso", Checksum: sha512Checksum, Algorithm: sha512Algorithm},
+		{Path: "firmware/image with spaces.bin", Checksum: sha512Checksum, Algorithm: sha512Algorithm},
+		{Path: "notes.txt", Checksum: sha256Checksum},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Path != expected[i].Path || entry.Checksum != expected[i].Checksum {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, expected[i], entry)
+		}
+	}
+	if entries[2].Algorithm.Name != "sha256" {
+		t.Fatalf("expected algorithm sha256, got %s", entries[2].Algorithm.Name)
+	}
+}
+
+func TestParseManifest_Malformed(t *testing.T) {
+	for _, content := range []string{"not-a-checksum  file.txt\n", strings.Repeat("a", 128) + "\n"} {
+		if _, err := parseManifest(content); err == nil {
+			t.Fatalf("expected error for %q, got nil", content)
+		}
+	}
+}
+
+func TestSelectManifestEntries(t *testing.T) {
+	tempDir := t.TempDir()
+	manifestPath := filepath.Join(tempDir, "SHA512SUMS")
+	checksum := strings.Repeat("a", 128)
+
+	entries, err := parseManifest(checksum + "  a.txt\n" + checksum + "  b.txt\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries = resolveManifestEntries(entries, filepath.Dir(manifestPath))
+
+	all, notListed, errs := selectManifestEntries(entries, nil)
+	if len(all) != 2 || len(notListed) != 0 || len(errs) != 0 {
+		t.Fatalf("expected all the entries, got %v %v %v", all, notListed, errs)
+	}
+
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	selected, notListed, errs := selectManifestEntries(entries, []string{filepath.Join(tempDir, "b.txt"), filepath.Join(tempDir, "c.txt")})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(selected) != 1 || selected[0].Path != filepath.Join(tempDir, "b.txt") {
+		t.Fatalf("expected only b.txt to be selected, got %v", selected)
+	}
+	if len(notListed) != 1 || notListed[0] != filepath.Join(tempDir, "c.txt") {
+		t.Fatalf("expected c.txt to be not listed, got %v", notListed)
+	}
+}
+
+func TestDownloadManifest(t *testing.T) {
+	published := map[string]string{"/SHA512SUMS": "manifest", "/SHA512SUMS.sign": "signature"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := published[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, content)
+	}))
+	defer server.Close()
+
+	verifySignatures = true
+	defer func() { verifySignatures = false }()
+
+	directory := t.TempDir()
+	manifestAbsolutePath, err := downloadManifest(server.URL+"/SHA512SUMS", directory)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifestAbsolutePath != filepath.Join(directory, "SHA512SUMS") {
+		t.Fatalf("unexpected manifest path %s", manifestAbsolutePath)
+	}
+	if signaturePath, err := findManifestSignature(manifestAbsolutePath); err != nil || signaturePath != manifestAbsolutePath+".sign" {
+		t.Fatalf("expected the downloaded signature, got %s (%v)", signaturePath, err)
+	}
+	if content, err := os.ReadFile(manifestAbsolutePath); err != nil || string(content) != "manifest" {
+		t.Fatalf("unexpected manifest content %q (%v)", content, err)
+	}
+
+	delete(published, "/SHA512SUMS.sign")
+	if _, err := downloadManifest(server.URL+"/SHA512SUMS", t.TempDir()); err == nil || !strings.Contains(err.Error(), "no signature found") {
+		t.Fatalf("expected an error for the missing signature, got %v", err)
+	}
+
+	if _, err := downloadManifest(server.URL+"/MD5SUMS", t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a missing manifest")
+	}
+}
+
+func TestLocalManifestEntries(t *testing.T) {
+	checksum := strings.Repeat("a", 128)
+	entries, err := parseManifest(checksum + "  debian.iso\n" + checksum + "  /etc/shadow\n" + checksum + "  ../../home/user/.ssh/id_ed25519\n" + checksum + "  pool/../../outside\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	manifestURL := "https://mirror.example.com/SHA512SUMS"
+	local, rejected := localManifestEntries(entries, manifestURL)
+	if len(local) != 1 || local[0].Path != "debian.iso" {
+		t.Fatalf("expected only debian.iso to be kept, got %v", local)
+	}
+	if len(rejected) != 3 {
+		t.Fatalf("expected the entries outside the directory to be rejected, got %v", rejected)
+	}
+	for _, result := range rejected {
+		if result.Status != Malformed || result.Path != manifestURL || result.Error == nil {
+			t.Fatalf("expected the entry to be malformed, got %+v", result)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// metricsTextfile is set by --metrics-textfile in check
+var metricsTextfile string
+
+// metricsTextfileUsage is the usage of the --metrics-textfile flag
+const metricsTextfileUsage = "write the metrics of the check to this file in the Prometheus text format, like /var/lib/node_exporter/checksum.prom for the textfile collector of node_exporter"
+
+// hashedBytesTotal is the amount of data hashed since the start of the command
+var hashedBytesTotal atomic.Int64
+
+// hashedBytesReader counts the bytes read in hashedBytesTotal
+type hashedBytesReader struct {
+	reader io.Reader
+}
+
+func (r *hashedBytesReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	hashedBytesTotal.Add(int64(n))
+	return n, err
+}
+
+// countHashedBytes counts the bytes read from the reader in hashedBytesTotal
+func countHashedBytes(reader io.Reader) io.Reader {
+	return &hashedBytesReader{reader: reader}
+}
+
+// checkStartedAt is the time when the check started, for the duration of its metrics
+var checkStartedAt time.Time
+
+// writeCheckMetrics writes the metrics of the check, with the results of all its paths, to the file given
+// with --metrics-textfile
+func writeCheckMetrics(results []ChecksumFileVerificationResult) {
+	if metricsTextfile == "" {
+		return
+	}
+
+	metricsAbsolutePath, err := filepath.Abs(metricsTextfile)
+	if err == nil {
+		err = replaceFile(metricsAbsolutePath, formatCheckMetrics(results, hashedBytesTotal.Load(), time.Since(checkStartedAt), time.Now()))
+	}
+	if err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, fmt.Errorf("the metrics could not be written: %w", err))
+	}
+}
+
+// checkSummary counts the results of a check
+type checkSummary struct {
+	Checked    int
+	Matched    int
+	Mismatched int
+	Failed     int
+}
+
+// summarizeCheck counts the files compared with their checksum, the ones that matched and did not match it,
+// and the ones that could not be checked
+func summarizeCheck(results []ChecksumFileVerificationResult) checkSummary {
+	var summary checkSummary
+	for _, result := range results {
+		switch result.Status {
+		case Match:
+			summary.Checked++
+			summary.Matched++
+		case NotMatch:
+			summary.Checked++
+			summary.Mismatched++
+		case CheckingFailed, LockedVerification, BadSignature, Malformed, TimedOutVerification:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// formatCheckMetrics returns the metrics of a check in the Prometheus text format. They are gauges with the values
+// of the last check, since the file is replaced on each run.
+func formatCheckMetrics(results []ChecksumFileVerificationResult, hashedBytes int64, duration time.Duration, finishedAt time.Time) string {
+	summary := summarizeCheck(results)
+
+	var metrics strings.Builder
+	writeMetric := func(name string, help string, value any) {
+		fmt.Fprintf(&metrics, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	writeMetric("checksum_utils_check_files_checked", "Files compared with their checksum in the last check.", summary.Checked)
+	writeMetric("checksum_utils_check_files_matched", "Files that matched their checksum in the last check.", summary.Matched)
+	writeMetric("checksum_utils_check_files_mismatched", "Files that did not match their checksum in the last check.", summary.Mismatched)
+	writeMetric("checksum_utils_check_files_failed", "Files that could not be checked in the last check.", summary.Failed)
+	writeMetric("checksum_utils_check_bytes_hashed", "Bytes hashed in the last check.", hashedBytes)
+	writeMetric("checksum_utils_check_duration_seconds", "Duration of the last check.", duration.Seconds())
+	writeMetric("checksum_utils_check_last_run_timestamp_seconds", "Time when the last check finished.", finishedAt.Unix())
+	return metrics.String()
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCheckMetrics(t *testing.T) {
+	results := []ChecksumFileVerificationResult{
+		{Path: "a", Status: Match},
+		{Path: "b", Status: Match},
+		{Path: "c", Status: NotMatch},
+		{Path: "d", Status: Malformed},
+		{Path: "e", Status: NotFound},
+	}
+
+	metrics := formatCheckMetrics(results, 2048, 1500*time.Millisecond, time.Unix(1700000000, 0))
+
+	for _, expected := range []string{
+		"# TYPE checksum_utils_check_files_checked gauge\nchecksum_utils_check_files_checked 3\n",
+		"checksum_utils_check_files_matched 2\n",
+		"checksum_utils_check_files_mismatched 1\n",
+		"checksum_utils_check_files_failed 1\n",
+		"checksum_utils_check_bytes_hashed 2048\n",
+		"checksum_utils_check_duration_seconds 1.5\n",
+		"checksum_utils_check_last_run_timestamp_seconds 1700000000\n",
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", expected, metrics)
+		}
+	}
+}
+
+func TestWriteCheckMetrics(t *testing.T) {
+	metricsTextfile = filepath.Join(t.TempDir(), "checksum.prom")
+	defer func() { metricsTextfile = "" }()
+	errorsCheckingChecksumFiles = nil
+
+	writeCheckMetrics([]ChecksumFileVerificationResult{{Path: "a", Status: Match}})
+
+	if len(errorsCheckingChecksumFiles) > 0 {
+		t.Fatalf("unexpected errors: %v", errorsCheckingChecksumFiles)
+	}
+	content, err := os.ReadFile(metricsTextfile)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	if !strings.Contains(string(content), "checksum_utils_check_files_matched 1\n") {
+		t.Fatalf("unexpected metrics:\n%s", content)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// multiHashExtension is the extension of the checksum files that keep the checksums of several algorithms, one
+// BSD style line per algorithm like "SHA512 (wedding.raw) = 9b71d2...", that cksum -c can verify too
+const multiHashExtension = ".checksums"
+
+// multiHashAlgorithmNames are the algorithms of the .checksums files created with --algorithms
+var multiHashAlgorithmNames []string
+
+// multiHashAlgorithms returns the algorithms given with --algorithms
+func multiHashAlgorithms() ([]hashAlgorithm, error) {
+	algorithms := make([]hashAlgorithm, 0, len(multiHashAlgorithmNames))
+	for _, name := range multiHashAlgorithmNames {
+		algorithm, err := algorithmByName(strings.ToLower(strings.TrimSpace(name)))
+		if err != nil {
+			return nil, err
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+	return algorithms, nil
+}
+
+// hashReaderWithAll consumes the reader once and returns its hexadecimal checksums using each of the algorithms
+func hashReaderWithAll(reader io.Reader, algorithms []hashAlgorithm) ([]string, error) {
+	hashers := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, algorithm := range algorithms {
+		hashers[i] = algorithm.New()
+		writers[i] = hashers[i]
+	}
+
+	if err := copyDoubleBuffered(io.MultiWriter(writers...), throttle(countHashedBytes(reader))); err != nil {
+		return nil, err
+	}
+
+	checksums := make([]string, len(hashers))
+	for i, hasher := range hashers {
+		checksums[i] = hex.EncodeToString(hasher.Sum(nil))
+	}
+	return checksums, nil
+}
+
+// createMultiHashFile stores the checksums of the file for each of the --algorithms in its .checksums file
+func createMultiHashFile(fileAbsolutePath string) ChecksumFileCreationResult {
+	multiHashFilePath := fileAbsolutePath + multiHashExtension
+
+	_, err := os.Stat(multiHashFilePath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+	}
+	if exists && !overwriteExisting {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Existing, Error: nil}
+	}
+
+	fileName := filepath.Base(fileAbsolutePath)
+	if strings.ContainsAny(fileName, "\n\r") {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: fmt.Errorf("the name of the file is not supported by %s files", multiHashExtension)}
+	}
+
+	algorithms, err := multiHashAlgorithms()
+	if err != nil {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+	}
+
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		if _, special := err.(*specialFileError); special {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: SpecialCreation, Error: err}
+		}
+		if os.IsPermission(err) {
+			return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: LockedCreation, Error: err}
+		}
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+	}
+	defer file.Close()
+
+	counter := &countingReader{reader: file}
+	checksums, err := hashReaderWithAll(counter, algorithms)
+	if err != nil {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+	}
+
+	var content strings.Builder
+	if writeHeader {
+		algorithmNames := make([]string, len(algorithms))
+		for i, algorithm := range algorithms {
+			algorithmNames[i] = algorithm.Name
+		}
+		content.WriteString(metadataHeader(algorithmNames...))
+	}
+	for i, algorithm := range algorithms {
+		fmt.Fprintf(&content, "%s (%s) = %s\n", strings.ToUpper(algorithm.Name), fileName, checksums[i])
+	}
+	if err := replaceChecksumFile(multiHashFilePath, content.String()); err != nil {
+		return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: Failed, Error: err}
+	}
+
+	status := Created
+	if exists {
+		status = Overwritten
+	}
+	return ChecksumFileCreationResult{Path: fileAbsolutePath, Status: status, Error: nil, HashedBytes: counter.count}
+}
+
+// checkMultiHashFile compares the file read from the reader with each of the checksums of its .checksums file,
+// reporting the status of each algorithm when any of them does not match
+func checkMultiHashFile(fileAbsolutePath string, reader io.Reader) ChecksumFileVerificationResult {
+	content, err := os.ReadFile(fileAbsolutePath + multiHashExtension)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	}
+
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: fmt.Errorf("%s: %w", fileAbsolutePath+multiHashExtension, err)}
+	}
+	if len(entries) == 0 {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: fmt.Errorf("%s has no checksums", fileAbsolutePath+multiHashExtension)}
+	}
+
+	algorithms := make([]hashAlgorithm, len(entries))
+	for i, entry := range entries {
+		algorithms[i] = entry.Algorithm
+	}
+	checksums, err := hashReaderWithAll(reader, algorithms)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: CheckingFailed, Error: err}
+	}
+
+	allMatch := true
+	statuses := make([]string, len(entries))
+	for i, entry := range entries {
+		if strings.EqualFold(checksums[i], entry.Checksum) {
+			statuses[i] = entry.Algorithm.Name + " matches"
+		} else {
+			statuses[i] = entry.Algorithm.Name + " does not match"
+			allMatch = false
+		}
+	}
+
+	if allMatch {
+		return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: Match, Error: nil}
+	}
+	return ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: NotMatch, Error: errors.New(strings.Join(statuses, ", "))}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateAndCheck_MultiHashFile(t *testing.T) {
+	multiHashAlgorithmNames = []string{"sha512", "sha256"}
+	defer func() { multiHashAlgorithmNames = nil }()
+
+	filePath := filepath.Join(t.TempDir(), "wedding.raw")
+	if err := os.WriteFile(filePath, []byte("raw"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	content, err := os.ReadFile(filePath + multiHashExtension)
+	if err != nil {
+		t.Fatalf("read %s file: %v", multiHashExtension, err)
+	}
+	if !strings.HasPrefix(string(content), "SHA512 (wedding.raw) = ") || !strings.Contains(string(content), "\nSHA256 (wedding.raw) = ") {
+		t.Fatalf("expected a line per algorithm, got %q", content)
+	}
+	if _, err := os.Stat(filePath + checksumFileExtension); !os.IsNotExist(err) {
+		t.Fatalf("expected no %s checksum file", checksumFileExtension)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+
+	// Corrupt only the sha256 checksum
+	lines := strings.Split(string(content), "\n")
+	lines[1] = lines[1][:len(lines[1])-1] + "0"
+	if lines[1] == strings.Split(string(content), "\n")[1] {
+		lines[1] = lines[1][:len(lines[1])-1] + "1"
+	}
+	if err := os.WriteFile(filePath+multiHashExtension, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatalf("write %s file: %v", multiHashExtension, err)
+	}
+	result := checkChecksumFile(filePath)
+	if result.Status != NotMatch || result.Error == nil || result.Error.Error() != "sha512 matches, sha256 does not match" {
+		t.Fatalf("expected status %s per algorithm, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+}
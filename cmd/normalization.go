@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizedPath returns the path in the NFC Unicode normalization form, so the names written in NFC, like on
+// Linux and Windows, and the ones written in NFD, like by macOS, compare equal
+func normalizedPath(path string) string {
+	return norm.NFC.String(path)
+}
+
+// normalizationVariant returns the path of the file named like the path in another Unicode normalization form,
+// when it exists, like the NFD name given by macOS to a file whose checksum file or manifest entry uses NFC.
+// The whole path and its last element are tried in both forms, without listing the directory.
+func normalizationVariant(path string) (string, bool) {
+	if isASCII(path) {
+		return "", false
+	}
+
+	directory, name := filepath.Split(path)
+	candidates := []string{
+		norm.NFC.String(path),
+		norm.NFD.String(path),
+		directory + norm.NFC.String(name),
+		directory + norm.NFD.String(name),
+	}
+	for _, candidate := range candidates {
+		if candidate == path {
+			continue
+		}
+		if _, err := os.Lstat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// resolveNormalizationVariant returns the path, or its normalization variant when only that one exists
+func resolveNormalizationVariant(path string) string {
+	if isASCII(path) {
+		return path
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return path
+	}
+	if variant, found := normalizationVariant(path); found {
+		return variant
+	}
+	return path
+}
+
+// isASCII reports whether the path only has ASCII characters, which have a single normalization form
+func isASCII(path string) bool {
+	for i := 0; i < len(path); i++ {
+		if path[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
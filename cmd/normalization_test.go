@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestNormalizationVariants(t *testing.T) {
+	tempDir := t.TempDir()
+	nfcName := norm.NFC.String("café.raw")
+	nfdName := norm.NFD.String("café.raw")
+	if nfcName == nfdName {
+		t.Fatalf("expected the NFC and NFD names to differ")
+	}
+
+	// A file named in NFD by macOS, with its checksum file named in NFC on the NAS
+	nfdPath := filepath.Join(tempDir, nfdName)
+	if err := os.WriteFile(nfdPath, []byte("espresso"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	checksum, err := hashPath(nfdPath, sha512Algorithm)
+	if err != nil {
+		t.Fatalf("hash file: %v", err)
+	}
+	nfcChecksumPath := filepath.Join(tempDir, nfcName+checksumFileExtension)
+	if err := os.WriteFile(nfcChecksumPath, []byte(checksum), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if path := checksumFilePath(nfdPath); path != nfcChecksumPath {
+		t.Fatalf("expected the checksum file named in NFC, got %q", path)
+	}
+	if result := verifyChecksumFile(nfdPath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+	if _, orphaned := checkOrphanedChecksumFile(nfcChecksumPath); orphaned {
+		t.Fatalf("expected the checksum file named in NFC not to be orphaned")
+	}
+
+	entries := resolveManifestEntries([]manifestEntry{{Path: nfcName, Checksum: checksum}}, tempDir)
+	if entries[0].Path != nfdPath {
+		t.Fatalf("expected the manifest entry resolved to the file named in NFD, got %q", entries[0].Path)
+	}
+	selected, notListed, _ := selectManifestEntries([]manifestEntry{{Path: filepath.Join(tempDir, nfcName)}}, []string{nfdPath})
+	if len(selected) != 1 || len(notListed) != 0 {
+		t.Fatalf("expected the file selected in the manifest, got %v and %v", selected, notListed)
+	}
+
+	if path := resolveNormalizationVariant(filepath.Join(tempDir, "missing.raw")); path != filepath.Join(tempDir, "missing.raw") {
+		t.Fatalf("expected the missing ASCII path unchanged, got %q", path)
+	}
+}
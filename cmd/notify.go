@@ -0,0 +1,249 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webhookURL is set by --webhook-url in check
+var webhookURL string
+
+// webhookURLUsage is the usage of the --webhook-url flag
+const webhookURLUsage = "POST a JSON summary of the check to this URL when it finishes, and an alert as soon as a file does not match its checksum"
+
+// webhookClient sends the webhooks, without waiting forever for a server that does not answer
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// pendingWebhookAlerts are the alerts being sent, waited for before sending the summary
+var pendingWebhookAlerts sync.WaitGroup
+
+// webhookFile is a file of a webhook, with the reason of its result
+type webhookFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+// webhookAlert is sent as soon as a file does not match its checksum
+type webhookAlert struct {
+	Event      string    `json:"event"`
+	Host       string    `json:"host"`
+	DetectedAt time.Time `json:"detected_at"`
+	webhookFile
+}
+
+// webhookSummary is sent when the check finishes
+type webhookSummary struct {
+	Event           string        `json:"event"`
+	Host            string        `json:"host"`
+	StartedAt       time.Time     `json:"started_at"`
+	FinishedAt      time.Time     `json:"finished_at"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	FilesChecked    int           `json:"files_checked"`
+	FilesMatched    int           `json:"files_matched"`
+	FilesMismatched int           `json:"files_mismatched"`
+	FilesFailed     int           `json:"files_failed"`
+	BytesHashed     int64         `json:"bytes_hashed"`
+	Mismatches      []webhookFile `json:"mismatches"`
+	Failures        []webhookFile `json:"failures"`
+	Errors          []string      `json:"errors"`
+}
+
+// finishCheckRun exports the results of the check, with all its paths, to the metrics, the notifications and
+// the state file read by the status command, after verifying that the mounts of --read-only are still read-only
+func finishCheckRun(results []ChecksumFileVerificationResult) {
+	if err := finishReadOnlyCheck(); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+	writeCheckMetrics(results)
+	notifyCheckFinished(results)
+	if err := recordCheckFinished(results, errorsCheckingChecksumFiles, stoppedByMaxDuration.Load() || stoppedByMaxErrors.Load() || stoppedByFailFast.Load()); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+}
+
+// notifyCheckFinished sends the summary of the check to the webhook given with --webhook-url, its report
+// to the addresses given with --email-to, a message to the chat services when files did not match or failed,
+// and pings the --ping-url
+func notifyCheckFinished(results []ChecksumFileVerificationResult) {
+	if webhookURL != "" {
+		pendingWebhookAlerts.Wait()
+		if err := postWebhook(newWebhookSummary(results, errorsCheckingChecksumFiles, time.Now())); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
+
+	if err := sendEmailReport(results, errorsCheckingChecksumFiles); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+
+	for _, err := range sendChatNotifications(results, errorsCheckingChecksumFiles) {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+
+	if err := pingFinished(results, errorsCheckingChecksumFiles); err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+	}
+}
+
+// pingURL is set by --ping-url in check
+var pingURL string
+
+// pingURLUsage is the usage of the --ping-url flag
+const pingURLUsage = "ping this URL of a dead man's switch like healthchecks.io: its /start when the check starts, and itself when it finishes successfully or its /fail when files do not match or fail"
+
+// pingStarted pings the /start endpoint of the URL given with --ping-url, so the duration of the check is measured
+func pingStarted() error {
+	if pingURL == "" {
+		return nil
+	}
+	return ping(strings.TrimSuffix(pingURL, "/")+"/start", "")
+}
+
+// pingFinished pings the URL given with --ping-url when the check succeeded, or its /fail endpoint when files did
+// not match or failed, or there were errors, with the summary of the check
+func pingFinished(results []ChecksumFileVerificationResult, errs []error) error {
+	if pingURL == "" {
+		return nil
+	}
+
+	endpoint := strings.TrimSuffix(pingURL, "/")
+	if summary := summarizeCheck(results); summary.Mismatched > 0 || summary.Failed > 0 || len(errs) > 0 {
+		endpoint += "/fail"
+	}
+	return ping(endpoint, checkHeadline(results, errs))
+}
+
+// ping posts the message to the endpoint of the dead man's switch
+func ping(endpoint string, message string) error {
+	response, err := webhookClient.Post(endpoint, "text/plain; charset=utf-8", strings.NewReader(message))
+	if err != nil {
+		return fmt.Errorf("the ping could not be sent: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("the ping could not be sent: %s", response.Status)
+	}
+	return nil
+}
+
+// checkHeadline summarizes the check in a line, telling how many files did not match or failed when there are
+func checkHeadline(results []ChecksumFileVerificationResult, errs []error) string {
+	summary := summarizeCheck(results)
+	if summary.Mismatched > 0 || summary.Failed > 0 || len(errs) > 0 {
+		return fmt.Sprintf("checksum-utils on %s: %d files do not match, %d failed, %d errors", hostname(), summary.Mismatched, summary.Failed, len(errs))
+	}
+	return fmt.Sprintf("checksum-utils on %s: %d files match", hostname(), summary.Matched)
+}
+
+// alertMismatch sends an alert to the webhook given with --webhook-url when the file does not match its checksum,
+// without waiting for it
+func alertMismatch(result ChecksumFileVerificationResult) {
+	if webhookURL == "" || result.Status != NotMatch {
+		return
+	}
+
+	alert := webhookAlert{Event: "mismatch", Host: hostname(), DetectedAt: time.Now(), webhookFile: newWebhookFile(result)}
+	pendingWebhookAlerts.Add(1)
+	go func() {
+		defer pendingWebhookAlerts.Done()
+		if err := postWebhook(alert); err != nil {
+			appendLocked(&errorsCheckingChecksumFiles, err)
+		}
+	}()
+}
+
+// newWebhookSummary returns the summary of the check finished at finishedAt
+func newWebhookSummary(results []ChecksumFileVerificationResult, errs []error, finishedAt time.Time) webhookSummary {
+	summary := summarizeCheck(results)
+	webhook := webhookSummary{
+		Event:           "check_finished",
+		Host:            hostname(),
+		StartedAt:       checkStartedAt,
+		FinishedAt:      finishedAt,
+		DurationSeconds: finishedAt.Sub(checkStartedAt).Seconds(),
+		FilesChecked:    summary.Checked,
+		FilesMatched:    summary.Matched,
+		FilesMismatched: summary.Mismatched,
+		FilesFailed:     summary.Failed,
+		BytesHashed:     hashedBytesTotal.Load(),
+		Mismatches:      []webhookFile{},
+		Failures:        []webhookFile{},
+		Errors:          []string{},
+	}
+	for _, result := range results {
+		switch result.Status {
+		case NotMatch:
+			webhook.Mismatches = append(webhook.Mismatches, newWebhookFile(result))
+		case CheckingFailed, LockedVerification, BadSignature, Malformed, TimedOutVerification:
+			webhook.Failures = append(webhook.Failures, newWebhookFile(result))
+		}
+	}
+	for _, err := range errs {
+		webhook.Errors = append(webhook.Errors, err.Error())
+	}
+	return webhook
+}
+
+func newWebhookFile(result ChecksumFileVerificationResult) webhookFile {
+	file := webhookFile{Path: result.Path}
+	if result.Error != nil {
+		file.Error = result.Error.Error()
+	}
+	return file
+}
+
+// postWebhook sends the payload as JSON to the webhook given with --webhook-url
+func postWebhook(payload any) error {
+	if err := postJSON(webhookURL, payload); err != nil {
+		return fmt.Errorf("the webhook could not be sent: %w", err)
+	}
+	return nil
+}
+
+// postJSON sends the payload as JSON to the URL, failing when the server does not accept it
+func postJSON(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	response, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return errors.New(response.Status)
+	}
+	return nil
+}
+
+// hostname returns the name of the host, or an empty string when it is unknown
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWebhookNotifications(t *testing.T) {
+	var mutex sync.Mutex
+	var events []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s with %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var event map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode webhook: %v", err)
+		}
+		mutex.Lock()
+		events = append(events, event)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	webhookURL = server.URL
+	defer func() { webhookURL = "" }()
+	errorsCheckingChecksumFiles = nil
+
+	results := []ChecksumFileVerificationResult{
+		{Path: "/volume1/a.raw", Status: Match},
+		{Path: "/volume1/b.raw", Status: NotMatch, Error: errors.New("probable bit rot")},
+		{Path: "/volume1/c.raw", Status: CheckingFailed, Error: errors.New("input/output error")},
+	}
+	alertMismatch(results[0])
+	alertMismatch(results[1])
+	notifyCheckFinished(results)
+
+	if len(errorsCheckingChecksumFiles) > 0 {
+		t.Fatalf("unexpected errors: %v", errorsCheckingChecksumFiles)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected an alert and a summary, got %v", events)
+	}
+	if events[0]["event"] != "mismatch" || events[0]["path"] != "/volume1/b.raw" || events[0]["error"] != "probable bit rot" {
+		t.Fatalf("unexpected alert %v", events[0])
+	}
+	summary := events[1]
+	if summary["event"] != "check_finished" || summary["files_checked"] != float64(2) || summary["files_mismatched"] != float64(1) || summary["files_failed"] != float64(1) {
+		t.Fatalf("unexpected summary %v", summary)
+	}
+	if mismatches := summary["mismatches"].([]any); len(mismatches) != 1 {
+		t.Fatalf("expected a mismatch in the summary, got %v", mismatches)
+	}
+}
+
+func TestWebhookNotifications_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhookURL = server.URL
+	defer func() { webhookURL = "" }()
+	errorsCheckingChecksumFiles = nil
+	defer func() { errorsCheckingChecksumFiles = nil }()
+
+	notifyCheckFinished(nil)
+
+	if len(errorsCheckingChecksumFiles) != 1 {
+		t.Fatalf("expected the failed webhook to be reported, got %v", errorsCheckingChecksumFiles)
+	}
+}
+
+func TestPing(t *testing.T) {
+	var pings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings = append(pings, r.URL.Path)
+	}))
+	defer server.Close()
+
+	pingURL = server.URL + "/your-uuid"
+	defer func() { pingURL = "" }()
+
+	if err := pingStarted(); err != nil {
+		t.Fatalf("ping start: %v", err)
+	}
+	if err := pingFinished([]ChecksumFileVerificationResult{{Path: "a", Status: Match}}, nil); err != nil {
+		t.Fatalf("ping success: %v", err)
+	}
+	if err := pingFinished([]ChecksumFileVerificationResult{{Path: "a", Status: NotMatch}}, nil); err != nil {
+		t.Fatalf("ping failure: %v", err)
+	}
+
+	expected := []string{"/your-uuid/start", "/your-uuid", "/your-uuid/fail"}
+	if strings.Join(pings, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected the pings %v, got %v", expected, pings)
+	}
+}
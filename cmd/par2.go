@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// par2Extension is the extension of the PAR2 recovery files created next to the files
+const par2Extension = ".par2"
+
+// par2Program is the par2cmdline executable used to create the recovery data and to restore the files
+var par2Program = "par2"
+
+var createPar2 bool
+var par2Redundancy int
+var repairWithPar2 bool
+
+// ensurePar2 returns an error when par2cmdline is not installed
+func ensurePar2() error {
+	if _, err := exec.LookPath(par2Program); err != nil {
+		return fmt.Errorf("PAR2 recovery data requires par2cmdline (%s) in the PATH: %w", par2Program, err)
+	}
+	return nil
+}
+
+// hasPar2 reports whether the file has PAR2 recovery data
+func hasPar2(fileAbsolutePath string) bool {
+	_, err := os.Stat(fileAbsolutePath + par2Extension)
+	return err == nil
+}
+
+// createPar2Files creates the PAR2 recovery data of the file, <file>.par2 and a single <file>.volXX+YY.par2 volume,
+// able to restore the given percentage of the file
+func createPar2Files(fileAbsolutePath string, redundancy int) error {
+	name := filepath.Base(fileAbsolutePath)
+	return runPar2(filepath.Dir(fileAbsolutePath), "create", "-q", "-r"+strconv.Itoa(redundancy), "-n1", "--", name+par2Extension, name)
+}
+
+// restoreWithPar2 restores the damaged file from its PAR2 recovery data, and creates the recovery data again,
+// because par2cmdline removes it with the backup of the damaged file once the file is restored
+func restoreWithPar2(fileAbsolutePath string, redundancy int) error {
+	if !hasPar2(fileAbsolutePath) {
+		return fmt.Errorf("%s has no PAR2 recovery data", fileAbsolutePath)
+	}
+
+	name := filepath.Base(fileAbsolutePath)
+	if err := runPar2(filepath.Dir(fileAbsolutePath), "repair", "-q", "-p", "--", name+par2Extension); err != nil {
+		return err
+	}
+
+	if err := createPar2Files(fileAbsolutePath, redundancy); err != nil {
+		return fmt.Errorf("the file was restored but its recovery data could not be created again: %w", err)
+	}
+
+	return nil
+}
+
+// runPar2 runs par2cmdline in the directory and includes its output in the error when it fails
+func runPar2(directory string, args ...string) error {
+	var output bytes.Buffer
+	command := exec.Command(par2Program, args...)
+	command.Dir = directory
+	command.Stdout = &output
+	command.Stderr = &output
+
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(output.String())
+		if message == "" {
+			return fmt.Errorf("par2: %w", err)
+		}
+		return fmt.Errorf("par2: %w: %s", err, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// quarantineNotMatchingFiles moves the files that do not match their checksum file, with their checksum file and signature,
+// into the quarantine directory, keeping their path relative to the processed path that contains them
+func quarantineNotMatchingFiles(results []ChecksumFileVerificationResult, paths []string, quarantineDirectory string) {
+	quarantineAbsolutePath, err := filepath.Abs(quarantineDirectory)
+	if err != nil {
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		return
+	}
+
+	for i, result := range results {
+		if result.Status != NotMatch {
+			continue
+		}
+
+		relativePath, inside := quarantineRelativePath(result.Path, paths)
+		if !inside {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, fmt.Errorf("quarantine %s: the file is not inside the checked paths", result.Path))
+			continue
+		}
+		destination := filepath.Join(quarantineAbsolutePath, relativePath)
+		if err := quarantineFile(result.Path, result.ListedIn, destination); err != nil {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, fmt.Errorf("quarantine %s: %w", result.Path, err))
+			continue
+		}
+
+		results[i].QuarantinedTo = destination
+		fmt.Printf("- %s → %s ☣️\n", result.Path, destination)
+	}
+}
+
+// quarantineRelativePath returns the path of the file relative to the processed directory that contains it,
+// or its name when it was given directly. It reports false for the files outside the processed paths.
+func quarantineRelativePath(fileAbsolutePath string, paths []string) (string, bool) {
+	for _, path := range paths {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		if absolutePath == fileAbsolutePath {
+			return filepath.Base(fileAbsolutePath), true
+		}
+
+		relativePath, err := filepath.Rel(absolutePath, fileAbsolutePath)
+		if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		return filepath.Join(filepath.Base(absolutePath), relativePath), true
+	}
+
+	return "", false
+}
+
+// quarantineFile moves the file, its checksum file and its signature to the destination. A checksum file listing
+// other files too, like the one given in listedIn, is left in place for them, and the checksum of the file is
+// recorded in a new checksum file next to the destination.
+func quarantineFile(fileAbsolutePath string, listedIn string, destination string) error {
+	if _, err := os.Lstat(destination); err == nil {
+		return fmt.Errorf("%s already exists", destination)
+	}
+
+	checksumFile := checksumFilePath(fileAbsolutePath)
+	if listedIn == "" {
+		if content, err := readChecksumFileContent(checksumFile); err == nil && len(content.Entries) > 1 {
+			listedIn = checksumFile
+		}
+	}
+	var listedChecksum string
+	if listedIn != "" {
+		checksum, err := checksumListedFor(listedIn, fileAbsolutePath)
+		if err != nil {
+			return err
+		}
+		listedChecksum = checksum
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return err
+	}
+
+	if err := moveFile(fileAbsolutePath, destination); err != nil {
+		return err
+	}
+
+	if listedIn != "" {
+		return os.WriteFile(destination+checksumFileExtension, []byte(coreutilsChecksumLine(listedChecksum, filepath.Base(destination))), 0o644)
+	}
+
+	// The checksum file is quarantined next to the file, even when it was in the store directory
+	for source, target := range map[string]string{
+		checksumFile:                      destination + checksumFileExtension,
+		checksumFile + signatureExtension: destination + checksumFileExtension + signatureExtension,
+	} {
+		if _, err := os.Stat(source); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := moveFile(source, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checksumListedFor returns the checksum of the line of the file in the checksum file listing several files
+func checksumListedFor(checksumFileAbsolutePath string, fileAbsolutePath string) (string, error) {
+	content, err := readChecksumFileContent(checksumFileAbsolutePath)
+	if err != nil {
+		return "", err
+	}
+	if checksumFileAbsolutePath == checksumFilePath(fileAbsolutePath) {
+		if checksum, listed := content.checksumOfFile(fileAbsolutePath); listed {
+			return checksum, nil
+		}
+	}
+	for _, entry := range content.Entries {
+		listedPath := filepath.Join(filepath.Dir(checksumFileAbsolutePath), filepath.FromSlash(entry.Name))
+		if normalizedPath(listedPath) == normalizedPath(fileAbsolutePath) {
+			return entry.Checksum, nil
+		}
+	}
+	return "", fmt.Errorf("%s does not list %s", checksumFileAbsolutePath, fileAbsolutePath)
+}
+
+// moveFile renames the file, copying and removing it when the destination is on another file system
+func moveFile(source string, destination string) error {
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	sourceInfo, err := sourceFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_EXCL, sourceInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(destinationFile, sourceFile); err != nil {
+		destinationFile.Close()
+		os.Remove(destination)
+		return err
+	}
+
+	if err := destinationFile.Close(); err != nil {
+		os.Remove(destination)
+		return err
+	}
+
+	if err := os.Chtimes(destination, sourceInfo.ModTime(), sourceInfo.ModTime()); err != nil {
+		return err
+	}
+
+	sourceFile.Close()
+	return os.Remove(source)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuarantineNotMatchingFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	dataDir := filepath.Join(tempDir, "photos")
+	quarantineDir := filepath.Join(tempDir, "quarantine")
+	filePath := filepath.Join(dataDir, "2024", "wedding.raw")
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("corrupted"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filePath+".sha512", []byte("0000"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	results := []ChecksumFileVerificationResult{{Path: filePath, Status: NotMatch}}
+	quarantineNotMatchingFiles(results, []string{dataDir}, quarantineDir)
+
+	expected := filepath.Join(quarantineDir, "photos", "2024", "wedding.raw")
+	if results[0].QuarantinedTo != expected {
+		t.Fatalf("expected the file to be quarantined in %s, got %q (%v)", expected, results[0].QuarantinedTo, errorsCheckingChecksumFiles)
+	}
+
+	for _, path := range []string{expected, expected + ".sha512"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to exist: %v", path, err)
+		}
+	}
+	for _, path := range []string{filePath, filePath + ".sha512"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be moved, got %v", path, err)
+		}
+	}
+}
+
+func TestQuarantineNotMatchingFiles_OutsideThePaths(t *testing.T) {
+	tempDir := t.TempDir()
+	victimPath := filepath.Join(tempDir, "outside", "victim.txt")
+	if err := os.MkdirAll(filepath.Dir(victimPath), 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(victimPath, []byte("victim"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	defer func() { errorsCheckingChecksumFiles = nil }()
+
+	results := []ChecksumFileVerificationResult{{Path: victimPath, Status: NotMatch}}
+	quarantineNotMatchingFiles(results, []string{filepath.Join(tempDir, "tree")}, filepath.Join(tempDir, "quarantine"))
+
+	if results[0].QuarantinedTo != "" {
+		t.Fatalf("expected the file outside the checked paths not to be quarantined, got %q", results[0].QuarantinedTo)
+	}
+	if _, err := os.Stat(victimPath); err != nil {
+		t.Fatalf("expected %s to be kept: %v", victimPath, err)
+	}
+}
+
+func TestQuarantineNotMatchingFiles_ListingChecksumFile(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "photos")
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	checksums := map[string]string{"a.raw": strings.Repeat("a", 128), "b.raw": strings.Repeat("b", 128), "c.raw": strings.Repeat("c", 128)}
+	for name := range checksums {
+		if err := os.WriteFile(filepath.Join(dataDir, name), []byte("corrupted"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	listing := checksums["a.raw"] + "  a.raw\n" + checksums["b.raw"] + "  b.raw\n" + checksums["c.raw"] + "  c.raw\n"
+	listingPath := filepath.Join(dataDir, "a.raw.sha512")
+	if err := os.WriteFile(listingPath, []byte(listing), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	// a.raw failed through its own checksum file and b.raw through the one of a.raw, which also lists c.raw
+	results := []ChecksumFileVerificationResult{
+		{Path: filepath.Join(dataDir, "a.raw"), Status: NotMatch},
+		{Path: filepath.Join(dataDir, "b.raw"), Status: NotMatch, ListedIn: listingPath},
+	}
+	quarantineNotMatchingFiles(results, []string{dataDir}, quarantineDir)
+
+	if content, err := os.ReadFile(listingPath); err != nil || string(content) != listing {
+		t.Fatalf("expected the checksum file listing other files to be kept, got %q (%v)", content, err)
+	}
+	for _, result := range results {
+		name := filepath.Base(result.Path)
+		if result.QuarantinedTo != filepath.Join(quarantineDir, "photos", name) {
+			t.Fatalf("expected %s to be quarantined, got %q (%v)", name, result.QuarantinedTo, errorsCheckingChecksumFiles)
+		}
+		recorded, err := readChecksumFileContent(result.QuarantinedTo + ".sha512")
+		if err != nil {
+			t.Fatalf("read the quarantined checksum file: %v", err)
+		}
+		if checksum, listed := recorded.checksumOfFile(result.QuarantinedTo); !listed || checksum != checksums[name] {
+			t.Fatalf("expected the checksum of %s to be recorded, got %q", name, checksum)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// rcloneScheme is the prefix of the paths on the remotes configured in rclone, like rclone:b2-archive:photos
+const rcloneScheme = "rclone:"
+
+// rcloneProgram is the rclone client used to reach the remotes, so the remotes of the user's rclone configuration
+// are used
+const rcloneProgram = "rclone"
+
+// rcloneLocation is a folder on a remote of rclone, parsed from a path like rclone:b2-archive:photos
+type rcloneLocation struct {
+	Path string
+}
+
+// parseRclonePath parses a path like rclone:remote:path, where remote:path is a path understood by rclone
+func parseRclonePath(rawPath string) (rcloneLocation, error) {
+	path := strings.TrimPrefix(rawPath, rcloneScheme)
+	if !strings.HasPrefix(rawPath, rcloneScheme) || !strings.Contains(path, ":") {
+		return rcloneLocation{}, fmt.Errorf("%s is not a valid rclone: path, like rclone:remote:path", rawPath)
+	}
+	return rcloneLocation{Path: strings.TrimSuffix(path, "/")}, nil
+}
+
+// remotePath returns the rclone path of the file, relative to the folder
+func (l rcloneLocation) remotePath(file string) string {
+	if strings.HasSuffix(l.Path, ":") {
+		return l.Path + file
+	}
+	return l.Path + "/" + file
+}
+
+// URL returns the rclone: path of the file, relative to the folder
+func (l rcloneLocation) URL(file string) string {
+	return rcloneScheme + l.remotePath(file)
+}
+
+// run runs rclone with the arguments, reading its input from stdin when it is not nil and writing its output
+// to stdout
+func (l rcloneLocation) run(stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(rcloneProgram, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// openRcloneBackend returns the backend of the files inside the rclone: path
+func openRcloneBackend(rawPath string) (storageBackend, error) {
+	return parseRclonePath(rawPath)
+}
+
+// List returns the paths of the files inside the folder, relative to it
+func (l rcloneLocation) List() ([]string, error) {
+	var output bytes.Buffer
+	if err := l.run(nil, &output, "lsf", "--recursive", "--files-only", "--format", "p", l.Path); err != nil {
+		return nil, fmt.Errorf("%s: %w", rcloneScheme+l.Path, err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(output.String(), "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Resolve returns the path relative to the folder, as the paths of its files are
+func (l rcloneLocation) Resolve(relativePath string) string {
+	return relativePath
+}
+
+// Open streams the content of the file through rclone
+func (l rcloneLocation) Open(file string) (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(l.run(nil, writer, "cat", l.remotePath(file)))
+	}()
+	return reader, nil
+}
+
+// Write uploads the content to the file through rclone
+func (l rcloneLocation) Write(file string, content []byte) error {
+	return l.run(bytes.NewReader(content), nil, "rcat", l.remotePath(file))
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseRclonePath(t *testing.T) {
+	location, err := parseRclonePath("rclone:b2-archive:photos/2024/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.Path != "b2-archive:photos/2024" {
+		t.Fatalf("unexpected location: %+v", location)
+	}
+	if url := location.URL("wedding.raw"); url != "rclone:b2-archive:photos/2024/wedding.raw" {
+		t.Fatalf("unexpected URL: %s", url)
+	}
+
+	location, err = parseRclonePath("rclone:gdrive:")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path := location.remotePath("budget.pdf"); path != "gdrive:budget.pdf" {
+		t.Fatalf("unexpected remote path: %s", path)
+	}
+
+	if _, err := parseRclonePath("rclone:photos"); err == nil {
+		t.Fatalf("expected error for a path without remote")
+	}
+}
+
+func TestRunRemoteCopyVerification(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake rclone is a shell script")
+	}
+
+	// The fake rclone serves the files of the remote "copy:" from a local directory
+	tempDir := t.TempDir()
+	copyDir := filepath.Join(tempDir, "copy")
+	binDir := filepath.Join(tempDir, "bin")
+	script := "#!/bin/sh\n[ \"$1\" = cat ] || exit 1\nexec cat \"" + copyDir + "/${2#copy:}\"\n"
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, rcloneProgram), []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake rclone: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	localDir := filepath.Join(tempDir, "photos")
+	for name, contents := range map[string][2]string{
+		"a.raw":     {"raw a", "raw a"},
+		"sub/b.raw": {"raw b", "corrupted"},
+	} {
+		localPath := filepath.Join(localDir, filepath.FromSlash(name))
+		copyPath := filepath.Join(copyDir, filepath.FromSlash(name))
+		for path, content := range map[string]string{localPath: contents[0], copyPath: contents[1]} {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+		}
+		if result := createChecksumFile(localPath); result.Status != Created {
+			t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+		}
+	}
+
+	remoteCopy = "rclone:copy:"
+	defer func() { remoteCopy = "" }()
+
+	var results []ChecksumFileVerificationResult
+	if err := runRemoteCopyVerification(localDir, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	if statuses["rclone:copy:a.raw"] != Match || statuses["rclone:copy:sub/b.raw"] != NotMatch || len(results) != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// readOnlyMode asserts that check does not write to the checked paths
+var readOnlyMode bool
+
+const readOnlyModeUsage = "refuse the options that modify the checked paths and require them to be on read-only mounts, verified again when the check finishes, for evidence and archival volumes where any write is unacceptable"
+
+// readOnlyPaths are the paths whose mounts were verified to be read-only at the start of the check
+var readOnlyPaths []string
+
+// validateReadOnlyOptions returns an error when --read-only is used with an option that modifies the checked paths
+func validateReadOnlyOptions() error {
+	if readOnlyMode && (relocateChecksumFiles || quarantineDirectory != "") {
+		return errors.New("--read-only cannot be used with --relocate or --quarantine, which modify the checked paths")
+	}
+	return nil
+}
+
+// verifyReadOnlyMounts returns an error when a path is not on a read-only mount. Remote paths are refused, as their
+// storage cannot be verified.
+func verifyReadOnlyMounts(paths []string) error {
+	for _, path := range paths {
+		if isRemoteURL(path) {
+			return fmt.Errorf("--read-only cannot verify that %s is not modified, use it only with local paths", path)
+		}
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		// The mount of a pattern is the one of the directory it is in
+		for hasGlobMeta(absolutePath) {
+			absolutePath = filepath.Dir(absolutePath)
+		}
+		readOnly, err := isReadOnlyMount(absolutePath)
+		if err != nil {
+			return fmt.Errorf("--read-only cannot verify the mount of %s: %w", absolutePath, err)
+		}
+		if !readOnly {
+			return fmt.Errorf("%s is on a writable mount, mount it read-only (like mount -o remount,ro) to check it with --read-only", absolutePath)
+		}
+	}
+	return nil
+}
+
+// startReadOnlyCheck verifies that the checked paths are on read-only mounts, the directory of the manifest when
+// --manifest is used without paths, or the current directory
+func startReadOnlyCheck(args []string) error {
+	if !readOnlyMode {
+		return nil
+	}
+	paths := args
+	if len(paths) == 0 {
+		paths = []string{"."}
+		if manifestPath != "" && !isURL(manifestPath) {
+			paths = []string{filepath.Dir(manifestPath)}
+		}
+	}
+	if err := verifyReadOnlyMounts(paths); err != nil {
+		return err
+	}
+	readOnlyPaths = paths
+	return nil
+}
+
+// finishReadOnlyCheck verifies that the mounts of the checked paths are still read-only, so a mount remounted
+// writable during the check is reported
+func finishReadOnlyCheck() error {
+	if len(readOnlyPaths) == 0 {
+		return nil
+	}
+	if err := verifyReadOnlyMounts(readOnlyPaths); err != nil {
+		return fmt.Errorf("the checked paths may have been modified during the check: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readOnlyMountPoint returns a mount point mounted read-only, or "" when there is none
+func readOnlyMountPoint() string {
+	mounts, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return ""
+	}
+	defer mounts.Close()
+
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && slices.Contains(strings.Split(fields[3], ","), "ro") && !strings.Contains(fields[1], `\`) {
+			if _, err := os.Stat(fields[1]); err == nil {
+				return fields[1]
+			}
+		}
+	}
+	return ""
+}
+
+func TestVerifyReadOnlyMounts(t *testing.T) {
+	if err := verifyReadOnlyMounts([]string{t.TempDir()}); err == nil {
+		t.Fatalf("expected an error for a writable mount")
+	}
+	if err := verifyReadOnlyMounts([]string{"sftp://backup@nas.local/volume1"}); err == nil {
+		t.Fatalf("expected an error for a remote path")
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	mountPoint := readOnlyMountPoint()
+	if mountPoint == "" {
+		t.Skip("no read-only mount to verify")
+	}
+	if err := verifyReadOnlyMounts([]string{mountPoint, mountPoint + "/*.raw"}); err != nil {
+		t.Fatalf("expected %s to be on a read-only mount, got %v", mountPoint, err)
+	}
+}
+
+func TestValidateReadOnlyOptions(t *testing.T) {
+	readOnlyMode = true
+	defer func() {
+		readOnlyMode = false
+		relocateChecksumFiles = false
+	}()
+
+	if err := validateReadOnlyOptions(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	relocateChecksumFiles = true
+	if err := validateReadOnlyOptions(); err == nil {
+		t.Fatalf("expected an error for --read-only with --relocate")
+	}
+}
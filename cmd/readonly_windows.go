@@ -0,0 +1,35 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "golang.org/x/sys/windows"
+
+// isReadOnlyMount reports whether the path is on a read-only volume
+func isReadOnlyMount(path string) (bool, error) {
+	pathPointer, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false, err
+	}
+	volumePath := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(pathPointer, &volumePath[0], uint32(len(volumePath))); err != nil {
+		return false, err
+	}
+	var flags uint32
+	if err := windows.GetVolumeInformation(&volumePath[0], nil, 0, nil, nil, &flags, nil, 0); err != nil {
+		return false, err
+	}
+	return flags&windows.FILE_READ_ONLY_VOLUME != 0, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// remoteCopy is set by --remote-copy in check and create
+var remoteCopy string
+
+// remoteCopyUsage is the usage of the --remote-copy flag
+const remoteCopyUsage = "verify the copy of the local directory in this path, like rclone:b2-archive:photos, b2://offsite-backup/photos or another local directory, against the checksum files of the local files; in create, store the checksums in the file info of the verified copies in B2"
+
+// remoteCopyLocation is the folder holding the copy of a local directory, given with --remote-copy
+type remoteCopyLocation interface {
+	// URL returns the URL of the copy of the file, relative to the folder
+	URL(file string) string
+	// checkCopy compares the copy of the file with the checksum of the local file
+	checkCopy(file string, localFileAbsolutePath string) ChecksumFileVerificationResult
+}
+
+// openRemoteCopy returns the location of the path given with --remote-copy: a b2:// path, or the path of any
+// of the backends, even a local directory
+func openRemoteCopy(rawPath string) (remoteCopyLocation, error) {
+	if strings.HasPrefix(rawPath, b2Scheme) {
+		return openB2Location(rawPath)
+	}
+	if strings.HasPrefix(rawPath, s3Scheme) && s3ETagVerification {
+		return openS3Copy(rawPath)
+	}
+
+	backend, err := openBackend(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	return backendCopy{backend}, nil
+}
+
+// backendCopy is the copy of a local directory in a backend, whose files are hashed to be verified
+type backendCopy struct {
+	storageBackend
+}
+
+// URL returns the URL of the copy of the file, relative to the root of the backend
+func (c backendCopy) URL(file string) string {
+	return c.storageBackend.URL(c.Resolve(file))
+}
+
+// checkCopy compares the copy of the local file with the checksum of the local file, streaming its content
+func (c backendCopy) checkCopy(file string, localFileAbsolutePath string) ChecksumFileVerificationResult {
+	fileURL := c.URL(file)
+
+	checksum, failed := localChecksumOfCopy(fileURL, localFileAbsolutePath)
+	if failed != nil {
+		return *failed
+	}
+
+	hexFileChecksum, err := hashBackendFile(c.storageBackend, c.Resolve(file))
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+
+	if strings.EqualFold(hexFileChecksum, checksum) {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: Match, Error: nil}
+	}
+
+	return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("the copy does not match the checksum of %s", localFileAbsolutePath)}
+}
+
+// splitRemoteArgs separates the URLs of the remote backends, like sftp://, s3://, dav://, davs:// and rclone:,
+// from the local paths
+func splitRemoteArgs(args []string) ([]string, []string) {
+	var localArgs, remoteArgs []string
+	for _, arg := range args {
+		if isRemoteURL(arg) {
+			remoteArgs = append(remoteArgs, arg)
+			continue
+		}
+		localArgs = append(localArgs, arg)
+	}
+
+	return localArgs, remoteArgs
+}
+
+// runRemoteVerification checks the checksum files of the files inside the remote URL
+func runRemoteVerification(rawURL string, results *[]ChecksumFileVerificationResult) error {
+	backend, err := openBackend(rawURL)
+	if err != nil {
+		return err
+	}
+
+	return runBackendVerification(backend, results)
+}
+
+// runRemoteCreation creates the checksum files of the files inside the remote URL
+func runRemoteCreation(rawURL string, results *[]ChecksumFileCreationResult) error {
+	if signChecksumFiles {
+		return fmt.Errorf("%s: --sign is not supported for remote paths", rawURL)
+	}
+	if strings.HasPrefix(rawURL, sftpScheme) {
+		return fmt.Errorf("%s: creating checksum files is not supported for sftp:// paths", rawURL)
+	}
+
+	backend, err := openBackend(rawURL)
+	if err != nil {
+		return err
+	}
+
+	return runBackendCreation(backend, results)
+}
+
+// runRemoteCopyVerification verifies the copy of the local directory in the path given with --remote-copy
+// against the checksums of the local files, so the cloud copy of an archive is verified with its local checksum files
+func runRemoteCopyVerification(directory string, results *[]ChecksumFileVerificationResult) error {
+	location, err := openRemoteCopy(remoteCopy)
+	if err != nil {
+		return err
+	}
+
+	return processLocalCopy(directory, &errorsCheckingChecksumFiles, func(file string, localFileAbsolutePath string) {
+		reportChecksumFileVerification(location.URL(file), results, func(string) ChecksumFileVerificationResult {
+			return location.checkCopy(file, localFileAbsolutePath)
+		})
+	})
+}
+
+// runRemoteCopyCreation stores the checksums of the local files of the directory in the file info of their copies
+// in the b2:// path given with --remote-copy
+func runRemoteCopyCreation(directory string, results *[]ChecksumFileCreationResult) error {
+	if !strings.HasPrefix(remoteCopy, b2Scheme) {
+		return fmt.Errorf("%s: --remote-copy in create is only supported for b2:// paths", remoteCopy)
+	}
+	location, err := openB2Location(remoteCopy)
+	if err != nil {
+		return err
+	}
+
+	return processLocalCopy(directory, &errorsCreatingChecksumFiles, func(file string, localFileAbsolutePath string) {
+		reportChecksumFileCreation(location.URL(file), results, func(string) ChecksumFileCreationResult {
+			return location.storeChecksumOfCopy(file, localFileAbsolutePath)
+		})
+	})
+}
+
+// processLocalCopy calls process with each file of the local directory, but the checksum files, and its path relative
+// to the directory with slashes, which is the path of its copy
+func processLocalCopy(directory string, errorsList *[]error, process func(file string, localFileAbsolutePath string)) error {
+	directoryAbsolutePath, err := filepath.Abs(directory)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(directoryAbsolutePath); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory, --remote-copy requires the directory of the copy", directory)
+	}
+
+	processPaths([]string{directoryAbsolutePath}, errorsList, func(filePath string) error {
+		if isChecksumFile(filePath) {
+			return nil
+		}
+		relativePath, err := filepath.Rel(directoryAbsolutePath, filePath)
+		if err != nil {
+			return err
+		}
+		process(filepath.ToSlash(relativePath), filePath)
+		return nil
+	})
+
+	return nil
+}
+
+// localChecksumOfCopy returns the stored checksum of the local file of a copy, or the result of the copy when
+// the local file has no valid checksum
+func localChecksumOfCopy(fileURL string, localFileAbsolutePath string) (string, *ChecksumFileVerificationResult) {
+	if stored, err := hasStoredChecksum(localFileAbsolutePath); err != nil {
+		return "", &ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	} else if !stored {
+		return "", &ChecksumFileVerificationResult{Path: fileURL, Status: NotFound, Error: nil}
+	}
+	content, err := readStoredChecksum(localFileAbsolutePath)
+	if err != nil {
+		return "", &ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+	checksum, _ := content.checksumOfFile(localFileAbsolutePath)
+	if err := validateChecksum(checksum, sha512Algorithm); err != nil {
+		return "", &ChecksumFileVerificationResult{Path: fileURL, Status: Malformed, Error: err}
+	}
+
+	return checksum, nil
+}
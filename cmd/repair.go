@@ -0,0 +1,470 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errorsRepairingChecksumFiles []error
+var resultsRepairingChecksumFiles []ChecksumFileRepairResult
+
+var assumeModified bool
+
+// repairCmd represents the repair command
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Regenerate not matching checksum files.",
+	Long: `Check the files and, for the ones whose checksum does not match their checksum file, regenerate the checksum file
+after confirming that the change of the file was intentional. Use --assume-modified to regenerate them without asking.
+With --par2, the files with PAR2 recovery data are restored from it first.
+
+Example:
+  checksum-utils repair ./work
+  checksum-utils repair --assume-modified ~/documents/budget.xlsx
+  checksum-utils repair --par2 ~/photos
+`,
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completePaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		printHeader()
+
+		if signChecksumFiles {
+			if err := ensureGPG(); err != nil {
+				errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, err)
+				printErrorsRepairingChecksumFiles()
+				return
+			}
+		}
+
+		if repairWithPar2 {
+			if err := ensurePar2(); err != nil {
+				errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, err)
+				printErrorsRepairingChecksumFiles()
+				return
+			}
+		}
+
+		paths, expandErrors, _ := gatherPaths(args)
+		errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, expandErrors...)
+		if len(paths) == 0 {
+			printErrorsRepairingChecksumFiles()
+			return
+		}
+
+		confirm := confirmRepair
+		if assumeModified {
+			confirm = func(string) bool { return true }
+		} else if !isStdinTTY() {
+			errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, errors.New("stdin is not a terminal, the checksum files can only be regenerated with --assume-modified"))
+			confirm = func(string) bool { return false }
+		}
+
+		fmt.Println()
+		fmt.Printf("Processing %d paths\n", len(paths))
+
+		resultsRepairingChecksumFiles = []ChecksumFileRepairResult{}
+		processPaths(paths, &errorsRepairingChecksumFiles, func(filePath string) error {
+			return handleChecksumFileRepair(filePath, &resultsRepairingChecksumFiles, confirm)
+		})
+
+		printResultsRepairingChecksumFiles(resultsRepairingChecksumFiles)
+		printErrorsRepairingChecksumFiles()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+
+	repairCmd.Flags().StringVar(&checksumFileExtension, "suffix", checksumFileExtension, checksumFileExtensionUsage)
+	repairCmd.Flags().StringVar(&storeDirectory, "store-dir", "", storeDirectoryUsage)
+	repairCmd.Flags().StringVar(&storeMode, "store", sidecarStoreMode, storeModeUsage)
+	repairCmd.Flags().BoolVar(&hiddenSidecars, "hidden-sidecar", false, hiddenSidecarsUsage)
+	repairCmd.Flags().StringVar(&digestEncoding, "encoding", hexEncoding, digestEncodingUsage)
+	repairCmd.Flags().StringVar(&sidecarFormat, "sidecar-format", bareSidecarFormat, sidecarFormatUsage)
+	repairCmd.Flags().BoolVar(&matchModTime, "match-mtime", false, matchModTimeUsage)
+	repairCmd.Flags().BoolVar(&appendNewline, "newline", false, appendNewlineUsage)
+	repairCmd.Flags().BoolVar(&writeHeader, "header", false, writeHeaderUsage)
+	repairCmd.Flags().BoolVar(&recordFileSize, "record-size", false, "also record the size and the modification time of the files in their regenerated checksum files")
+	repairCmd.Flags().BoolVar(&assumeModified, "assume-modified", false, "regenerate the not matching checksum files without asking, assuming that the files were modified intentionally")
+	repairCmd.Flags().Var(&bufferSize, "buffer-size", bufferSizeUsage)
+	repairCmd.Flags().IntVar(&readRetries, "retries", readRetries, readRetriesUsage)
+	repairCmd.Flags().DurationVar(&readRetryDelay, "retry-delay", readRetryDelay, readRetryDelayUsage)
+	repairCmd.Flags().IntVar(&unstableRetries, "unstable-retries", 0, unstableRetriesUsage)
+	repairCmd.Flags().Var(&selectedIOEngine, "io-engine", ioEngineUsage)
+	repairCmd.Flags().Var(&maxThroughput, "max-throughput", maxThroughputUsage)
+	repairCmd.Flags().BoolVar(&runInBackground, "background", false, backgroundUsage)
+	repairCmd.Flags().BoolVar(&signChecksumFiles, "sign", false, "create a new detached GPG signature (.sha512.asc) for each regenerated checksum file")
+	repairCmd.Flags().StringVar(&signingKey, "sign-key", "", "GPG key used by --sign instead of the default secret key")
+	repairCmd.Flags().BoolVar(&repairWithPar2, "par2", false, "restore the corrupted files that have PAR2 recovery data (.par2) instead of regenerating their checksum file")
+	repairCmd.Flags().IntVar(&par2Redundancy, "par2-redundancy", 10, "percentage of each file that its new PAR2 recovery data is able to restore")
+}
+
+type ChecksumFileRepairStatus string
+
+const (
+	Repaired       ChecksumFileRepairStatus = "Repaired"
+	Restored       ChecksumFileRepairStatus = "Restored"
+	KeptNotMatch   ChecksumFileRepairStatus = "Kept"
+	MatchRepair    ChecksumFileRepairStatus = "Match"
+	NotFoundRepair ChecksumFileRepairStatus = "NotFound"
+	FailedRepair   ChecksumFileRepairStatus = "Failed"
+	LockedRepair   ChecksumFileRepairStatus = "Locked"
+	SpecialRepair  ChecksumFileRepairStatus = "Special"
+	UnstableRepair ChecksumFileRepairStatus = "Unstable"
+)
+
+type ChecksumFileRepairResult struct {
+	Path   string
+	Status ChecksumFileRepairStatus
+	Error  error
+}
+
+// confirmationReader reads the answers of the user to the confirmation questions
+var confirmationReader = bufio.NewReader(os.Stdin)
+
+// confirmRepair asks the user whether the change of the file was intentional
+func confirmRepair(fileAbsolutePath string) bool {
+	fmt.Printf("  %s does not match its checksum file. Was it modified intentionally? Regenerate the checksum file [y/N]: ", filepath.Base(fileAbsolutePath))
+
+	answer, err := confirmationReader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func handleChecksumFileRepair(filePath string, results *[]ChecksumFileRepairResult, confirm func(string) bool) error {
+	return handleChecksumFileRepairWith(filePath, results, confirm, nil)
+}
+
+// handleChecksumFileRepairWith repairs the checksum file of the file, reading its content through wrap when it is not
+// nil, so the reads can be observed or controlled
+func handleChecksumFileRepairWith(filePath string, results *[]ChecksumFileRepairResult, confirm func(string) bool, wrap func(io.Reader) io.Reader) error {
+	fileAbsolutePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	if isChecksumFile(fileAbsolutePath) {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("- %s ", fileAbsolutePath)
+	spinner := startProgress(prefix)
+	start := time.Now()
+	// A file being written is hashed again instead of being reported as a file that does not match
+	verification := retryUnstable(func() ChecksumFileVerificationResult {
+		return checkChecksumFileWith(fileAbsolutePath, wrap)
+	}, func(result ChecksumFileVerificationResult) bool {
+		return result.Status == UnstableVerification
+	})
+	elapsed := time.Since(start)
+	spinner.Stop()
+
+	if spinner.Enabled() {
+		clearProgressLine(prefix)
+	} else {
+		fmt.Print(prefix)
+	}
+
+	var result ChecksumFileRepairResult
+	switch verification.Status {
+	case Match:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: MatchRepair, Error: nil}
+		fmt.Printf("✅ (%s)\n", formatDuration(elapsed))
+	case NotFound:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: NotFoundRepair, Error: nil}
+		fmt.Println("👻")
+	case LockedVerification:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: LockedRepair, Error: verification.Error}
+		fmt.Println("🔒")
+	case CheckingFailed:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: verification.Error}
+		fmt.Printf("❌ (%s)\n", formatDuration(elapsed))
+	case SpecialVerification:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: SpecialRepair, Error: verification.Error}
+		fmt.Println("🔌")
+	case UnstableVerification:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: UnstableRepair, Error: verification.Error}
+		fmt.Printf("🌀 (%s)\n", formatDuration(elapsed))
+	case Malformed:
+		fmt.Println("🧩")
+		if confirm(fileAbsolutePath) {
+			result = repairChecksumFile(fileAbsolutePath)
+		} else {
+			result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: verification.Error}
+		}
+		if result.Status == Repaired {
+			fmt.Println("  🔧 checksum file regenerated")
+		}
+	case NotMatch:
+		fmt.Printf("⚠️ (%s)\n", formatDuration(elapsed))
+		if repairWithPar2 && hasPar2(fileAbsolutePath) {
+			if err := restoreFromPar2(fileAbsolutePath); err != nil {
+				errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, err)
+			} else {
+				fmt.Println("  🩹 file restored from its PAR2 recovery data")
+				*results = append(*results, ChecksumFileRepairResult{Path: fileAbsolutePath, Status: Restored, Error: nil})
+				return nil
+			}
+		}
+		if confirm(fileAbsolutePath) {
+			result = repairChecksumFile(fileAbsolutePath)
+		} else {
+			result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: KeptNotMatch, Error: nil}
+		}
+		if result.Status == Repaired {
+			fmt.Println("  🔧 checksum file regenerated")
+		}
+	default:
+		result = ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: fmt.Errorf("%s: %v", verification.Status, verification.Error)}
+		fmt.Printf("❌ (%s)\n", formatDuration(elapsed))
+	}
+
+	*results = append(*results, result)
+
+	return nil
+}
+
+// restoreFromPar2 restores the file from its PAR2 recovery data and verifies that it matches its checksum file again
+func restoreFromPar2(fileAbsolutePath string) error {
+	if err := restoreWithPar2(fileAbsolutePath, par2Redundancy); err != nil {
+		return fmt.Errorf("%s: %w", fileAbsolutePath, err)
+	}
+
+	if verification := checkChecksumFile(fileAbsolutePath); verification.Status != Match {
+		return fmt.Errorf("%s was restored from its PAR2 recovery data but still does not match its checksum file", fileAbsolutePath)
+	}
+
+	return nil
+}
+
+// repairChecksumFile replaces the checksum file of the file with its current checksum,
+// and signs it again when --sign is used
+func repairChecksumFile(fileAbsolutePath string) ChecksumFileRepairResult {
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		if os.IsPermission(err) {
+			return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: LockedRepair, Error: err}
+		}
+		return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: err}
+	}
+	defer file.Close()
+
+	hexFileChecksum, err := hashReader(file)
+	if err != nil {
+		return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: err}
+	}
+
+	if err := replaceStoredChecksum(fileAbsolutePath, hexFileChecksum); err != nil {
+		return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: err}
+	}
+
+	checksumFile := checksumFilePath(fileAbsolutePath)
+	if signChecksumFiles {
+		if err := signFile(checksumFile, signingKey); err != nil {
+			return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: FailedRepair, Error: err}
+		}
+	} else if _, err := os.Stat(checksumFile + signatureExtension); err == nil {
+		errorsRepairingChecksumFiles = append(errorsRepairingChecksumFiles, fmt.Errorf("the signature of %s is no longer valid, repair it with --sign", checksumFile))
+	}
+
+	return ChecksumFileRepairResult{Path: fileAbsolutePath, Status: Repaired, Error: nil}
+}
+
+// replaceStoredChecksum stores the new checksum of the file, for repair and for create with --force or --update-stale.
+// When its checksum file lists several files, only the line of the file is rewritten, so the checksums of the other
+// files are kept.
+func replaceStoredChecksum(fileAbsolutePath string, hexFileChecksum string) error {
+	if storeMode == xattrStoreMode {
+		return writeChecksumFile(fileAbsolutePath, hexFileChecksum)
+	}
+
+	checksumFile := checksumFilePath(fileAbsolutePath)
+	content, err := os.ReadFile(checksumFile)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(parseChecksumFile(string(content)).Entries) <= 1) {
+		return writeChecksumFile(fileAbsolutePath, hexFileChecksum)
+	}
+	if err != nil {
+		return err
+	}
+
+	rewritten, found := replaceListedChecksum(string(content), filepath.Base(fileAbsolutePath), encodeDigest(hexFileChecksum))
+	if !found {
+		return fmt.Errorf("%s lists several files but not %s", checksumFile, filepath.Base(fileAbsolutePath))
+	}
+	return replaceChecksumFile(checksumFile, rewritten)
+}
+
+// replaceListedChecksum replaces the checksum of the "<checksum>  <file>" line of the file in the content of a checksum
+// file that lists several files, keeping the other lines as they are, and reports whether the file is listed
+func replaceListedChecksum(content string, fileName string, checksum string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	found := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		escaped := strings.HasPrefix(trimmed, `\`)
+		trimmed = strings.TrimPrefix(trimmed, `\`)
+		separator := strings.IndexAny(trimmed, " \t")
+		if separator < 0 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimLeft(trimmed[separator:], " \t"), "*")
+		if escaped {
+			name = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(name)
+		}
+		if name != fileName {
+			continue
+		}
+
+		// The line ending of the line is kept, so a checksum file edited on other systems keeps its own
+		ending := line[len(strings.TrimRight(line, "\r\n")):]
+		lines[i] = strings.TrimSuffix(coreutilsChecksumLine(checksum, fileName), "\n") + ending
+		found = true
+	}
+	return strings.Join(lines, ""), found
+}
+
+func printResultsRepairingChecksumFiles(results []ChecksumFileRepairResult) {
+	if len(results) > 0 {
+		fmt.Println("Results:", len(results), "files processed")
+	}
+
+	var matchQuantity = 0
+	var notFoundQuantity = 0
+	var repairedResults []ChecksumFileRepairResult
+	var restoredResults []ChecksumFileRepairResult
+	var notRepairedResults []ChecksumFileRepairResult
+	var lockedResults []ChecksumFileRepairResult
+	var failedResults []ChecksumFileRepairResult
+	var specialResults []ChecksumFileRepairResult
+	var unstableResults []ChecksumFileRepairResult
+
+	for _, result := range results {
+		switch result.Status {
+		case MatchRepair:
+			matchQuantity++
+		case NotFoundRepair:
+			notFoundQuantity++
+		case Repaired:
+			repairedResults = append(repairedResults, result)
+		case Restored:
+			restoredResults = append(restoredResults, result)
+		case KeptNotMatch:
+			notRepairedResults = append(notRepairedResults, result)
+		case LockedRepair:
+			lockedResults = append(lockedResults, result)
+		case FailedRepair:
+			failedResults = append(failedResults, result)
+		case SpecialRepair:
+			specialResults = append(specialResults, result)
+		case UnstableRepair:
+			unstableResults = append(unstableResults, result)
+		}
+	}
+
+	if matchQuantity > 0 {
+		fmt.Println("✅ :", matchQuantity, "checksum files match")
+	}
+
+	if notFoundQuantity > 0 {
+		fmt.Println("👻 :", notFoundQuantity, "files without a checksum file")
+	}
+
+	if len(repairedResults) > 0 {
+		fmt.Println("🔧 :", len(repairedResults), "checksum files regenerated")
+		for _, repairedResult := range repairedResults {
+			fmt.Print("- ", repairedResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(restoredResults) > 0 {
+		fmt.Println("🩹 :", len(restoredResults), "files restored from their PAR2 recovery data")
+		for _, restoredResult := range restoredResults {
+			fmt.Print("- ", restoredResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(notRepairedResults) > 0 {
+		fmt.Println("⚠️ :", len(notRepairedResults), "checksum files not match and were kept")
+		for _, notRepairedResult := range notRepairedResults {
+			fmt.Print("- ", notRepairedResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(lockedResults) > 0 {
+		fmt.Println("🔒 :", len(lockedResults), "files could not be read due to permissions")
+		for _, lockedResult := range lockedResults {
+			fmt.Print("- ", lockedResult.Path)
+			fmt.Println()
+		}
+	}
+
+	if len(specialResults) > 0 {
+		fmt.Println("🔌 :", len(specialResults), "special files skipped")
+		for _, specialResult := range specialResults {
+			fmt.Print("- ", specialResult.Path, " (", specialResult.Error, ")")
+			fmt.Println()
+		}
+	}
+
+	if len(unstableResults) > 0 {
+		fmt.Println("🌀 :", len(unstableResults), "files modified while they were hashed, their checksum files were kept")
+		for _, unstableResult := range unstableResults {
+			fmt.Print("- ", unstableResult.Path, " | ", unstableResult.Error)
+			fmt.Println()
+		}
+	}
+
+	if len(failedResults) > 0 {
+		fmt.Println("❌ :", len(failedResults), "checksum files failed to repair")
+		for _, failedResult := range failedResults {
+			fmt.Print("- ", failedResult.Path, " | Error: ", failedResult.Error)
+			fmt.Println()
+		}
+	}
+}
+
+func printErrorsRepairingChecksumFiles() {
+	if len(errorsRepairingChecksumFiles) > 0 {
+		fmt.Println()
+		fmt.Println("Errors:")
+
+		for _, error := range errorsRepairingChecksumFiles {
+			fmt.Println("- ", error)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRepairChecksumFile(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+
+	if err := os.WriteFile(filePath, []byte("hello, modified"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := checkChecksumFile(filePath); result.Status != NotMatch {
+		t.Fatalf("expected status %s, got %s", NotMatch, result.Status)
+	}
+
+	result := repairChecksumFile(filePath)
+	if result.Status != Repaired {
+		t.Fatalf("expected status %s, got %s (%v)", Repaired, result.Status, result.Error)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s after repairing, got %s", Match, result.Status)
+	}
+}
+
+func TestHandleChecksumFileRepair_Declined(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filePath+".sha512", []byte(strings.Repeat("0", 128)), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	var results []ChecksumFileRepairResult
+	if err := handleChecksumFileRepair(filePath, &results, func(string) bool { return false }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Status != KeptNotMatch {
+		t.Fatalf("expected status %s, got %+v", KeptNotMatch, results)
+	}
+
+	checksumBytes, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	if string(checksumBytes) != strings.Repeat("0", 128) {
+		t.Fatalf("checksum file should not be overwritten")
+	}
+}
+
+func TestHandleChecksumFileRepair_Par2(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake par2 program is a shell script")
+	}
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+	data := []byte("hello")
+
+	// The fake par2 restores the file from a copy kept as its recovery data
+	fakePar2 := filepath.Join(tempDir, "par2")
+	script := "#!/bin/sh\nif [ \"$1\" = repair ]; then cp data.txt.par2 data.txt; fi\n"
+	if err := os.WriteFile(fakePar2, []byte(script), 0o700); err != nil {
+		t.Fatalf("write fake par2: %v", err)
+	}
+	par2Program = fakePar2
+	repairWithPar2 = true
+	defer func() {
+		par2Program = "par2"
+		repairWithPar2 = false
+	}()
+
+	hash := sha512.Sum512(data)
+	if err := os.WriteFile(filePath+".sha512", []byte(hex.EncodeToString(hash[:])), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	if err := os.WriteFile(filePath+".par2", data, 0o600); err != nil {
+		t.Fatalf("write recovery data: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("hellO"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var results []ChecksumFileRepairResult
+	if err := handleChecksumFileRepair(filePath, &results, func(string) bool { return false }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Status != Restored {
+		t.Fatalf("expected status %s, got %+v (%v)", Restored, results, errorsRepairingChecksumFiles)
+	}
+	if content, err := os.ReadFile(filePath); err != nil || string(content) != string(data) {
+		t.Fatalf("expected the file to be restored, got %q (%v)", content, err)
+	}
+}
+
+func TestRepairChecksumFile_MultiEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "a")
+	otherFilePath := filepath.Join(tempDir, "b")
+
+	if err := os.WriteFile(otherFilePath, []byte("other"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("modified"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	otherHash := sha512.Sum512([]byte("other"))
+	content := "# listed together\r\n" + strings.Repeat("0", 128) + "  a\r\n" + hex.EncodeToString(otherHash[:]) + "  b\r\n"
+	if err := os.WriteFile(filePath+".sha512", []byte(content), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	result := repairChecksumFile(filePath)
+	if result.Status != Repaired {
+		t.Fatalf("expected status %s, got %s (%v)", Repaired, result.Status, result.Error)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s after repairing, got %s (%v)", Match, result.Status, result.Error)
+	}
+	repaired, err := os.ReadFile(filePath + ".sha512")
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	if !strings.HasPrefix(string(repaired), "# listed together\r\n") || !strings.HasSuffix(string(repaired), hex.EncodeToString(otherHash[:])+"  b\r\n") {
+		t.Fatalf("expected the other lines to be kept, got %q", repaired)
+	}
+
+	var results []ChecksumFileVerificationResult
+	if !checkListedFiles(filePath+".sha512", &results) {
+		t.Fatalf("expected the checksum file to list several files")
+	}
+	if len(results) != 1 || results[0].Path != otherFilePath || results[0].Status != Match {
+		t.Fatalf("expected %s to still match, got %+v", otherFilePath, results)
+	}
+}
@@ -17,12 +17,19 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -35,6 +42,34 @@ var (
 
 const progressBarWidth = 10
 
+// jobs is the number of files processed at the same time
+var jobs int
+
+// hddMode reads the files one at a time, sorted by directory and inode, so the heads of hard disks do not thrash
+var hddMode bool
+
+// hddModeUsage is the usage of the --hdd flag of the commands that hash files
+const hddModeUsage = "read the files one at a time sorted by directory and inode, for hard disks; --jobs only walks the directories"
+
+// oneFileSystem keeps the walks on the file system of each directory, without entering other mounts
+var oneFileSystem bool
+
+// oneFileSystemUsage is the usage of the --one-file-system flag of the commands that walk directories
+const oneFileSystemUsage = "do not walk into directories on other file systems, like other mounts, network shares or /proc"
+
+// outputMutex serializes the output and the updates of the results when several files are processed at the same time
+var outputMutex sync.Mutex
+
+// checksumFileExtension is the extension of the checksum files created next to the data files, changed with --suffix
+var checksumFileExtension = ".sha512"
+
+const checksumFileExtensionUsage = "extension of the checksum files created next to the files, like .checksum"
+
+// hiddenSidecars names the checksum files like dotfiles, .data.txt.sha512 for data.txt, hidden on Windows too
+var hiddenSidecars bool
+
+const hiddenSidecarsUsage = "name the checksum files like dotfiles, .data.txt.sha512 for data.txt, and hide them on Windows too"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "checksum-utils",
@@ -42,6 +77,44 @@ var rootCmd = &cobra.Command{
 	Short:   "Multiplatform checksum utils.",
 	Long: `A multiplatform checksum utils for NAS admins.
 `,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd, configPath, cmd.Flags().Changed("config")); err != nil {
+			return err
+		}
+
+		if storeDirectory != "" {
+			storeAbsolutePath, err := filepath.Abs(storeDirectory)
+			if err != nil {
+				return err
+			}
+			storeDirectory = storeAbsolutePath
+		}
+
+		if digestEncoding != hexEncoding && digestEncoding != upperHexEncoding && digestEncoding != base64Encoding {
+			return fmt.Errorf("--encoding must be %s, %s or %s, not %q", hexEncoding, upperHexEncoding, base64Encoding, digestEncoding)
+		}
+
+		if sidecarFormat != bareSidecarFormat && sidecarFormat != coreutilsSidecarFormat {
+			return fmt.Errorf("--sidecar-format must be %s or %s, not %q", bareSidecarFormat, coreutilsSidecarFormat, sidecarFormat)
+		}
+
+		if err := validateStoreMode(); err != nil {
+			return err
+		}
+
+		if err := validateLockScope(); err != nil {
+			return err
+		}
+
+		if checksumFileExtension == "" || strings.ContainsAny(checksumFileExtension, `/\`) {
+			return fmt.Errorf("--suffix must be a file extension like .checksum, not %q", checksumFileExtension)
+		}
+
+		if runInBackground {
+			return enterBackgroundMode()
+		}
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -54,6 +127,8 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", defaultConfigPath(), "configuration file with the default values of the flags, one \"name = value\" per line")
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
@@ -61,12 +136,26 @@ func init() {
 		<-c
 		fmt.Println()
 
+		// Only check runs with a start time
+		if !checkStartedAt.IsZero() {
+			flushInterruptedCheck()
+		}
+
 		printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
 		printErrorsCheckingChecksumFiles()
 
 		printResultsCreatingChecksumFiles(resultsCreatingChecksumFiles)
 		printErrorsCreatingChecksumFiles()
 
+		printResultsUpdatingCatalog(resultsUpdatingCatalog)
+		printErrorsUpdatingCatalog()
+
+		printResultsAuditingFiles(resultsAuditingFiles)
+		printErrorsAuditingFiles()
+
+		printResultsRepairingChecksumFiles(resultsRepairingChecksumFiles)
+		printErrorsRepairingChecksumFiles()
+
 		os.Exit(1)
 	}()
 }
@@ -176,13 +265,13 @@ func readPathsFromStdin() ([]string, error) {
 	}
 
 	var paths []string
-	scanner := bufio.NewScanner(os.Stdin)
+	scanner := newPathScanner(os.Stdin)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		line := pathEntry(scanner.Text())
 		if line == "" {
 			continue
 		}
-		if strings.HasSuffix(line, ".sha512") {
+		if isChecksumFile(line) {
 			continue
 		}
 		paths = append(paths, line)
@@ -220,12 +309,72 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dms", rounded.Milliseconds())
 }
 
+// byteSize is a number of bytes given as a flag, like 4MiB, 80MB or 1048576
+type byteSize int64
+
+func (s *byteSize) String() string {
+	if *s == 0 {
+		return "0"
+	}
+	return formatBytes(int64(*s))
+}
+
+func (s *byteSize) Set(value string) error {
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = byteSize(size)
+	return nil
+}
+
+func (s *byteSize) Type() string {
+	return "size"
+}
+
+// sizeUnits are the multipliers of the size units, decimal (KB) and binary (K, KiB) like GNU coreutils
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+}
+
+// parseSize parses a positive size like 4MiB, 1.5G, 80MB or 1048576
+func parseSize(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	unitStart := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if unitStart == -1 {
+		unitStart = len(trimmed)
+	}
+
+	number, err := strconv.ParseFloat(trimmed[:unitStart], 64)
+	multiplier, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[unitStart:]))]
+	if err != nil || !ok || number <= 0 {
+		return 0, fmt.Errorf("%q is not a valid size, use a number with an optional unit like 4MiB or 80MB", value)
+	}
+
+	return int64(number * multiplier), nil
+}
+
 func expandArgs(args []string) ([]string, []error, bool) {
 	var expanded []string
 	var errs []error
 	hadGlob := false
 
 	for _, arg := range args {
+		if strings.HasPrefix(arg, smbScheme) {
+			path, err := resolveSMBURL(arg)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			arg = path
+		}
+
 		if hasGlobMeta(arg) {
 			hadGlob = true
 			matches, err := filepath.Glob(arg)
@@ -246,10 +395,93 @@ func expandArgs(args []string) ([]string, []error, bool) {
 	return expanded, errs, hadGlob
 }
 
+// filesFrom is the file listing the paths to process, one per line, or - for stdin
+var filesFrom string
+
+const filesFromUsage = "process the paths listed in this file, one per line, or in stdin with -, reporting the missing ones"
+
+// nullSeparated reads the paths of stdin and --files-from separated by NUL characters, like the output of
+// find -print0, so paths with newlines or leading and trailing spaces are read as they are
+var nullSeparated bool
+
+const nullSeparatedUsage = "the paths of stdin and --files-from are separated by NUL characters, like the output of find -print0"
+
+// newPathScanner returns a scanner of the paths of the reader, one per line or separated by NUL characters with --null
+func newPathScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	if nullSeparated {
+		scanner.Split(scanNullSeparated)
+	}
+	return scanner
+}
+
+// scanNullSeparated is a bufio.SplitFunc returning the entries separated by NUL characters
+func scanNullSeparated(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// pathEntry returns the path of a scanned entry, trimming the spaces of the lines but not of the NUL separated entries
+func pathEntry(entry string) string {
+	if nullSeparated {
+		return entry
+	}
+	return strings.TrimSpace(entry)
+}
+
+// readPathsFromList reads the paths listed in the file, or in stdin when it is -, one per entry, skipping empty entries
+// and checksum files. The paths that do not exist are reported with their line instead of being returned.
+func readPathsFromList(listPath string) ([]string, []error) {
+	listName := listPath
+	var reader io.Reader = os.Stdin
+	if listPath == "-" {
+		listName = "stdin"
+	} else {
+		file, err := os.Open(listPath)
+		if err != nil {
+			return nil, []error{err}
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var paths []string
+	var errs []error
+	scanner := newPathScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := pathEntry(scanner.Text())
+		if line == "" || isChecksumFile(line) {
+			continue
+		}
+		if _, err := os.Lstat(line); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", listName, lineNumber, err))
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", listName, err))
+	}
+
+	return paths, errs
+}
+
 func gatherPaths(args []string) ([]string, []error, bool) {
 	paths, errs, hadGlob := expandArgs(args)
 	var readErr error
 
+	if filesFrom != "" {
+		listedPaths, listErrors := readPathsFromList(filesFrom)
+		paths = append(paths, listedPaths...)
+		errs = append(errs, listErrors...)
+		return paths, errs, true
+	}
+
 	if len(args) == 0 || !isStdinTTY() {
 		stdinPaths, err := readPathsFromStdin()
 		if err != nil {
@@ -268,59 +500,388 @@ func gatherPaths(args []string) ([]string, []error, bool) {
 	return paths, errs, hadGlob
 }
 
+// isChecksumFile reports whether the path is a checksum file, the signature of one or PAR2 recovery data,
+// files that hold integrity data and must not be processed as data files. The extensions are recognized
+// in any case, since the files copied from Windows or FAT media often have uppercase extensions
+func isChecksumFile(path string) bool {
+	return hasSuffixFold(path, checksumFileExtension) || hasSuffixFold(path, checksumFileExtension+signatureExtension) || hasSuffixFold(path, archiveManifestExtension) || hasSuffixFold(path, par2Extension) || hasSuffixFold(path, multiHashExtension) || isTagManifest(path)
+}
+
+// hasSuffixFold reports whether the path ends with the suffix, ignoring the case
+func hasSuffixFold(path string, suffix string) bool {
+	return len(path) >= len(suffix) && strings.EqualFold(path[len(path)-len(suffix):], suffix)
+}
+
+// trimChecksumFileExtension returns the path without the extension of the checksum files, in any case, and without
+// the leading dot of the checksum files named with --hidden-sidecar, which is the path of the file they protect
+func trimChecksumFileExtension(path string) string {
+	if !hasSuffixFold(path, checksumFileExtension) {
+		return path
+	}
+
+	path = path[:len(path)-len(checksumFileExtension)]
+	if hiddenSidecars {
+		directory, name := filepath.Split(path)
+		if strings.HasPrefix(name, ".") {
+			return directory + name[1:]
+		}
+	}
+	return path
+}
+
+// checksumFilePath returns the path of the checksum file of the file: the one in the store directory with
+// --store-dir, its alternate data stream with --store ads, or else the one next to it, named like a dotfile with
+// --hidden-sidecar, with the extension in lowercase, or in uppercase or with the name in another Unicode normalization
+// form when only that one exists
+func checksumFilePath(fileAbsolutePath string) string {
+	if storeDirectory != "" {
+		return storedChecksumFilePath(fileAbsolutePath)
+	}
+	if storeMode == adsStoreMode {
+		return adsChecksumFilePath(fileAbsolutePath)
+	}
+	if hiddenSidecars {
+		fileAbsolutePath = filepath.Join(filepath.Dir(fileAbsolutePath), "."+filepath.Base(fileAbsolutePath))
+	}
+
+	lowercasePath := fileAbsolutePath + checksumFileExtension
+	if _, err := os.Lstat(lowercasePath); errors.Is(err, os.ErrNotExist) {
+		uppercasePath := fileAbsolutePath + strings.ToUpper(checksumFileExtension)
+		if _, err := os.Lstat(uppercasePath); err == nil {
+			return uppercasePath
+		}
+		if variant, found := normalizationVariant(lowercasePath); found {
+			return variant
+		}
+	}
+	return lowercasePath
+}
+
 func hasGlobMeta(path string) bool {
 	return strings.ContainsAny(path, "*?[")
 }
 
 func processPaths(paths []string, errorsList *[]error, handler func(string) error) {
+	if hddMode {
+		// Collect every file first, walking with several walkers when --jobs is used, so they can be read
+		// one at a time sorted by their position on the disk
+		var files []string
+		walkPaths(paths, errorsList, func(filePath string) error {
+			appendLocked(&files, filePath)
+			return nil
+		})
+		sortForSequentialReads(files)
+		for _, filePath := range files {
+			if err := handler(filePath); errors.Is(err, filepath.SkipAll) {
+				return
+			} else if err != nil {
+				appendLocked(errorsList, err)
+			}
+		}
+		return
+	}
+
+	// With several jobs, the walk feeds the files to workers and the errors of the handler no longer stop it,
+	// except filepath.SkipAll, which stops the walk
+	run := handler
+	if jobs > 1 {
+		files := make(chan string)
+		var stopped atomic.Bool
+		var workers sync.WaitGroup
+		for range jobs {
+			workers.Go(func() {
+				for filePath := range files {
+					if stopped.Load() {
+						continue
+					}
+					if err := handler(filePath); errors.Is(err, filepath.SkipAll) {
+						stopped.Store(true)
+					} else if err != nil {
+						appendLocked(errorsList, err)
+					}
+				}
+			})
+		}
+		defer func() {
+			close(files)
+			workers.Wait()
+		}()
+
+		run = func(filePath string) error {
+			if stopped.Load() {
+				return filepath.SkipAll
+			}
+			files <- filePath
+			return nil
+		}
+	}
+
+	walkPaths(paths, errorsList, run)
+}
+
+// walkPaths calls run for each file of the paths, walking the directories
+func walkPaths(paths []string, errorsList *[]error, run func(string) error) {
 	for _, path := range paths {
 		argFileInfo, err := os.Stat(path)
 		if err != nil {
-			*errorsList = append(*errorsList, err)
+			appendLocked(errorsList, err)
 			continue
 		}
 
 		if argFileInfo.IsDir() {
 			directoryAbsolutePath, err := filepath.Abs(path)
 			if err != nil {
-				*errorsList = append(*errorsList, err)
+				appendLocked(errorsList, err)
 				continue
 			}
 
-			if err := filepath.Walk(directoryAbsolutePath, func(filePath string, fileInfo os.FileInfo, err error) error {
-				if err != nil {
-					*errorsList = append(*errorsList, err)
-					fmt.Println("Error: ", err)
-					return err
+			if jobs > 1 {
+				if stopped := walkConcurrently(directoryAbsolutePath, argFileInfo, jobs, errorsList, run); stopped {
+					return
 				}
+				continue
+			}
 
-				if fileInfo.IsDir() {
-					return nil
-				}
+			// A symlink given as argument is walked like the directory it points to
+			target, err := filepath.EvalSymlinks(directoryAbsolutePath)
+			if err != nil {
+				appendLocked(errorsList, err)
+				continue
+			}
 
-				return handler(filePath)
-			}); err != nil {
-				*errorsList = append(*errorsList, err)
-				fmt.Println("Error: ", err)
+			visited := newVisitedDirectories()
+			stopped := walkDirectory(directoryAbsolutePath, target, directoryAbsolutePath, argFileInfo, visited, errorsList, run)
+			if stopped {
+				return
 			}
 			continue
 		}
 
 		fileAbsolutePath, err := filepath.Abs(path)
 		if err != nil {
-			*errorsList = append(*errorsList, err)
+			appendLocked(errorsList, err)
 			continue
 		}
 
-		ext := filepath.Ext(fileAbsolutePath)
-		if ext == ".sha512" {
+		if isChecksumFile(fileAbsolutePath) {
 			isChecksumFileError := fmt.Errorf("%s is a checksum file.", fileAbsolutePath)
-			*errorsList = append(*errorsList, isChecksumFileError)
+			appendLocked(errorsList, isChecksumFileError)
 			continue
 		}
 
-		if err := handler(path); err != nil {
-			*errorsList = append(*errorsList, err)
+		if err := run(path); errors.Is(err, filepath.SkipAll) {
+			return
+		} else if err != nil {
+			appendLocked(errorsList, err)
 		}
 	}
 }
+
+// walkDirectory walks the directory, reporting its files under logicalDirectory, the path it was reached by, and
+// calls run for each file. The symlinks to directories are walked with --follow-symlinks, once per directory.
+// An unreadable directory, a file that vanished or an error of run is recorded and the walk continues with
+// the other entries. It reports whether the walk was stopped by run returning filepath.SkipAll.
+func walkDirectory(root string, directory string, logicalDirectory string, rootInfo os.FileInfo, visited *visitedDirectories, errorsList *[]error, run func(string) error) bool {
+	stopped := false
+	if err := filepath.Walk(directory, func(physicalPath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			appendLocked(errorsList, err)
+			fmt.Println("Error: ", err)
+			return nil
+		}
+
+		filePath := logicalDirectory + strings.TrimPrefix(physicalPath, directory)
+
+		if fileInfo.IsDir() {
+			if filePath != root && (isOnOtherFileSystem(rootInfo, fileInfo) || isFilteredOut(root, filePath, true)) {
+				return filepath.SkipDir
+			}
+			if followSymlinks && !visited.visit(physicalPath, fileInfo) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fileInfo.Mode()&os.ModeSymlink != 0 {
+			if targetInfo, err := os.Stat(physicalPath); err == nil && targetInfo.IsDir() {
+				if !followSymlinks || isOnOtherFileSystem(rootInfo, targetInfo) || isFilteredOut(root, filePath, true) {
+					return nil
+				}
+				target, err := filepath.EvalSymlinks(physicalPath)
+				if err != nil {
+					appendLocked(errorsList, err)
+					return nil
+				}
+				if walkDirectory(root, target, filePath, rootInfo, visited, errorsList, run) {
+					stopped = true
+					return filepath.SkipAll
+				}
+				return nil
+			}
+		}
+
+		if isFilteredOut(root, filePath, false) {
+			return nil
+		}
+
+		if err := run(filePath); errors.Is(err, filepath.SkipAll) {
+			stopped = true
+			return err
+		} else if err != nil {
+			appendLocked(errorsList, err)
+		}
+		return nil
+	}); err != nil {
+		appendLocked(errorsList, err)
+		fmt.Println("Error: ", err)
+	}
+	return stopped
+}
+
+// isOnOtherFileSystem reports whether the directory is on another file system than the walked one
+// when --one-file-system is used
+func isOnOtherFileSystem(rootInfo os.FileInfo, fileInfo os.FileInfo) bool {
+	if !oneFileSystem {
+		return false
+	}
+	rootDevice, rootOk := fileDevice(rootInfo)
+	device, ok := fileDevice(fileInfo)
+	return rootOk && ok && device != rootDevice
+}
+
+// sortForSequentialReads sorts the files by directory and, inside each directory, by inode, which approximates
+// their position on the disk
+func sortForSequentialReads(files []string) {
+	inodes := make(map[string]uint64, len(files))
+	for _, file := range files {
+		if fileInfo, err := os.Lstat(file); err == nil {
+			inodes[file] = fileInode(fileInfo)
+		}
+	}
+
+	slices.SortFunc(files, func(a string, b string) int {
+		if directoryOrder := strings.Compare(filepath.Dir(a), filepath.Dir(b)); directoryOrder != 0 {
+			return directoryOrder
+		}
+		if inodeOrder := cmp.Compare(inodes[a], inodes[b]); inodeOrder != 0 {
+			return inodeOrder
+		}
+		return strings.Compare(a, b)
+	})
+}
+
+// walkConcurrently walks the directory reading its subdirectories with several walkers, since reading
+// directories one by one dominates the runtime on network file systems, and calls run for each file.
+// An error reading a directory is recorded and the walk continues with the other directories.
+// It reports whether the walk was stopped by run returning filepath.SkipAll.
+func walkConcurrently(root string, rootInfo os.FileInfo, walkers int, errorsList *[]error, run func(string) error) bool {
+	var mu sync.Mutex
+	changed := sync.NewCond(&mu)
+	directories := []string{root}
+	active := 0
+	stopped := false
+
+	visited := newVisitedDirectories()
+	visited.visit(root, rootInfo)
+
+	var wg sync.WaitGroup
+	for range walkers {
+		wg.Go(func() {
+			for {
+				mu.Lock()
+				for len(directories) == 0 && active > 0 && !stopped {
+					changed.Wait()
+				}
+				if len(directories) == 0 || stopped {
+					mu.Unlock()
+					return
+				}
+				directory := directories[len(directories)-1]
+				directories = directories[:len(directories)-1]
+				active++
+				mu.Unlock()
+
+				entries, err := os.ReadDir(directory)
+				if err != nil {
+					appendLocked(errorsList, err)
+					fmt.Println("Error: ", err)
+				}
+
+				for _, entry := range entries {
+					entryPath := filepath.Join(directory, entry.Name())
+					isDir := entry.IsDir()
+					if entry.Type()&os.ModeSymlink != 0 {
+						if targetInfo, err := os.Stat(entryPath); err == nil && targetInfo.IsDir() {
+							if !followSymlinks {
+								continue
+							}
+							isDir = true
+						}
+					}
+
+					if isFilteredOut(root, entryPath, isDir) {
+						continue
+					}
+
+					if isDir {
+						if oneFileSystem || followSymlinks {
+							// os.Stat follows the symlinks, so the identity is the one of the directory they point to
+							fileInfo, err := os.Stat(entryPath)
+							if err == nil && isOnOtherFileSystem(rootInfo, fileInfo) {
+								continue
+							}
+							if err == nil && followSymlinks && !visited.visit(entryPath, fileInfo) {
+								continue
+							}
+						}
+
+						mu.Lock()
+						directories = append(directories, entryPath)
+						changed.Signal()
+						mu.Unlock()
+						continue
+					}
+
+					if err := run(entryPath); errors.Is(err, filepath.SkipAll) {
+						mu.Lock()
+						stopped = true
+						mu.Unlock()
+						break
+					} else if err != nil {
+						appendLocked(errorsList, err)
+					}
+				}
+
+				mu.Lock()
+				active--
+				changed.Broadcast()
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	return stopped
+}
+
+// appendLocked appends the items to the list holding outputMutex, so workers can share the list
+func appendLocked[T any](list *[]T, items ...T) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	*list = append(*list, items...)
+}
+
+// sortByPath sorts the results by their path, so the reports of two runs over the same tree are identical, also when
+// several jobs finished the files in another order
+func sortByPath[T any](results []T, path func(T) string) {
+	slices.SortStableFunc(results, func(a T, b T) int {
+		return strings.Compare(path(a), path(b))
+	})
+}
+
+// sortErrors sorts the errors by their message, so the errors recorded by several jobs are reported in the same order
+func sortErrors(errs []error) {
+	slices.SortStableFunc(errs, func(a error, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runStatus is the progress of the running check or create, printed without interrupting it when the process
+// receives SIGUSR1 or, in an interactive terminal, when Enter is pressed
+type runStatus struct {
+	mutex         sync.Mutex
+	paths         []string
+	startedAt     time.Time
+	hashedAtStart int64
+	current       map[string]time.Time
+	filesDone     int
+	counting      bool
+	counted       bool
+	filesTotal    int
+	bytesTotal    int64
+}
+
+// activeRunStatus is the progress of the running check or create, nil when none is running
+var activeRunStatus *runStatus
+
+var listenForStatusRequestsOnce sync.Once
+
+// startRunStatus starts tracking the progress of the run over the paths, and listens for the requests to print it
+func startRunStatus(paths []string) {
+	outputMutex.Lock()
+	activeRunStatus = newRunStatus(paths, hashedBytesTotal.Load(), time.Now())
+	outputMutex.Unlock()
+
+	listenForStatusRequestsOnce.Do(listenForStatusRequests)
+}
+
+func newRunStatus(paths []string, hashedBytes int64, now time.Time) *runStatus {
+	return &runStatus{paths: paths, startedAt: now, hashedAtStart: hashedBytes, current: map[string]time.Time{}}
+}
+
+// listenForStatusRequests prints the status on the status signals and, when both the input and the output are
+// a terminal, on every line read from the input
+func listenForStatusRequests() {
+	if len(statusSignals) > 0 {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, statusSignals...)
+		go func() {
+			for range c {
+				printRunStatus()
+			}
+		}()
+	}
+
+	if isStdinTTY() && isStdoutTTY() {
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				printRunStatus()
+			}
+		}()
+	}
+}
+
+// beginFile records that the file is being processed
+func (s *runStatus) beginFile(fileAbsolutePath string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.current[fileAbsolutePath] = time.Now()
+}
+
+// finishFile records that the file was processed
+func (s *runStatus) finishFile(fileAbsolutePath string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.current, fileAbsolutePath)
+	s.filesDone++
+}
+
+// countTotals counts in the background the files of the run and their size, the first time the status is printed,
+// so the runs whose status is never asked for do not walk their paths twice
+func (s *runStatus) countTotals() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.counting || s.counted {
+		return
+	}
+	s.counting = true
+
+	go func() {
+		var countMutex sync.Mutex
+		var filesTotal int
+		var bytesTotal int64
+		var errs []error
+		walkPaths(s.paths, &errs, func(filePath string) error {
+			if isChecksumFile(filePath) {
+				return nil
+			}
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				return nil
+			}
+			countMutex.Lock()
+			defer countMutex.Unlock()
+			filesTotal++
+			bytesTotal += fileInfo.Size()
+			return nil
+		})
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.counting, s.counted = false, true
+		s.filesTotal, s.bytesTotal = filesTotal, bytesTotal
+	}()
+}
+
+// format returns the status: the files done out of the total, the data hashed and its throughput, the estimated
+// time left once the total is counted, and the files being processed with the time spent on them
+func (s *runStatus) format(hashedBytes int64, now time.Time) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hashed := hashedBytes - s.hashedAtStart
+	elapsed := now.Sub(s.startedAt)
+	var throughput float64
+	if elapsed > 0 {
+		throughput = float64(hashed) / elapsed.Seconds()
+	}
+
+	var status strings.Builder
+	status.WriteString("📊 : ")
+	if s.counted {
+		fmt.Fprintf(&status, "%d of %d files done, %s of %s hashed", s.filesDone, s.filesTotal, formatBytes(hashed), formatBytes(s.bytesTotal))
+	} else {
+		fmt.Fprintf(&status, "%d files done (counting the total), %s hashed", s.filesDone, formatBytes(hashed))
+	}
+	fmt.Fprintf(&status, " at %s/s in %s", formatBytes(int64(throughput)), formatDuration(elapsed))
+	if s.counted && throughput > 0 {
+		remaining := max(s.bytesTotal-hashed, 0)
+		fmt.Fprintf(&status, ", about %s left", formatDuration(time.Duration(float64(remaining)/throughput*float64(time.Second))))
+	}
+	status.WriteString("\n")
+
+	current := make([]string, 0, len(s.current))
+	for fileAbsolutePath := range s.current {
+		current = append(current, fileAbsolutePath)
+	}
+	sort.Strings(current)
+	for _, fileAbsolutePath := range current {
+		fmt.Fprintf(&status, "   ▶ %s (%s)\n", fileAbsolutePath, formatDuration(now.Sub(s.current[fileAbsolutePath])))
+	}
+
+	return status.String()
+}
+
+// printRunStatus prints the status of the running check or create, if any
+func printRunStatus() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	if activeRunStatus == nil {
+		return
+	}
+	activeRunStatus.countTotals()
+	fmt.Print("\n" + activeRunStatus.format(hashedBytesTotal.Load(), time.Now()))
+}
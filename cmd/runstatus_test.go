@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStatusFormat(t *testing.T) {
+	startedAt := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+	status := newRunStatus([]string{"/volume1"}, 100, startedAt)
+	status.beginFile("/volume1/a.raw")
+	status.finishFile("/volume1/a.raw")
+	status.beginFile("/volume1/b.raw")
+	status.current["/volume1/b.raw"] = startedAt.Add(5 * time.Second)
+
+	now := startedAt.Add(10 * time.Second)
+	formatted := status.format(100+10<<20, now)
+	if !strings.Contains(formatted, "1 files done (counting the total), 10.0 MiB hashed at 1.0 MiB/s") || !strings.Contains(formatted, "▶ /volume1/b.raw (5s)") {
+		t.Fatalf("unexpected status %q", formatted)
+	}
+
+	status.counted, status.filesTotal, status.bytesTotal = true, 4, 40<<20
+	formatted = status.format(100+10<<20, now)
+	if !strings.Contains(formatted, "1 of 4 files done, 10.0 MiB of 40.0 MiB hashed") || !strings.Contains(formatted, "about 30s left") {
+		t.Fatalf("unexpected status %q", formatted)
+	}
+}
+
+func TestRunStatusCountTotals(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{"a.raw": "aaaa", "b.raw": "bb"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(path); result.Status != Created {
+			t.Fatalf("expected status %s, got %s", Created, result.Status)
+		}
+	}
+
+	status := newRunStatus([]string{tempDir}, 0, time.Now())
+	status.countTotals()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status.mutex.Lock()
+		counted, filesTotal, bytesTotal := status.counted, status.filesTotal, status.bytesTotal
+		status.mutex.Unlock()
+		if counted {
+			if filesTotal != 2 || bytesTotal != 6 {
+				t.Fatalf("expected 2 files of 6 bytes without the checksum files, got %d files of %d bytes", filesTotal, bytesTotal)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the totals were not counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
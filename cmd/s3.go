@@ -0,0 +1,516 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3Scheme is the prefix of the paths of objects in an S3 compatible bucket
+const s3Scheme = "s3://"
+
+var s3Endpoint string
+
+// s3MetadataStore is set by --s3-metadata in create
+var s3MetadataStore bool
+
+// s3ChecksumMetadata is the user metadata of the objects keeping their checksum, x-amz-meta-sha512
+const s3ChecksumMetadata = "X-Amz-Meta-Sha512"
+
+// s3MaxCopySize is the size of the largest object that CopyObject can copy, larger objects are copied in parts
+const s3MaxCopySize = 5 << 30
+
+// s3CopyPartSize is the size of the parts of the copies of the large objects
+const s3CopyPartSize = 1 << 30
+
+// s3KeptHeaders are the headers of an object kept when its metadata is replaced, besides its user metadata
+var s3KeptHeaders = []string{"Content-Type", "Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"}
+
+// s3Client is a minimal client of the S3 API, signing the requests with AWS Signature Version 4
+type s3Client struct {
+	Endpoint        *url.URL
+	PathStyle       bool
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	HTTPClient      *http.Client
+}
+
+// s3Object is an object listed in a bucket
+type s3Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// newS3Client creates a client with the credentials and region of the AWS environment variables.
+// When an endpoint is given, like the one of a MinIO server, path style requests are used.
+func newS3Client(endpoint string) (*s3Client, error) {
+	client := &s3Client{
+		Region:          firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		HTTPClient:      http.DefaultClient,
+	}
+
+	if client.AccessKeyID == "" || client.SecretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for s3:// paths")
+	}
+
+	endpoint = firstNonEmpty(endpoint, os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"))
+	if endpoint == "" {
+		client.Endpoint = &url.URL{Scheme: "https", Host: "s3." + client.Region + ".amazonaws.com"}
+		return client, nil
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("%s is not a valid S3 endpoint URL", endpoint)
+	}
+	client.Endpoint = endpointURL
+	client.PathStyle = true
+
+	return client, nil
+}
+
+// firstNonEmpty returns the first of the values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseS3URL returns the bucket and the key prefix of a URL like s3://bucket/prefix
+func parseS3URL(rawURL string) (string, string, error) {
+	bucketAndPrefix := strings.TrimPrefix(rawURL, s3Scheme)
+	bucket, prefix, _ := strings.Cut(bucketAndPrefix, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("%s is not a valid s3:// URL", rawURL)
+	}
+
+	return bucket, prefix, nil
+}
+
+// s3URL returns the s3:// URL of the object
+func s3URL(bucket string, key string) string {
+	return s3Scheme + bucket + "/" + key
+}
+
+// requestURL returns the URL of the object, or of the bucket when the key is empty
+func (c *s3Client) requestURL(bucket string, key string) *url.URL {
+	requestURL := *c.Endpoint
+
+	basePath := strings.TrimSuffix(requestURL.Path, "/")
+	if c.PathStyle || strings.Contains(bucket, ".") {
+		basePath += "/" + bucket
+	} else {
+		requestURL.Host = bucket + "." + requestURL.Host
+	}
+
+	requestURL.Path = basePath + "/" + key
+	requestURL.RawPath = s3EncodePath(requestURL.Path)
+
+	return &requestURL
+}
+
+// do sends the signed request, with the headers, and returns the response when its status is successful
+func (c *s3Client) do(method string, bucket string, key string, query map[string]string, headers map[string]string, body []byte) (*http.Response, error) {
+	requestURL := c.requestURL(bucket, key)
+	requestURL.RawQuery = s3CanonicalQuery(query)
+
+	request, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = int64(len(body))
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	c.sign(request, body, time.Now().UTC())
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		defer response.Body.Close()
+
+		var s3Error struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		content, _ := io.ReadAll(response.Body)
+		if xml.Unmarshal(content, &s3Error) == nil && s3Error.Code != "" {
+			return nil, fmt.Errorf("%s: %s: %s", s3URL(bucket, key), s3Error.Code, s3Error.Message)
+		}
+		return nil, fmt.Errorf("%s: %s", s3URL(bucket, key), response.Status)
+	}
+
+	return response, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (c *s3Client) sign(request *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	hexPayloadHash := hex.EncodeToString(payloadHash[:])
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", hexPayloadHash)
+	if c.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexPayloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + c.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, c.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Encode percent-encodes every byte except the unreserved characters, as required by Signature Version 4
+func s3Encode(value string, keepSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' || (keepSlash && b == '/') {
+			encoded.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", b)
+	}
+	return encoded.String()
+}
+
+// s3EncodePath percent-encodes the path, keeping its slashes
+func s3EncodePath(path string) string {
+	return s3Encode(path, true)
+}
+
+// s3CanonicalQuery encodes the query parameters sorted by name
+func s3CanonicalQuery(query map[string]string) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parameters := make([]string, 0, len(names))
+	for _, name := range names {
+		parameters = append(parameters, s3Encode(name, false)+"="+s3Encode(query[name], false))
+	}
+	return strings.Join(parameters, "&")
+}
+
+// ListObjects returns the objects of the bucket whose key starts with the prefix
+func (c *s3Client) ListObjects(bucket string, prefix string) ([]s3Object, error) {
+	var objects []s3Object
+	continuationToken := ""
+
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if continuationToken != "" {
+			query["continuation-token"] = continuationToken
+		}
+
+		response, err := c.do(http.MethodGet, bucket, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+		}
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, s3Object{Key: content.Key, Size: content.Size, ETag: strings.Trim(content.ETag, `"`), LastModified: content.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// GetObject returns the content of the object
+func (c *s3Client) GetObject(bucket string, key string) (io.ReadCloser, error) {
+	response, err := c.do(http.MethodGet, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// PutObject stores the content in the object
+func (c *s3Client) PutObject(bucket string, key string, content []byte) error {
+	response, err := c.do(http.MethodPut, bucket, key, nil, nil, content)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// HeadObject returns the headers of the object, with its size and user metadata
+func (c *s3Client) HeadObject(bucket string, key string) (http.Header, error) {
+	response, err := c.do(http.MethodHead, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	return response.Header, nil
+}
+
+// SetMetadata sets the user metadata of the object, keeping the rest of its metadata, with a copy of the object
+// onto itself, since the metadata of an object cannot be changed. Objects larger than 5 GiB are copied in parts,
+// as CopyObject does not copy them.
+func (c *s3Client) SetMetadata(bucket string, key string, name string, value string) error {
+	header, err := c.HeadObject(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{name: value}
+	for headerName := range header {
+		if strings.HasPrefix(headerName, "X-Amz-Meta-") && headerName != name {
+			headers[headerName] = header.Get(headerName)
+		}
+	}
+	for _, headerName := range s3KeptHeaders {
+		if headerValue := header.Get(headerName); headerValue != "" {
+			headers[headerName] = headerValue
+		}
+	}
+	copySource := "/" + bucket + "/" + s3EncodePath(key)
+
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if size <= s3MaxCopySize {
+		headers["X-Amz-Copy-Source"] = copySource
+		headers["X-Amz-Metadata-Directive"] = "REPLACE"
+		response, err := c.do(http.MethodPut, bucket, key, nil, headers, nil)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	response, err := c.do(http.MethodPost, bucket, key, map[string]string{"uploads": ""}, headers, nil)
+	if err != nil {
+		return err
+	}
+	var upload struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(response.Body).Decode(&upload)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	abort := func(err error) error {
+		if response, abortErr := c.do(http.MethodDelete, bucket, key, map[string]string{"uploadId": upload.UploadID}, nil, nil); abortErr == nil {
+			response.Body.Close()
+		}
+		return err
+	}
+
+	var completion strings.Builder
+	completion.WriteString("<CompleteMultipartUpload>")
+	for partNumber, start := 1, int64(0); start < size; partNumber, start = partNumber+1, start+s3CopyPartSize {
+		end := min(start+s3CopyPartSize, size) - 1
+		response, err := c.do(http.MethodPut, bucket, key, map[string]string{"partNumber": strconv.Itoa(partNumber), "uploadId": upload.UploadID}, map[string]string{
+			"X-Amz-Copy-Source":       copySource,
+			"X-Amz-Copy-Source-Range": fmt.Sprintf("bytes=%d-%d", start, end),
+		}, nil)
+		if err != nil {
+			return abort(err)
+		}
+		var part struct {
+			ETag string `xml:"ETag"`
+		}
+		err = xml.NewDecoder(response.Body).Decode(&part)
+		response.Body.Close()
+		if err != nil {
+			return abort(err)
+		}
+		fmt.Fprintf(&completion, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", partNumber, part.ETag)
+	}
+	completion.WriteString("</CompleteMultipartUpload>")
+
+	response, err = c.do(http.MethodPost, bucket, key, map[string]string{"uploadId": upload.UploadID}, nil, []byte(completion.String()))
+	if err != nil {
+		return abort(err)
+	}
+	return response.Body.Close()
+}
+
+// s3Backend is the objects of a bucket whose key starts with a prefix
+type s3Backend struct {
+	Client *s3Client
+	Bucket string
+	Prefix string
+}
+
+// openS3Backend returns the backend of the objects inside the s3:// URL
+func openS3Backend(rawURL string) (storageBackend, error) {
+	bucket, prefix, err := parseS3URL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newS3Client(s3Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := s3Backend{Client: c, Bucket: bucket, Prefix: prefix}
+	backend.Prefix = backend.listPrefix()
+	return backend, nil
+}
+
+func (b s3Backend) URL(key string) string {
+	return s3URL(b.Bucket, key)
+}
+
+// List returns the keys of the objects
+func (b s3Backend) List() ([]string, error) {
+	objects, err := b.Client.ListObjects(b.Bucket, b.listPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(objects))
+	for i, object := range objects {
+		keys[i] = object.Key
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// listPrefix returns the prefix ending in a slash, so s3://bucket/photos does not list photos-old/ or photos2/ like
+// b2Location.fileName
+func (b s3Backend) listPrefix() string {
+	if b.Prefix == "" || strings.HasSuffix(b.Prefix, "/") {
+		return b.Prefix
+	}
+	return b.Prefix + "/"
+}
+
+// Resolve returns the key of the object relative to the prefix
+func (b s3Backend) Resolve(relativePath string) string {
+	if b.Prefix == "" {
+		return relativePath
+	}
+	return strings.TrimSuffix(b.Prefix, "/") + "/" + relativePath
+}
+
+func (b s3Backend) Open(key string) (io.ReadCloser, error) {
+	return b.Client.GetObject(b.Bucket, key)
+}
+
+func (b s3Backend) Write(key string, content []byte) error {
+	return b.Client.PutObject(b.Bucket, key, content)
+}
+
+// ReadChecksumMetadata returns the checksum kept in the x-amz-meta-sha512 metadata of the object, or an empty string
+func (b s3Backend) ReadChecksumMetadata(key string) (string, error) {
+	header, err := b.Client.HeadObject(b.Bucket, key)
+	if err != nil {
+		return "", err
+	}
+	return header.Get(s3ChecksumMetadata), nil
+}
+
+// WriteChecksumMetadata keeps the checksum in the x-amz-meta-sha512 metadata of the object
+func (b s3Backend) WriteChecksumMetadata(key string, checksum string) error {
+	return b.Client.SetMetadata(b.Bucket, key, s3ChecksumMetadata, checksum)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// s3ETagVerification is set by --s3-etag in check
+var s3ETagVerification bool
+
+// s3ETagVerificationUsage is the usage of the --s3-etag flag
+const s3ETagVerificationUsage = "with --remote-copy in an s3:// path, compare the ETag of the objects with the one computed from the local files, downloading them only when it does not match, like for objects encrypted with SSE-KMS"
+
+// s3ETagPartSizes are the part sizes of the multipart uploads of the usual tools, tried to compute the ETag of
+// the multipart uploads: the minimum of S3 used by rclone, the 8 MiB of the AWS CLI and boto3, the 15 MiB of s3cmd,
+// the 16 MiB of MinIO and some larger sizes
+var s3ETagPartSizes = []int64{5 << 20, 8 << 20, 15 << 20, 16 << 20, 32 << 20, 64 << 20, 100 << 20, 128 << 20, 256 << 20, 512 << 20, 1 << 30}
+
+// multipartETagHasher computes the ETag of a multipart upload of the written content with parts of the part size:
+// the MD5 of the MD5s of the parts, followed by the number of parts
+type multipartETagHasher struct {
+	partSize    int64
+	part        hash.Hash
+	partWritten int64
+	partDigests []byte
+	parts       int
+}
+
+func newMultipartETagHasher(partSize int64) *multipartETagHasher {
+	return &multipartETagHasher{partSize: partSize, part: md5.New()}
+}
+
+func (h *multipartETagHasher) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(int64(len(p)), h.partSize-h.partWritten)
+		h.part.Write(p[:n])
+		h.partWritten += n
+		p = p[n:]
+
+		if h.partWritten == h.partSize {
+			h.finishPart()
+		}
+	}
+	return written, nil
+}
+
+func (h *multipartETagHasher) finishPart() {
+	h.partDigests = h.part.Sum(h.partDigests)
+	h.parts++
+	h.part.Reset()
+	h.partWritten = 0
+}
+
+// ETag returns the ETag of the multipart upload of the written content
+func (h *multipartETagHasher) ETag() string {
+	if h.partWritten > 0 || h.parts == 0 {
+		h.finishPart()
+	}
+	digest := md5.Sum(h.partDigests)
+	return hex.EncodeToString(digest[:]) + "-" + strconv.Itoa(h.parts)
+}
+
+// multipartETagPartSizes returns the part sizes that split an object of the size in the number of parts: the usual
+// ones and the size divided by the number of parts, rounded up to a MiB, used by the tools that adapt the part size
+// to the size of the files
+func multipartETagPartSizes(size int64, parts int) []int64 {
+	fits := func(partSize int64) bool {
+		return partSize > 0 && (size+partSize-1)/partSize == int64(parts)
+	}
+
+	var partSizes []int64
+	for _, partSize := range s3ETagPartSizes {
+		if fits(partSize) {
+			partSizes = append(partSizes, partSize)
+		}
+	}
+	if parts > 0 {
+		const mebibyte = 1 << 20
+		partSize := ((size+int64(parts)-1)/int64(parts) + mebibyte - 1) / mebibyte * mebibyte
+		if fits(partSize) && !containsPartSize(partSizes, partSize) {
+			partSizes = append(partSizes, partSize)
+		}
+	}
+	return partSizes
+}
+
+func containsPartSize(partSizes []int64, partSize int64) bool {
+	for _, size := range partSizes {
+		if size == partSize {
+			return true
+		}
+	}
+	return false
+}
+
+// localETags hashes the local file once, returning its hexadecimal SHA512 checksum and the ETags it would have
+// as an object with the ETag: its MD5 for a single part upload, or the ETags of the multipart uploads with the
+// part sizes that give the number of parts of the ETag
+func localETags(fileAbsolutePath string, size int64, etag string) (string, []string, error) {
+	file, err := openDataFile(fileAbsolutePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	checksum := sha512Algorithm.New()
+	writers := []io.Writer{checksum}
+
+	singlePart := md5.New()
+	var multipartHashers []*multipartETagHasher
+	if _, parts, multipart := strings.Cut(etag, "-"); multipart {
+		partCount, err := strconv.Atoi(parts)
+		if err == nil {
+			for _, partSize := range multipartETagPartSizes(size, partCount) {
+				hasher := newMultipartETagHasher(partSize)
+				multipartHashers = append(multipartHashers, hasher)
+				writers = append(writers, hasher)
+			}
+		}
+	} else {
+		writers = append(writers, singlePart)
+	}
+
+	if err := copyDoubleBuffered(io.MultiWriter(writers...), throttle(countHashedBytes(file))); err != nil {
+		return "", nil, err
+	}
+
+	var etags []string
+	if len(multipartHashers) == 0 {
+		etags = append(etags, hex.EncodeToString(singlePart.Sum(nil)))
+	}
+	for _, hasher := range multipartHashers {
+		etags = append(etags, hasher.ETag())
+	}
+
+	return hex.EncodeToString(checksum.Sum(nil)), etags, nil
+}
+
+// s3Copy is the copy of a local directory in a bucket, with its objects listed once to know their ETag and size
+type s3Copy struct {
+	backendCopy
+	Objects map[string]s3Object
+}
+
+// openS3Copy lists the objects inside the s3:// URL given with --remote-copy
+func openS3Copy(rawURL string) (remoteCopyLocation, error) {
+	backend, err := openS3Backend(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	s3 := backend.(s3Backend)
+
+	objects, err := s3.Client.ListObjects(s3.Bucket, s3.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	location := s3Copy{backendCopy: backendCopy{s3}, Objects: make(map[string]s3Object, len(objects))}
+	for _, object := range objects {
+		location.Objects[object.Key] = object
+	}
+	return location, nil
+}
+
+// checkCopy compares the ETag of the copy of the local file with the one computed from the local file, once
+// the local file is verified with its checksum. When no ETag computed matches, as the ETags of the objects encrypted
+// with SSE-KMS are not their MD5 or the part size of the upload is unknown, the copy is downloaded to be verified.
+func (c s3Copy) checkCopy(file string, localFileAbsolutePath string) ChecksumFileVerificationResult {
+	fileURL := c.URL(file)
+
+	checksum, failed := localChecksumOfCopy(fileURL, localFileAbsolutePath)
+	if failed != nil {
+		return *failed
+	}
+
+	object, ok := c.Objects[c.Resolve(file)]
+	if !ok {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: fmt.Errorf("the copy of %s does not exist", localFileAbsolutePath)}
+	}
+
+	info, err := os.Stat(localFileAbsolutePath)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+	if info.Size() != object.Size {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("the copy has %d bytes, %s has %d bytes", object.Size, localFileAbsolutePath, info.Size())}
+	}
+
+	localChecksum, etags, err := localETags(localFileAbsolutePath, object.Size, object.ETag)
+	if err != nil {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: CheckingFailed, Error: err}
+	}
+	if !strings.EqualFold(localChecksum, checksum) {
+		return ChecksumFileVerificationResult{Path: fileURL, Status: NotMatch, Error: fmt.Errorf("%s does not match its checksum, so its copy cannot be verified", localFileAbsolutePath)}
+	}
+
+	for _, etag := range etags {
+		if strings.EqualFold(etag, object.ETag) {
+			return ChecksumFileVerificationResult{Path: fileURL, Status: Match, Error: nil}
+		}
+	}
+
+	return c.backendCopy.checkCopy(file, localFileAbsolutePath)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// multipartETag returns the ETag of the multipart upload of the parts
+func multipartETag(parts ...string) string {
+	var digests []byte
+	for _, part := range parts {
+		digest := md5.Sum([]byte(part))
+		digests = append(digests, digest[:]...)
+	}
+	digest := md5.Sum(digests)
+	return hex.EncodeToString(digest[:]) + fmt.Sprintf("-%d", len(parts))
+}
+
+func TestMultipartETagHasher(t *testing.T) {
+	for content, parts := range map[string][]string{
+		"abcdefghij": {"abcd", "efgh", "ij"},
+		"abcdefgh":   {"abcd", "efgh"},
+		"":           {""},
+	} {
+		hasher := newMultipartETagHasher(4)
+		io.WriteString(hasher, content)
+		if etag := hasher.ETag(); etag != multipartETag(parts...) {
+			t.Fatalf("unexpected ETag of %q: %s", content, etag)
+		}
+	}
+}
+
+func TestMultipartETagPartSizes(t *testing.T) {
+	partSizes := multipartETagPartSizes(20<<20, 3)
+	if len(partSizes) != 2 || partSizes[0] != 8<<20 || partSizes[1] != 7<<20 {
+		t.Fatalf("unexpected part sizes %v", partSizes)
+	}
+}
+
+func TestS3CopyETag(t *testing.T) {
+	contents := map[string]string{"a.raw": "raw a", "b.raw": "raw b", "c.raw": "raw c", "d.raw": "raw d"}
+	singlePartETag := md5.Sum([]byte("raw a"))
+	etags := map[string]string{
+		"a.raw": hex.EncodeToString(singlePartETag[:]),
+		"b.raw": multipartETag("raw b"),
+		// An object encrypted with SSE-KMS, whose ETag is not its MD5
+		"c.raw": strings.Repeat("0", 32),
+		"d.raw": strings.Repeat("0", 32),
+	}
+
+	var mutex sync.Mutex
+	downloaded := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.URL.Path == "/bucket/" {
+			io.WriteString(w, "<ListBucketResult><IsTruncated>false</IsTruncated>")
+			for _, name := range []string{"a.raw", "b.raw", "c.raw", "d.raw"} {
+				size := len(contents[name])
+				if name == "d.raw" {
+					size++
+				}
+				fmt.Fprintf(w, `<Contents><Key>photos/%s</Key><Size>%d</Size><ETag>"%s"</ETag></Contents>`, name, size, etags[name])
+			}
+			io.WriteString(w, "</ListBucketResult>")
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, "/bucket/photos/")
+		downloaded[name] = true
+		io.WriteString(w, contents[name])
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	s3Endpoint = server.URL
+	s3ETagVerification = true
+	remoteCopy = "s3://bucket/photos"
+	defer func() {
+		s3Endpoint = ""
+		s3ETagVerification = false
+		remoteCopy = ""
+	}()
+
+	localDir := t.TempDir()
+	for name, content := range contents {
+		localPath := filepath.Join(localDir, name)
+		if err := os.WriteFile(localPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		if result := createChecksumFile(localPath); result.Status != Created {
+			t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+		}
+	}
+
+	var results []ChecksumFileVerificationResult
+	if err := runRemoteCopyVerification(localDir, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]ChecksumFileVerificationStatus{
+		"s3://bucket/photos/a.raw": Match,
+		"s3://bucket/photos/b.raw": Match,
+		"s3://bucket/photos/c.raw": Match,
+		"s3://bucket/photos/d.raw": NotMatch,
+	}
+	for _, result := range results {
+		if expected[result.Path] != result.Status {
+			t.Fatalf("expected status %s for %s, got %s (%v)", expected[result.Path], result.Path, result.Status, result.Error)
+		}
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	if downloaded["a.raw"] || downloaded["b.raw"] || !downloaded["c.raw"] {
+		t.Fatalf("only the object whose ETag is not its MD5 should be downloaded, got %v", downloaded)
+	}
+
+	if key := (s3Backend{Bucket: "bucket", Prefix: "photos/"}).Resolve("a.raw"); key != "photos/a.raw" {
+		t.Fatalf("unexpected key %s", key)
+	}
+}
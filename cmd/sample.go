@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+var samplePercentage percentage
+var sampleCount int
+
+// percentage is a percentage given as a flag, like 5%
+type percentage float64
+
+func (p *percentage) String() string {
+	if *p == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*p), 'f', -1, 64) + "%"
+}
+
+func (p *percentage) Set(value string) error {
+	number, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil || number <= 0 || number > 100 {
+		return fmt.Errorf("%q is not a valid percentage, use a number between 0 and 100 like 5%%", value)
+	}
+	*p = percentage(number)
+	return nil
+}
+
+func (p *percentage) Type() string {
+	return "percentage"
+}
+
+// isSampling reports whether --sample or --sample-count is used
+func isSampling() bool {
+	return samplePercentage > 0 || sampleCount > 0
+}
+
+// sampleProtectedFiles returns a random sample of the files of the paths that have a checksum file,
+// of --sample percent of them or of --sample-count files, and the number of protected files
+func sampleProtectedFiles(paths []string, errorsList *[]error) ([]string, int) {
+	var protectedFiles []string
+	processPaths(paths, errorsList, func(filePath string) error {
+		if isChecksumFile(filePath) {
+			return nil
+		}
+		if stored, err := hasStoredChecksum(filePath); err == nil && stored {
+			appendLocked(&protectedFiles, filePath)
+		}
+		return nil
+	})
+
+	size := sampleCount
+	if samplePercentage > 0 {
+		size = int(math.Ceil(float64(len(protectedFiles)) * float64(samplePercentage) / 100))
+	}
+	size = min(size, len(protectedFiles))
+
+	// A partial Fisher-Yates shuffle puts the sample at the beginning
+	for i := range size {
+		j := i + rand.IntN(len(protectedFiles)-i)
+		protectedFiles[i], protectedFiles[j] = protectedFiles[j], protectedFiles[i]
+	}
+
+	return protectedFiles[:size], len(protectedFiles)
+}
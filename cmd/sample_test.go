@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSampleProtectedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := range 12 {
+		filePath := filepath.Join(tempDir, fmt.Sprintf("data-%d.txt", i))
+		if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		// The last two files are not protected by a checksum file
+		if i < 10 {
+			if result := createChecksumFile(filePath); result.Status != Created {
+				t.Fatalf("expected status %s, got %s", Created, result.Status)
+			}
+		}
+	}
+
+	samplePercentage = 20
+	defer func() { samplePercentage = 0 }()
+
+	var errs []error
+	sample, protectedFilesQuantity := sampleProtectedFiles([]string{tempDir}, &errs)
+	if len(errs) != 0 || protectedFilesQuantity != 10 || len(sample) != 2 {
+		t.Fatalf("expected 2 of 10 files, got %d of %d (%v)", len(sample), protectedFilesQuantity, errs)
+	}
+	for _, filePath := range sample {
+		if _, err := os.Stat(filePath + checksumFileExtension); err != nil {
+			t.Fatalf("expected only files with a checksum file, got %s", filePath)
+		}
+	}
+
+	samplePercentage = 0
+	sampleCount = 100
+	defer func() { sampleCount = 0 }()
+	if sample, _ := sampleProtectedFiles([]string{tempDir}, &errs); len(sample) != 10 {
+		t.Fatalf("expected every protected file when the count is larger, got %d", len(sample))
+	}
+}
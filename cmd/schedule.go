@@ -0,0 +1,337 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var scheduleDaily bool
+var scheduleWeekly bool
+var scheduleMonthly bool
+var scheduleFormat string
+var scheduleCommand string
+var scheduleBackground bool
+var scheduleLogPath string
+var scheduleOutputDirectory string
+
+// scheduleFrequencies are the cron schedules of each frequency, at 03:00
+var scheduleFrequencies = map[string]string{
+	"daily":   "0 3 * * *",
+	"weekly":  "0 3 * * 0",
+	"monthly": "0 3 1 * *",
+}
+
+// systemdCalendars are the OnCalendar expressions of each frequency, at the same time as scheduleFrequencies: the
+// systemd shorthands daily, weekly and monthly run at 00:00, and weekly on Monday
+var systemdCalendars = map[string]string{
+	"daily":   "*-*-* 03:00:00",
+	"weekly":  "Sun *-*-* 03:00:00",
+	"monthly": "*-*-01 03:00:00",
+}
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Generate a scheduled task that runs checksum-utils.",
+	Long: `Write a ready to install systemd service and timer, cron line or Windows Task Scheduler task
+that runs check (or create) on the given paths, appending its output to a log file.
+
+Example:
+  checksum-utils schedule --monthly /volume1/photos
+  checksum-utils schedule --monthly --background /volume1
+  checksum-utils schedule --weekly --format cron --log /var/log/checksum-utils.log /volume1
+  checksum-utils schedule --daily --command create --format windows D:\Documents
+`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePaths,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		frequency, err := selectedScheduleFrequency()
+		if err != nil {
+			return err
+		}
+
+		if scheduleCommand != "check" && scheduleCommand != "create" {
+			return fmt.Errorf("--command must be check or create, not %q", scheduleCommand)
+		}
+
+		executablePath, err := os.Executable()
+		if err != nil {
+			return err
+		}
+
+		paths := make([]string, 0, len(args))
+		for _, arg := range args {
+			absolutePath, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, absolutePath)
+		}
+
+		logPath := scheduleLogPath
+		if logPath == "" {
+			logPath = defaultScheduleLogPath()
+		}
+		logPath, err = filepath.Abs(logPath)
+		if err != nil {
+			return err
+		}
+
+		name := "checksum-utils-" + scheduleCommand
+		command := scheduleCommand
+		if scheduleBackground {
+			command += " --background"
+		}
+
+		files := map[string]string{}
+		switch scheduleFormat {
+		case "systemd":
+			service, timer := systemdUnits(executablePath, command, paths, frequency, logPath, scheduleUser())
+			files[name+".service"] = service
+			files[name+".timer"] = timer
+		case "cron":
+			files[name+".cron"] = cronLine(executablePath, command, paths, frequency, logPath) + "\n"
+		case "windows":
+			files[name+".xml"] = taskSchedulerXML(executablePath, command, paths, frequency, logPath)
+		default:
+			return fmt.Errorf("--format must be systemd, cron or windows, not %q", scheduleFormat)
+		}
+
+		for fileName, content := range files {
+			filePath := filepath.Join(scheduleOutputDirectory, fileName)
+			if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+				return err
+			}
+			fmt.Println("Created", filePath)
+		}
+
+		fmt.Println()
+		fmt.Println("To install it:")
+		switch scheduleFormat {
+		case "systemd":
+			fmt.Printf("  sudo cp %s.service %s.timer /etc/systemd/system/\n", name, name)
+			fmt.Printf("  sudo systemctl daemon-reload && sudo systemctl enable --now %s.timer\n", name)
+		case "cron":
+			fmt.Printf("  (crontab -l; cat %s.cron) | crontab -\n", name)
+		case "windows":
+			fmt.Printf("  schtasks /Create /TN %s /XML %s.xml\n", name, name)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+
+	scheduleCmd.Flags().BoolVar(&scheduleDaily, "daily", false, "run every day at 03:00")
+	scheduleCmd.Flags().BoolVar(&scheduleWeekly, "weekly", false, "run every Sunday at 03:00")
+	scheduleCmd.Flags().BoolVar(&scheduleMonthly, "monthly", false, "run the first day of every month at 03:00 (default)")
+	scheduleCmd.Flags().StringVar(&scheduleFormat, "format", defaultScheduleFormat(), "format of the scheduled task: systemd, cron or windows")
+	scheduleCmd.Flags().StringVar(&scheduleCommand, "command", "check", "command to run: check or create")
+	scheduleCmd.Flags().BoolVar(&scheduleBackground, "background", false, "run the command with --background, with the lowest CPU and I/O priority")
+	scheduleCmd.Flags().StringVar(&scheduleLogPath, "log", "", "log file where the output is appended (default: checksum-utils.log in the user cache directory)")
+	scheduleCmd.Flags().StringVarP(&scheduleOutputDirectory, "output", "o", ".", "directory where the files are written")
+
+	scheduleCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"systemd", "cron", "windows"}, cobra.ShellCompDirectiveNoFileComp))
+	scheduleCmd.RegisterFlagCompletionFunc("command", cobra.FixedCompletions([]string{"check", "create"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// defaultScheduleFormat returns the scheduler of this system
+func defaultScheduleFormat() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "windows"
+	case "linux":
+		return "systemd"
+	}
+	return "cron"
+}
+
+// defaultScheduleLogPath returns checksum-utils.log in the user cache directory
+func defaultScheduleLogPath() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return "checksum-utils.log"
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "checksum-utils.log")
+}
+
+// scheduleUser returns the name of the user generating the scheduled task, who the systemd service runs as, so it
+// writes its log in the cache directory of that user and not as root; or an empty string when it is not known
+func scheduleUser() string {
+	currentUser, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return currentUser.Username
+}
+
+// selectedScheduleFrequency returns the frequency selected with --daily, --weekly or --monthly
+func selectedScheduleFrequency() (string, error) {
+	var selected []string
+	if scheduleDaily {
+		selected = append(selected, "daily")
+	}
+	if scheduleWeekly {
+		selected = append(selected, "weekly")
+	}
+	if scheduleMonthly {
+		selected = append(selected, "monthly")
+	}
+
+	switch len(selected) {
+	case 0:
+		return "monthly", nil
+	case 1:
+		return selected[0], nil
+	}
+	return "", errors.New("only one of --daily, --weekly and --monthly can be used")
+}
+
+// commandLine returns the command line quoted for the POSIX shell
+func commandLine(executablePath string, command string, paths []string) string {
+	words := []string{shellQuote(executablePath), command}
+	for _, path := range paths {
+		words = append(words, shellQuote(path))
+	}
+	return strings.Join(words, " ")
+}
+
+// systemdUnits returns a oneshot service running the command as the user, or as root when it is empty, and the timer
+// that starts it
+func systemdUnits(executablePath string, command string, paths []string, frequency string, logPath string, userName string) (string, string) {
+	quotedPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		quotedPaths = append(quotedPaths, systemdQuote(path))
+	}
+
+	var userLine string
+	if userName != "" {
+		userLine = "User=" + systemdEscapeSpecifiers(userName) + "\n"
+	}
+
+	service := fmt.Sprintf(`[Unit]
+Description=checksum-utils %[2]s %[3]s
+
+[Service]
+Type=oneshot
+%[7]sExecStartPre=/bin/mkdir -p %[5]s
+ExecStart=%[1]s %[2]s %[4]s
+StandardInput=null
+StandardOutput=append:%[6]s
+StandardError=append:%[6]s
+Nice=10
+IOSchedulingClass=idle
+`, systemdQuote(executablePath), command, systemdEscapeSpecifiers(strings.Join(paths, " ")), strings.Join(quotedPaths, " "), systemdQuote(filepath.Dir(logPath)), systemdEscapeSpecifiers(logPath), userLine)
+
+	timer := fmt.Sprintf(`[Unit]
+Description=Run checksum-utils %[1]s %[2]s
+
+[Timer]
+OnCalendar=%[3]s
+Persistent=true
+RandomizedDelaySec=1h
+
+[Install]
+WantedBy=timers.target
+`, command, frequency, systemdCalendars[frequency])
+
+	return service, timer
+}
+
+// systemdQuote quotes the argument for a systemd unit, escaping the % specifiers and the $ variables that systemd
+// would otherwise expand
+func systemdQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg) + `"`
+}
+
+// systemdEscapeSpecifiers escapes the % specifiers of a value that is neither a command line nor quoted, like the
+// description or the path of StandardOutput=append:, where systemd expands the specifiers but not the $ variables
+func systemdEscapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// cronLine returns the crontab line running the command, with its output appended to the log file
+func cronLine(executablePath string, command string, paths []string, frequency string, logPath string) string {
+	line := "mkdir -p " + shellQuote(filepath.Dir(logPath)) + " && " + commandLine(executablePath, command, paths) + " < /dev/null >> " + shellQuote(logPath) + " 2>&1"
+	return scheduleFrequencies[frequency] + " " + strings.ReplaceAll(line, "%", `\%`)
+}
+
+// taskSchedulerXML returns the Windows Task Scheduler task running the command through cmd.exe,
+// so its output can be appended to the log file
+func taskSchedulerXML(executablePath string, command string, paths []string, frequency string, logPath string) string {
+	arguments := `/c ""` + executablePath + `" ` + command
+	for _, path := range paths {
+		arguments += ` "` + path + `"`
+	}
+	arguments += ` < NUL >> "` + logPath + `" 2>&1"`
+
+	var schedule string
+	switch frequency {
+	case "daily":
+		schedule = `<ScheduleByDay><DaysInterval>1</DaysInterval></ScheduleByDay>`
+	case "weekly":
+		schedule = `<ScheduleByWeek><WeeksInterval>1</WeeksInterval><DaysOfWeek><Sunday /></DaysOfWeek></ScheduleByWeek>`
+	default:
+		schedule = `<ScheduleByMonth><DaysOfMonth><Day>1</Day></DaysOfMonth><Months><January /><February /><March /><April /><May /><June /><July /><August /><September /><October /><November /><December /></Months></ScheduleByMonth>`
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>checksum-utils %s</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <CalendarTrigger>
+      <StartBoundary>2025-01-01T03:00:00</StartBoundary>
+      <Enabled>true</Enabled>
+      %s
+    </CalendarTrigger>
+  </Triggers>
+  <Settings>
+    <StartWhenAvailable>true</StartWhenAvailable>
+    <DisallowStartIfOnBatteries>true</DisallowStartIfOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <Priority>7</Priority>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>cmd.exe</Command>
+      <Arguments>%s</Arguments>
+    </Exec>
+  </Actions>
+</Task>
+`, xmlEscape(command+" "+strings.Join(paths, " ")), schedule, xmlEscape(arguments))
+}
+
+// xmlEscape escapes the text for an XML element
+func xmlEscape(text string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCronLine(t *testing.T) {
+	line := cronLine("/usr/local/bin/checksum-utils", "check", []string{"/volume1/my photos", "/volume1/100%"}, "monthly", "/var/log/checksum-utils.log")
+
+	expected := `0 3 1 * * mkdir -p '/var/log' && '/usr/local/bin/checksum-utils' check '/volume1/my photos' '/volume1/100\%' < /dev/null >> '/var/log/checksum-utils.log' 2>&1`
+	if line != expected {
+		t.Fatalf("unexpected cron line:\n%s", line)
+	}
+}
+
+func TestSystemdUnits(t *testing.T) {
+	service, timer := systemdUnits("/usr/local/bin/checksum-utils", "create", []string{"/volume1/my photos"}, "weekly", "/var/log/checksum-utils.log", "alice")
+
+	if !strings.Contains(service, `ExecStart="/usr/local/bin/checksum-utils" create "/volume1/my photos"`) {
+		t.Fatalf("unexpected service:\n%s", service)
+	}
+	if !strings.Contains(service, "StandardOutput=append:/var/log/checksum-utils.log") {
+		t.Fatalf("expected the output to be appended to the log file:\n%s", service)
+	}
+	if !strings.Contains(service, "User=alice\n") {
+		t.Fatalf("expected the service to run as the user:\n%s", service)
+	}
+	if !strings.Contains(timer, "OnCalendar=Sun *-*-* 03:00:00") {
+		t.Fatalf("unexpected timer:\n%s", timer)
+	}
+}
+
+func TestSystemdUnits_Specifiers(t *testing.T) {
+	service, _ := systemdUnits("/usr/local/bin/checksum-utils", "check", []string{"/volume1/100%"}, "daily", "/var/log/100%/checksum-utils.log", "")
+
+	for _, expected := range []string{
+		"Description=checksum-utils check /volume1/100%%\n",
+		`ExecStart="/usr/local/bin/checksum-utils" check "/volume1/100%%"`,
+		"StandardOutput=append:/var/log/100%%/checksum-utils.log\n",
+		"StandardError=append:/var/log/100%%/checksum-utils.log\n",
+	} {
+		if !strings.Contains(service, expected) {
+			t.Fatalf("expected %q in the service:\n%s", expected, service)
+		}
+	}
+	if strings.Contains(service, "User=") {
+		t.Fatalf("expected no user when it is not known:\n%s", service)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	for arg, expected := range map[string]string{
+		"/volume1/my photos":  `"/volume1/my photos"`,
+		"/volume1/$HOME/100%": `"/volume1/$$HOME/100%%"`,
+		`/volume1/"quoted"\`:  `"/volume1/\"quoted\"\\"`,
+	} {
+		if quoted := systemdQuote(arg); quoted != expected {
+			t.Fatalf("expected %s to be quoted as %s, got %s", arg, expected, quoted)
+		}
+	}
+}
+
+func TestTaskSchedulerXML(t *testing.T) {
+	task := taskSchedulerXML(`C:\Tools\checksum-utils.exe`, "check", []string{`D:\Photos & Videos`}, "daily", `C:\Logs\checksum-utils.log`)
+
+	if !strings.Contains(task, "<ScheduleByDay>") {
+		t.Fatalf("expected a daily trigger:\n%s", task)
+	}
+	if !strings.Contains(task, `D:\Photos &amp; Videos`) {
+		t.Fatalf("expected the path to be escaped:\n%s", task)
+	}
+}
@@ -0,0 +1,381 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// latestReleaseURL is the GitHub API endpoint of the latest release of checksum-utils
+var latestReleaseURL = "https://api.github.com/repos/JuanOrbegoso/checksum-utils/releases/latest"
+
+// releaseChecksumsAsset is the name of the manifest with the SHA256 checksums of the release executables
+const releaseChecksumsAsset = "checksum-utils_checksums.txt"
+
+// releaseSigningKey is the public key of the GPG key that signs the checksums of the releases, the only key trusted
+// by self-update unless --keyring is given
+//
+//go:embed release-signing-key.asc
+var releaseSigningKey string
+
+var onlyCheckUpdate bool
+var requireSignature bool
+var skipSignature bool
+
+// selfUpdateCmd represents the self-update command
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update checksum-utils to the latest release.",
+	Long: `Download the latest release of checksum-utils from GitHub for this system, verify it against the checksums
+published with the release, after verifying their GPG signature with the release key embedded in checksum-utils,
+and replace the running executable with it.
+
+Example:
+  checksum-utils self-update
+  checksum-utils self-update --check
+  checksum-utils self-update --keyring ~/.gnupg/checksum-utils.kbx
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := fetchLatestRelease()
+		if err != nil {
+			return err
+		}
+
+		comparison, err := compareVersions(release.TagName, version)
+		if err != nil {
+			return fmt.Errorf("the latest release %s cannot be compared with %s: %w", release.TagName, version, err)
+		}
+		if comparison == 0 {
+			fmt.Println("checksum-utils", version, "is up to date")
+			return nil
+		}
+		// A release retracted or republished with an older tag would otherwise downgrade checksum-utils
+		if comparison < 0 {
+			fmt.Println("checksum-utils", version, "is newer than the latest release", release.TagName+", it is not downgraded")
+			return nil
+		}
+
+		fmt.Println("New release available:", version, "→", release.TagName)
+		if onlyCheckUpdate {
+			return nil
+		}
+
+		executablePath, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		executablePath, err = filepath.EvalSymlinks(executablePath)
+		if err != nil {
+			return err
+		}
+
+		if err := updateExecutable(executablePath, release); err != nil {
+			return err
+		}
+
+		fmt.Println("✅ Updated", executablePath, "to", release.TagName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().BoolVar(&onlyCheckUpdate, "check", false, "only check whether a new release is available")
+	selfUpdateCmd.Flags().BoolVar(&requireSignature, "require-signature", false, "fail when the checksums of the release are not signed")
+	selfUpdateCmd.Flags().MarkDeprecated("require-signature", "the signature is always required, use --skip-signature to update without it")
+	selfUpdateCmd.Flags().BoolVar(&skipSignature, "skip-signature", false, "update without verifying the signature of the checksums of the release, trusting only the checksums downloaded with it")
+	selfUpdateCmd.Flags().StringVar(&keyringPath, "keyring", "", "verify the signature with the keys of this keyring file instead of the release key embedded in checksum-utils")
+}
+
+// release is a GitHub release
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a file published with a GitHub release
+type releaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// asset returns the URL of the asset of the release with the name
+func (r release) asset(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.DownloadURL, true
+		}
+	}
+	return "", false
+}
+
+// releaseExecutableAsset returns the name of the release executable for this system, like checksum-utils_linux-amd64
+func releaseExecutableAsset() string {
+	name := fmt.Sprintf("checksum-utils_%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// fetchLatestRelease returns the latest release published on GitHub
+func fetchLatestRelease() (release, error) {
+	request, err := http.NewRequest(http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return release{}, err
+	}
+	request.Header.Set("Accept", "application/vnd.github+json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return release{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("%s: %s", latestReleaseURL, response.Status)
+	}
+
+	var latest release
+	if err := json.NewDecoder(response.Body).Decode(&latest); err != nil {
+		return release{}, err
+	}
+
+	return latest, nil
+}
+
+// downloadToTemporaryFile downloads the URL into a temporary file and returns its path
+func downloadToTemporaryFile(fileURL string) (string, error) {
+	response, err := http.Get(fileURL)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", fileURL, response.Status)
+	}
+
+	temporaryFile, err := os.CreateTemp("", "checksum-utils-*")
+	if err != nil {
+		return "", err
+	}
+	defer temporaryFile.Close()
+
+	if _, err := io.Copy(temporaryFile, response.Body); err != nil {
+		os.Remove(temporaryFile.Name())
+		return "", err
+	}
+
+	return temporaryFile.Name(), temporaryFile.Close()
+}
+
+// updateExecutable downloads the release executable for this system, verifies it against the checksums of the release,
+// verifying first their signature unless --skip-signature is used, and replaces the executable with it
+func updateExecutable(executablePath string, latest release) error {
+	assetName := releaseExecutableAsset()
+	executableURL, ok := latest.asset(assetName)
+	if !ok {
+		return fmt.Errorf("the release %s has no executable for %s/%s", latest.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	checksumsURL, ok := latest.asset(releaseChecksumsAsset)
+	if !ok {
+		return fmt.Errorf("the release %s has no %s, the executable cannot be verified", latest.TagName, releaseChecksumsAsset)
+	}
+
+	// The builds from source have no release key embedded, they cannot verify the release without --keyring
+	if !skipSignature && keyringPath == "" && !hasReleaseSigningKey() {
+		return errors.New("this build of checksum-utils has no release key to verify the signature of the release with, use --keyring with the release key, or --skip-signature to update without verifying it")
+	}
+
+	checksumsPath, err := downloadToTemporaryFile(checksumsURL)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(checksumsPath)
+
+	if skipSignature {
+		fmt.Println("⚠️ The signature of the checksums of the release is not verified, they are trusted as downloaded with it")
+	} else {
+		signatureURL, ok := latest.asset(releaseChecksumsAsset + signatureExtension)
+		if !ok {
+			return fmt.Errorf("the checksums of the release %s are not signed, use --skip-signature to update without verifying them", latest.TagName)
+		}
+		if err := ensureGPG(); err != nil {
+			return err
+		}
+
+		signaturePath, err := downloadToTemporaryFile(signatureURL)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(signaturePath)
+
+		if err := verifyReleaseSignature(signaturePath, checksumsPath); err != nil {
+			return fmt.Errorf("the checksums of the release %s are not trusted: %w", latest.TagName, err)
+		}
+		fmt.Println("🔏 Signature verified:", releaseChecksumsAsset+signatureExtension)
+	}
+
+	content, err := os.ReadFile(checksumsPath)
+	if err != nil {
+		return err
+	}
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", releaseChecksumsAsset, err)
+	}
+
+	var expected *manifestEntry
+	for i := range entries {
+		if entries[i].Path == assetName {
+			expected = &entries[i]
+		}
+	}
+	if expected == nil {
+		return fmt.Errorf("%s is not listed in %s", assetName, releaseChecksumsAsset)
+	}
+
+	newExecutablePath := executablePath + ".new"
+	os.Remove(newExecutablePath)
+	if err := fetchFile(executableURL, newExecutablePath, expected.Checksum, expected.Algorithm); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(newExecutablePath, 0o755); err != nil {
+		os.Remove(newExecutablePath)
+		return err
+	}
+
+	return replaceExecutable(executablePath, newExecutablePath)
+}
+
+// verifyReleaseSignature checks the detached signature of the checksums of the release with the keys of --keyring or,
+// by default, only with the release key embedded in checksum-utils, imported into a temporary GnuPG home so the keys
+// of the user are not trusted
+func verifyReleaseSignature(signaturePath string, checksumsPath string) error {
+	if keyringPath != "" {
+		return verifySignature(signaturePath, checksumsPath, keyringPath)
+	}
+	if !hasReleaseSigningKey() {
+		return errors.New("this build of checksum-utils has no release key to verify the signature with, use --keyring with the release key")
+	}
+
+	homeDirectory, err := os.MkdirTemp("", "checksum-utils-gnupg-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(homeDirectory)
+
+	keyPath := filepath.Join(homeDirectory, "release-signing-key.asc")
+	if err := os.WriteFile(keyPath, []byte(releaseSigningKey), 0o600); err != nil {
+		return err
+	}
+	if err := runGPG("--batch", "--homedir", homeDirectory, "--import", keyPath); err != nil {
+		return err
+	}
+	return runGPG("--batch", "--homedir", homeDirectory, "--verify", signaturePath, checksumsPath)
+}
+
+// hasReleaseSigningKey reports whether the release key was embedded in this build, as the release workflow does
+func hasReleaseSigningKey() bool {
+	return strings.Contains(releaseSigningKey, "BEGIN PGP PUBLIC KEY BLOCK")
+}
+
+// compareVersions compares the semantic versions, like v1.2.3 or v1.3.0-rc.1, returning -1, 0 or +1 when a is older
+// than, the same as or newer than b. A pre-release is older than its release.
+func compareVersions(a string, b string) (int, error) {
+	aNumbers, aPreRelease, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bNumbers, bPreRelease, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range aNumbers {
+		if comparison := cmp.Compare(aNumbers[i], bNumbers[i]); comparison != 0 {
+			return comparison, nil
+		}
+	}
+	switch {
+	case aPreRelease == bPreRelease:
+		return 0, nil
+	case aPreRelease == "":
+		return 1, nil
+	case bPreRelease == "":
+		return -1, nil
+	}
+	return strings.Compare(aPreRelease, bPreRelease), nil
+}
+
+// parseVersion returns the major, minor and patch numbers and the pre-release of the semantic version
+func parseVersion(version string) ([3]int, string, error) {
+	var numbers [3]int
+	withoutBuild, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), "+")
+	core, preRelease, _ := strings.Cut(withoutBuild, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != len(numbers) {
+		return numbers, "", fmt.Errorf("%q is not a semantic version like v1.2.3", version)
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return numbers, "", fmt.Errorf("%q is not a semantic version like v1.2.3", version)
+		}
+		numbers[i] = number
+	}
+	return numbers, preRelease, nil
+}
+
+// replaceExecutable moves the new executable over the running one. Windows does not allow replacing a running
+// executable, but allows renaming it, so it is moved aside first.
+func replaceExecutable(executablePath string, newExecutablePath string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(newExecutablePath, executablePath)
+	}
+
+	oldExecutablePath := executablePath + ".old"
+	if err := os.Remove(oldExecutablePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(executablePath, oldExecutablePath); err != nil {
+		return err
+	}
+	if err := os.Rename(newExecutablePath, executablePath); err != nil {
+		os.Rename(oldExecutablePath, executablePath)
+		return err
+	}
+
+	return nil
+}
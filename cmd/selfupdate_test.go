@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateExecutable(t *testing.T) {
+	newExecutable := []byte("new executable")
+	hash := sha256.Sum256(newExecutable)
+	checksums := fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash[:]), releaseExecutableAsset())
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			json.NewEncoder(w).Encode(release{TagName: "v9.9.9", Assets: []releaseAsset{
+				{Name: releaseExecutableAsset(), DownloadURL: server.URL + "/executable"},
+				{Name: releaseChecksumsAsset, DownloadURL: server.URL + "/checksums"},
+			}})
+		case "/executable":
+			w.Write(newExecutable)
+		case "/checksums":
+			w.Write([]byte(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	latestReleaseURL = server.URL + "/latest"
+	defer func() { latestReleaseURL = "https://api.github.com/repos/JuanOrbegoso/checksum-utils/releases/latest" }()
+
+	latest, err := fetchLatestRelease()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest.TagName != "v9.9.9" {
+		t.Fatalf("unexpected release %s", latest.TagName)
+	}
+
+	executablePath := filepath.Join(t.TempDir(), "checksum-utils")
+	if err := os.WriteFile(executablePath, []byte("old executable"), 0o755); err != nil {
+		t.Fatalf("write executable: %v", err)
+	}
+
+	previousKey := releaseSigningKey
+	defer func() { releaseSigningKey = previousKey }()
+	releaseSigningKey = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	if err := updateExecutable(executablePath, latest); err == nil {
+		t.Fatalf("expected error for a release without signature")
+	}
+
+	// A build without release key refuses to update without --keyring or --skip-signature
+	releaseSigningKey = "# no key"
+	if err := updateExecutable(executablePath, latest); err == nil {
+		t.Fatalf("expected error in a build without release key")
+	}
+	if content, err := os.ReadFile(executablePath); err != nil || string(content) != "old executable" {
+		t.Fatalf("expected the executable to be kept, got %q (%v)", content, err)
+	}
+
+	skipSignature = true
+	defer func() { skipSignature = false }()
+	if err := updateExecutable(executablePath, latest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(executablePath); err != nil || string(content) != string(newExecutable) {
+		t.Fatalf("expected the executable to be replaced, got %q (%v)", content, err)
+	}
+
+	checksums = fmt.Sprintf("%064x  %s\n", 0, releaseExecutableAsset())
+	if err := os.WriteFile(executablePath, []byte("old executable"), 0o755); err != nil {
+		t.Fatalf("write executable: %v", err)
+	}
+	if err := updateExecutable(executablePath, latest); err == nil {
+		t.Fatalf("expected error for an executable that does not match the checksums")
+	}
+	if content, err := os.ReadFile(executablePath); err != nil || string(content) != "old executable" {
+		t.Fatalf("expected the executable to be kept, got %q (%v)", content, err)
+	}
+}
+
+// generateGPGKey creates a key without passphrase in a new GnuPG home and returns the home and the public key
+func generateGPGKey(t *testing.T, name string) (string, string) {
+	homeDirectory, err := os.MkdirTemp("", "gnupg-")
+	if err != nil {
+		t.Fatalf("create GnuPG home: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(homeDirectory) })
+
+	if output, err := exec.Command("gpg", "--batch", "--homedir", homeDirectory, "--passphrase", "", "--quick-gen-key", name+" <"+name+"@example.com>", "ed25519", "sign", "never").CombinedOutput(); err != nil {
+		t.Fatalf("generate key: %v: %s", err, output)
+	}
+	publicKey, err := exec.Command("gpg", "--batch", "--homedir", homeDirectory, "--armor", "--export").Output()
+	if err != nil {
+		t.Fatalf("export key: %v", err)
+	}
+	return homeDirectory, string(publicKey)
+}
+
+func TestVerifyReleaseSignature(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	releaseHome, releaseKey := generateGPGKey(t, "release")
+	otherHome, _ := generateGPGKey(t, "other")
+
+	tempDir := t.TempDir()
+	checksumsPath := filepath.Join(tempDir, releaseChecksumsAsset)
+	if err := os.WriteFile(checksumsPath, []byte("checksums"), 0o600); err != nil {
+		t.Fatalf("write checksums: %v", err)
+	}
+	sign := func(homeDirectory string) string {
+		signaturePath := filepath.Join(tempDir, filepath.Base(homeDirectory)+signatureExtension)
+		if output, err := exec.Command("gpg", "--batch", "--homedir", homeDirectory, "--yes", "--armor", "--detach-sign", "--output", signaturePath, checksumsPath).CombinedOutput(); err != nil {
+			t.Fatalf("sign: %v: %s", err, output)
+		}
+		return signaturePath
+	}
+
+	previousKey := releaseSigningKey
+	defer func() { releaseSigningKey = previousKey }()
+
+	releaseSigningKey = "# no key"
+	if err := verifyReleaseSignature(sign(releaseHome), checksumsPath); err == nil {
+		t.Fatalf("expected an error for a build without release key")
+	}
+
+	releaseSigningKey = releaseKey
+	if err := verifyReleaseSignature(sign(releaseHome), checksumsPath); err != nil {
+		t.Fatalf("expected the signature of the release key to be trusted, got %v", err)
+	}
+	if err := verifyReleaseSignature(sign(otherHome), checksumsPath); err == nil {
+		t.Fatalf("expected the signature of another key not to be trusted")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v0.0.11", "v0.0.11", 0},
+		{"v0.0.12", "v0.0.11", 1},
+		{"v0.0.10", "v0.0.11", -1},
+		{"v0.0.9", "v0.0.11", -1},
+		{"v1.0.0", "v0.9.9", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.1", 1},
+		{"1.0.0+build.1", "v1.0.0", 0},
+	}
+	for _, test := range tests {
+		got, err := compareVersions(test.a, test.b)
+		if err != nil {
+			t.Fatalf("compareVersions(%q, %q): unexpected error: %v", test.a, test.b, err)
+		}
+		if got != test.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+
+	for _, invalid := range []string{"latest", "v1.2", "v1.x.3", "v1.2.-3"} {
+		if _, err := compareVersions(invalid, "v0.0.11"); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
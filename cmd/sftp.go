@@ -0,0 +1,170 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// sftpScheme is the prefix of the paths of files on a remote host reached over SSH
+const sftpScheme = "sftp://"
+
+// sshProgram is the SSH client used to reach the remote hosts, so the user's SSH configuration, keys and agent are used
+const sshProgram = "ssh"
+
+var remoteHashing bool
+
+// sftpLocation is a path on a remote host, parsed from a URL like sftp://user@host:port/path
+type sftpLocation struct {
+	Destination string
+	Port        string
+	Path        string
+}
+
+// parseSFTPURL parses a URL like sftp://user@host:port/path. Paths starting with /~/ are relative to the home directory.
+func parseSFTPURL(rawURL string) (sftpLocation, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return sftpLocation{}, err
+	}
+
+	if parsedURL.Scheme != "sftp" || parsedURL.Hostname() == "" {
+		return sftpLocation{}, fmt.Errorf("%s is not a valid sftp:// URL", rawURL)
+	}
+
+	location := sftpLocation{Destination: parsedURL.Hostname(), Port: parsedURL.Port(), Path: parsedURL.Path}
+	if parsedURL.User != nil {
+		location.Destination = parsedURL.User.Username() + "@" + location.Destination
+	}
+
+	switch {
+	case location.Path == "" || location.Path == "/~":
+		location.Path = "."
+	case strings.HasPrefix(location.Path, "/~/"):
+		location.Path = strings.TrimPrefix(location.Path, "/~/")
+	}
+
+	return location, nil
+}
+
+// URL returns the sftp:// URL of the path on the same host
+func (l sftpLocation) URL(path string) string {
+	host := l.Destination
+	if l.Port != "" {
+		host += ":" + l.Port
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/~/" + path
+	}
+
+	return sftpScheme + host + path
+}
+
+// run runs the command on the remote host, writing its output to stdout
+func (l sftpLocation) run(stdout io.Writer, command ...string) error {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+
+	args := []string{"-o", "BatchMode=yes"}
+	if l.Port != "" {
+		args = append(args, "-p", l.Port)
+	}
+	args = append(args, "--", l.Destination, strings.Join(quoted, " "))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(sshProgram, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// shellQuote quotes the argument for the POSIX shell of the remote host
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// openSFTPBackend returns the backend of the files inside the sftp:// URL
+func openSFTPBackend(rawURL string) (storageBackend, error) {
+	return parseSFTPURL(rawURL)
+}
+
+// List returns the paths of the regular files inside the path, or the path itself when it is a file
+func (l sftpLocation) List() ([]string, error) {
+	var output bytes.Buffer
+	if err := l.run(&output, "find", l.Path, "-type", "f", "-print0"); err != nil {
+		return nil, fmt.Errorf("%s: %w", l.URL(l.Path), err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(output.String(), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Resolve returns the path on the remote host of the file relative to the path
+func (l sftpLocation) Resolve(relativePath string) string {
+	return path.Join(l.Path, relativePath)
+}
+
+// Open streams the content of the file with cat on the remote host
+func (l sftpLocation) Open(file string) (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(l.run(writer, "cat", "--", file))
+	}()
+	return reader, nil
+}
+
+// Write is not supported, the files on the remote hosts are only read
+func (l sftpLocation) Write(file string, content []byte) error {
+	return fmt.Errorf("%s: creating checksum files is not supported for sftp:// paths", l.URL(file))
+}
+
+// HashRemote computes the checksum of the file on the remote host with sha512sum
+func (l sftpLocation) HashRemote(file string) (string, error) {
+	var output bytes.Buffer
+	if err := l.run(&output, "sha512sum", "--", file); err != nil {
+		return "", err
+	}
+	fields := strings.Fields(output.String())
+	if len(fields) == 0 {
+		return "", errors.New("sha512sum returned no checksum")
+	}
+	return strings.TrimPrefix(fields[0], "\\"), nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import "testing"
+
+func TestParseSFTPURL(t *testing.T) {
+	location, err := parseSFTPURL("sftp://backup@nas.local:2222/volume1/photos")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.Destination != "backup@nas.local" || location.Port != "2222" || location.Path != "/volume1/photos" {
+		t.Fatalf("unexpected location: %+v", location)
+	}
+	if url := location.URL("/volume1/photos/a.raw"); url != "sftp://backup@nas.local:2222/volume1/photos/a.raw" {
+		t.Fatalf("unexpected URL: %s", url)
+	}
+
+	location, err = parseSFTPURL("sftp://nas.local/~/documents")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.Destination != "nas.local" || location.Path != "documents" {
+		t.Fatalf("unexpected location: %+v", location)
+	}
+	if url := location.URL("documents/budget.pdf"); url != "sftp://nas.local/~/documents/budget.pdf" {
+		t.Fatalf("unexpected URL: %s", url)
+	}
+
+	if _, err := parseSFTPURL("sftp:///volume1"); err == nil {
+		t.Fatalf("expected error for a URL without host")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if quoted := shellQuote("it's b"); quoted != `'it'\''s b'` {
+		t.Fatalf("unexpected quoted argument: %s", quoted)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// signatureExtension is the extension of the detached signatures created next to the checksum files
+const signatureExtension = ".asc"
+
+// manifestSignatureExtensions are the extensions of the detached signatures usually published next to manifests,
+// like SHA512SUMS.asc, SHA512SUMS.sig, SHA512SUMS.sign or SHA256SUMS.gpg
+var manifestSignatureExtensions = []string{".asc", ".sig", ".sign", ".gpg"}
+
+// gpgProgram is the GnuPG executable used to sign and verify
+var gpgProgram = "gpg"
+
+// ensureGPG returns an error when GnuPG is not installed
+func ensureGPG() error {
+	if _, err := exec.LookPath(gpgProgram); err != nil {
+		return fmt.Errorf("signatures require GnuPG (%s) in the PATH: %w", gpgProgram, err)
+	}
+	return nil
+}
+
+// signFile creates an ASCII armored detached signature of the file in filePath + ".asc",
+// using the default secret key of GnuPG or the given key when it is not empty
+func signFile(filePath string, key string) error {
+	args := []string{"--batch", "--yes", "--armor", "--detach-sign", "--output", filePath + signatureExtension}
+	if key != "" {
+		args = append(args, "--local-user", key)
+	}
+	args = append(args, filePath)
+
+	return runGPG(args...)
+}
+
+// verifySignature checks the detached signature of the file, using the keys of GnuPG
+// or only the keys of the keyring file when it is not empty
+func verifySignature(signaturePath string, filePath string, keyring string) error {
+	if _, err := os.Stat(signaturePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("signature %s not found", signaturePath)
+		}
+		return err
+	}
+
+	args := []string{"--batch"}
+	if keyring != "" {
+		keyringAbsolutePath, err := filepath.Abs(keyring)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--no-default-keyring", "--keyring", keyringAbsolutePath)
+	}
+	args = append(args, "--verify", signaturePath, filePath)
+
+	return runGPG(args...)
+}
+
+// findManifestSignature returns the path of the detached signature published next to the manifest
+func findManifestSignature(manifestPath string) (string, error) {
+	for _, extension := range manifestSignatureExtensions {
+		signaturePath := manifestPath + extension
+		if _, err := os.Stat(signaturePath); err == nil {
+			return signaturePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("no signature found for %s, tried the extensions %s", manifestPath, strings.Join(manifestSignatureExtensions, ", "))
+}
+
+// runGPG runs GnuPG and includes its output in the error when it fails
+func runGPG(args ...string) error {
+	var output bytes.Buffer
+	command := exec.Command(gpgProgram, args...)
+	command.Stdout = &output
+	command.Stderr = &output
+
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(output.String())
+		if message == "" {
+			return fmt.Errorf("gpg: %w", err)
+		}
+		return fmt.Errorf("gpg: %w: %s", err, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"archive/zip"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeGPGSigner logs its arguments in the args file next to it and writes a signature in the --output file
+const fakeGPGSigner = `echo "$@" >> "$(dirname "$0")/args"
+while [ $# -gt 0 ]; do
+	if [ "$1" = --output ]; then echo signature > "$2"; fi
+	shift
+done
+`
+
+// useFakeGPG replaces GnuPG with a shell script for the test and returns the directory of the script
+func useFakeGPG(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gpg program is a shell script")
+	}
+
+	directory := t.TempDir()
+	fakeGPG := filepath.Join(directory, "gpg")
+	if err := os.WriteFile(fakeGPG, []byte("#!/bin/sh\n"+script), 0o700); err != nil {
+		t.Fatalf("write fake gpg: %v", err)
+	}
+	gpgProgram = fakeGPG
+	t.Cleanup(func() { gpgProgram = "gpg" })
+	return directory
+}
+
+func TestSignFile(t *testing.T) {
+	gpgDirectory := useFakeGPG(t, fakeGPGSigner)
+	filePath := filepath.Join(t.TempDir(), "data.txt.sha512")
+	if err := os.WriteFile(filePath, []byte(strings.Repeat("0", 128)), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	if err := signFile(filePath, "admin@nas.local"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filePath + signatureExtension); err != nil {
+		t.Fatalf("expected the signature to be created: %v", err)
+	}
+	args, err := os.ReadFile(filepath.Join(gpgDirectory, "args"))
+	if err != nil {
+		t.Fatalf("read the arguments of gpg: %v", err)
+	}
+	expected := "--batch --yes --armor --detach-sign --output " + filePath + signatureExtension + " --local-user admin@nas.local " + filePath
+	if strings.TrimSpace(string(args)) != expected {
+		t.Fatalf("unexpected arguments of gpg:\n%s", args)
+	}
+}
+
+func TestSignFile_Fails(t *testing.T) {
+	useFakeGPG(t, "echo 'no secret key' >&2\nexit 2\n")
+
+	err := signFile(filepath.Join(t.TempDir(), "data.txt.sha512"), "")
+	if err == nil || !strings.Contains(err.Error(), "no secret key") {
+		t.Fatalf("expected the output of gpg in the error, got %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	gpgDirectory := useFakeGPG(t, fakeGPGSigner)
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "SHA512SUMS")
+	if err := os.WriteFile(filePath, []byte("manifest"), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	if err := verifySignature(filePath+".asc", filePath, ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an error for the missing signature, got %v", err)
+	}
+
+	if err := os.WriteFile(filePath+".asc", []byte("signature"), 0o600); err != nil {
+		t.Fatalf("write signature: %v", err)
+	}
+	if err := verifySignature(filePath+".asc", filePath, "trusted.gpg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args, err := os.ReadFile(filepath.Join(gpgDirectory, "args"))
+	if err != nil {
+		t.Fatalf("read the arguments of gpg: %v", err)
+	}
+	if !strings.Contains(string(args), "--no-default-keyring --keyring ") || !strings.Contains(string(args), "--verify "+filePath+".asc "+filePath) {
+		t.Fatalf("unexpected arguments of gpg:\n%s", args)
+	}
+}
+
+func TestHandleChecksumFileCreation_Sign(t *testing.T) {
+	useFakeGPG(t, fakeGPGSigner)
+	signChecksumFiles = true
+	defer func() { signChecksumFiles = false }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var results []ChecksumFileCreationResult
+	if err := handleChecksumFileCreation(filePath, &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != Created {
+		t.Fatalf("expected status %s, got %+v", Created, results)
+	}
+	if _, err := os.Stat(filePath + checksumFileExtension + signatureExtension); err != nil {
+		t.Fatalf("expected the checksum file to be signed: %v", err)
+	}
+}
+
+func TestCreateManifests_Sign(t *testing.T) {
+	useFakeGPG(t, fakeGPGSigner)
+	signChecksumFiles = true
+	defer func() { signChecksumFiles = false }()
+
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "photos.zip")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	zipWriter := zip.NewWriter(file)
+	writer, err := zipWriter.Create("a.raw")
+	if err != nil {
+		t.Fatalf("create member: %v", err)
+	}
+	writer.Write([]byte("a"))
+	zipWriter.Close()
+	file.Close()
+
+	if result := createArchiveManifest(archivePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	if result := createTagManifestFile(tempDir); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	for _, manifestPath := range []string{archivePath + archiveManifestExtension, filepath.Join(tempDir, tagManifestName)} {
+		if _, err := os.Stat(manifestPath + signatureExtension); err != nil {
+			t.Fatalf("expected %s to be signed: %v", manifestPath, err)
+		}
+	}
+}
+
+func TestCheckListedFiles_VerifySignature(t *testing.T) {
+	tempDir := t.TempDir()
+	var listing strings.Builder
+	for name, data := range map[string]string{"a.jpg": "first", "b.jpg": "second"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(data), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		hash := sha512.Sum512([]byte(data))
+		fmt.Fprintf(&listing, "%s  %s\n", hex.EncodeToString(hash[:]), name)
+	}
+	checksumPath := filepath.Join(tempDir, "photos.sha512")
+	if err := os.WriteFile(checksumPath, []byte(listing.String()), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	if err := os.WriteFile(checksumPath+signatureExtension, []byte("signature"), 0o600); err != nil {
+		t.Fatalf("write signature: %v", err)
+	}
+
+	verifySignatures = true
+	defer func() { verifySignatures = false }()
+
+	// The listed files match, but the checksum file was edited after it was signed
+	useFakeGPG(t, "echo 'BAD signature' >&2\nexit 1\n")
+	var results []ChecksumFileVerificationResult
+	if !checkListedFiles(checksumPath, &results) {
+		t.Fatalf("expected the checksum file to list several files")
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected both listed files to be reported, got %v", results)
+	}
+	for _, result := range results {
+		if result.Status != BadSignature || result.Error == nil {
+			t.Fatalf("expected status %s, got %+v", BadSignature, result)
+		}
+	}
+
+	useFakeGPG(t, "exit 0\n")
+	results = nil
+	checkListedFiles(checksumPath, &results)
+	if len(results) != 2 {
+		t.Fatalf("expected both listed files to be reported, got %v", results)
+	}
+	for _, result := range results {
+		if result.Status != Match {
+			t.Fatalf("expected status %s with a valid signature, got %+v", Match, result)
+		}
+	}
+}
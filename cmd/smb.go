@@ -0,0 +1,204 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// smbScheme is the prefix of the paths of files on SMB/CIFS shares
+const smbScheme = "smb://"
+
+// networkBufferSize is the size of the reads of files on network shares, larger than the default
+// so fewer round trips are needed
+const networkBufferSize = 4 << 20
+
+// readRetries is the number of times a failed read of a file is retried, reopening the file, before giving up:
+// any failed read of a file on a network share, and the transient errors of the other files
+var readRetries = 3
+
+const readRetriesUsage = "number of times a read failing with a transient error, like EIO or a timeout on a network share, is retried before the file is reported as failed"
+
+// readRetryDelay is the delay before the first retry, doubled after each one
+var readRetryDelay = time.Second
+
+const readRetryDelayUsage = "delay before the first retry of a failed read, doubled after each one"
+
+// transientErrnos are the errors of the reads that may succeed when retried
+var transientErrnos = []syscall.Errno{syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.ETIMEDOUT, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EHOSTUNREACH, syscall.ENETDOWN, syscall.ENETUNREACH, syscall.ENETRESET}
+
+// isTransientReadError reports whether the read failed with an error that may not happen again, like a
+// hardware or network hiccup, rather than an error of the file itself
+func isTransientReadError(err error) bool {
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// resolveSMBURL returns the local path of a URL like smb://host/share/path: the UNC path on Windows,
+// or the path inside the mount point of the share elsewhere
+func resolveSMBURL(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	share, rest, _ := strings.Cut(strings.TrimPrefix(parsedURL.Path, "/"), "/")
+	if parsedURL.Scheme != "smb" || parsedURL.Hostname() == "" || share == "" {
+		return "", fmt.Errorf("%s is not a valid smb:// URL, expected smb://host/share/path", rawURL)
+	}
+
+	return smbSharePath(parsedURL.Hostname(), share, rest)
+}
+
+// openDataFile opens the file to compute its checksum. Files on network shares are read with larger reads. The file
+// is reopened to continue from the same offset when a read fails: any read on network shares, and the reads failing
+// with a transient error on the other files.
+func openDataFile(fileAbsolutePath string) (io.ReadCloser, error) {
+	if err := ensureRegularFile(fileAbsolutePath); err != nil {
+		return nil, err
+	}
+
+	retrying := &retryingFile{path: fileAbsolutePath, network: isNetworkPath(fileAbsolutePath)}
+	if err := retrying.retry(retrying.reopen); err != nil {
+		return nil, err
+	}
+
+	if !retrying.network {
+		return retrying, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{bufio.NewReaderSize(retrying, networkBufferSize), retrying}, nil
+}
+
+// retryingFile reads a file reopening it when a read fails, so a network glitch does not fail the whole verification
+type retryingFile struct {
+	path    string
+	file    *os.File
+	reader  io.Reader
+	offset  int64
+	network bool
+}
+
+func (f *retryingFile) Read(p []byte) (int, error) {
+	var n int
+	var readErr error
+	err := f.retry(func() error {
+		if f.file == nil {
+			if err := f.reopen(); err != nil {
+				return err
+			}
+		}
+
+		n, readErr = f.source().Read(p)
+		f.offset += int64(n)
+		if readErr == nil || errors.Is(readErr, io.EOF) || n > 0 {
+			return nil
+		}
+
+		// The file is reopened before the next attempt
+		f.file.Close()
+		f.file = nil
+		return readErr
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, readErr
+}
+
+// retry runs the operation until it succeeds, it fails with an error that is not retried, or the --retries are
+// exhausted, waiting --retry-delay before the first retry and doubling it after each one
+func (f *retryingFile) retry(operation func() error) error {
+	err := operation()
+	delay := readRetryDelay
+	for attempt := 0; attempt < readRetries && f.isRetried(err); attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		if err = operation(); !f.isRetried(err) {
+			return err
+		}
+	}
+	if f.isRetried(err) {
+		return fmt.Errorf("%w (after %d retries)", err, readRetries)
+	}
+	return err
+}
+
+// isRetried reports whether the operation failing with the error is retried: any error but the missing files,
+// the denied permissions and the end of the file on network shares, and the transient errors on the other files
+func (f *retryingFile) isRetried(err error) bool {
+	if err == nil || errors.Is(err, io.EOF) || os.IsPermission(err) || errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	return f.network || isTransientReadError(err)
+}
+
+// source returns the reader of the file: the file itself on network shares, or the --io-engine reading it
+func (f *retryingFile) source() io.Reader {
+	if f.reader == nil {
+		return f.file
+	}
+	return f.reader
+}
+
+// reopen opens the file again at the offset already read
+func (f *retryingFile) reopen() error {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	file, err := openPreservingAccessTime(f.path)
+	if err != nil {
+		return err
+	}
+
+	if f.offset > 0 {
+		if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	f.file = file
+	if !f.network {
+		f.reader = openLocalDataFile(file)
+	}
+	return nil
+}
+
+func (f *retryingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// smbMountLine matches the lines of the output of mount for SMB shares, like "//user@host/share on /Volumes/share (smbfs, ...)"
+var smbMountLine = regexp.MustCompile(`^//(?:[^@/]+@)?([^/]+)/(.+) on (.+) \(smbfs`)
+
+// smbSharePath returns the path of the file inside the mount point of the share, mounted with Finder or mount_smbfs
+func smbSharePath(host string, share string, rest string) (string, error) {
+	output, err := exec.Command("mount").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		matches := smbMountLine.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		mountShare, err := url.PathUnescape(matches[2])
+		if err != nil {
+			mountShare = matches[2]
+		}
+		if strings.EqualFold(matches[1], host) && strings.EqualFold(mountShare, share) {
+			return filepath.Join(matches[3], filepath.FromSlash(rest)), nil
+		}
+	}
+
+	return "", fmt.Errorf("smb://%s/%s is not mounted, mount it first with Finder (Go > Connect to Server)", host, share)
+}
+
+// isNetworkPath reports whether the path is on a SMB, AFP, NFS or WebDAV share
+func isNetworkPath(path string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false
+	}
+
+	var fileSystemType strings.Builder
+	for _, c := range stat.Fstypename {
+		if c == 0 {
+			break
+		}
+		fileSystemType.WriteByte(byte(c))
+	}
+
+	switch fileSystemType.String() {
+	case "smbfs", "afpfs", "nfs", "webdav":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// networkFileSystemTypes are the magic numbers of the SMB/CIFS and NFS file systems
+var networkFileSystemTypes = map[int64]bool{
+	0xFF534D42: true, // CIFS
+	0xFE534D42: true, // SMB2
+	0x517B:     true, // SMB
+	0x6969:     true, // NFS
+}
+
+// smbSharePath returns the path of the file inside the mount point of the share,
+// mounted with mount.cifs or by GVFS (GNOME Files)
+func smbSharePath(host string, share string, rest string) (string, error) {
+	mounts, err := os.Open("/proc/self/mounts")
+	if err == nil {
+		defer mounts.Close()
+
+		scanner := bufio.NewScanner(mounts)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 3 || (fields[2] != "cifs" && fields[2] != "smb3") {
+				continue
+			}
+
+			source := strings.ReplaceAll(fields[0], `\040`, " ")
+			mountHost, mountShare, _ := strings.Cut(strings.TrimPrefix(source, "//"), "/")
+			if strings.EqualFold(mountHost, host) && strings.EqualFold(strings.Trim(mountShare, "/"), share) {
+				return filepath.Join(strings.ReplaceAll(fields[1], `\040`, " "), filepath.FromSlash(rest)), nil
+			}
+		}
+	}
+
+	gvfsMounts, _ := filepath.Glob(fmt.Sprintf("/run/user/%d/gvfs/smb-share:*", os.Getuid()))
+	for _, gvfsMount := range gvfsMounts {
+		name := strings.ToLower(filepath.Base(gvfsMount))
+		if strings.Contains(name, "server="+strings.ToLower(host)+",") && (strings.Contains(name, "share="+strings.ToLower(share)+",") || strings.HasSuffix(name, "share="+strings.ToLower(share))) {
+			return filepath.Join(gvfsMount, filepath.FromSlash(rest)), nil
+		}
+	}
+
+	return "", fmt.Errorf("smb://%s/%s is not mounted, mount it first, like: sudo mount -t cifs //%s/%s /mnt/%s", host, share, host, share, share)
+}
+
+// isNetworkPath reports whether the path is on a SMB/CIFS or NFS share
+func isNetworkPath(path string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false
+	}
+
+	return networkFileSystemTypes[int64(stat.Type)]
+}
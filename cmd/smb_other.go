@@ -0,0 +1,30 @@
+//go:build !windows && !linux && !darwin
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import "fmt"
+
+// smbSharePath is not supported on this system, the share has to be mounted and its local path used instead
+func smbSharePath(host string, share string, rest string) (string, error) {
+	return "", fmt.Errorf("smb:// URLs are not supported on this system, use the path where //%s/%s is mounted", host, share)
+}
+
+// isNetworkPath is not supported on this system
+func isNetworkPath(path string) bool {
+	return false
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestResolveSMBURL_Invalid(t *testing.T) {
+	for _, rawURL := range []string{"smb://nas.local", "smb:///share/file"} {
+		if _, err := resolveSMBURL(rawURL); err == nil {
+			t.Fatalf("expected error for %s", rawURL)
+		}
+	}
+}
+
+func TestRetryingFile(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello world"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	retrying := &retryingFile{path: filePath, file: file}
+	defer retrying.Close()
+
+	buffer := make([]byte, 5)
+	if _, err := io.ReadFull(retrying, buffer); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	if err := retrying.reopen(); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	rest, err := io.ReadAll(retrying)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buffer)+string(rest) != "hello world" {
+		t.Fatalf("expected the reopened file to continue at the same offset, got %q", string(buffer)+string(rest))
+	}
+}
+
+// failingReader fails every read with its error
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestRetryingFile_TransientErrors(t *testing.T) {
+	previousDelay, previousRetries := readRetryDelay, readRetries
+	readRetryDelay, readRetries = time.Millisecond, 2
+	defer func() { readRetryDelay, readRetries = previousDelay, previousRetries }()
+
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	// A local file whose read fails with EIO is reopened and read again
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	retrying := &retryingFile{path: filePath, file: file, reader: failingReader{syscall.EIO}}
+	content, err := io.ReadAll(retrying)
+	retrying.Close()
+	if err != nil || string(content) != "hello world" {
+		t.Fatalf("expected the file read after the transient error, got %q (%v)", content, err)
+	}
+
+	// Other errors of local files are not retried
+	file, err = os.Open(filePath)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	retrying = &retryingFile{path: filePath, file: file, reader: failingReader{errors.New("bad descriptor")}}
+	if _, err := io.ReadAll(retrying); err == nil || err.Error() != "bad descriptor" {
+		t.Fatalf("expected the error without retrying, got %v", err)
+	}
+	retrying.Close()
+
+	// The file is reported as failed once the retries are exhausted
+	attempts := 0
+	err = (&retryingFile{}).retry(func() error {
+		attempts++
+		return syscall.ETIMEDOUT
+	})
+	if attempts != 3 || !errors.Is(err, syscall.ETIMEDOUT) || !strings.Contains(err.Error(), "after 2 retries") {
+		t.Fatalf("expected 3 attempts and the error after 2 retries, got %d attempts and %v", attempts, err)
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// smbSharePath returns the UNC path of the file on the share
+func smbSharePath(host string, share string, rest string) (string, error) {
+	return filepath.Join(`\\`+host+`\`+share+`\`, filepath.FromSlash(rest)), nil
+}
+
+// isNetworkPath reports whether the path is a UNC path, like \\host\share\file or \\?\UNC\host\share\file
+func isNetworkPath(path string) bool {
+	return (strings.HasPrefix(path, `\\`) && !strings.HasPrefix(path, `\\?\`)) || strings.HasPrefix(strings.ToUpper(path), `\\?\UNC\`)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+)
+
+// specialFileError is returned when opening a named pipe, a socket or a device as a data file, since reading them
+// would block forever or never end
+type specialFileError struct {
+	Kind string
+}
+
+func (e *specialFileError) Error() string {
+	return e.Kind + ", not a regular file"
+}
+
+// specialFileKind returns the kind of the file when it is a named pipe, a socket or a device
+func specialFileKind(mode os.FileMode) (string, bool) {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe", true
+	case mode&os.ModeSocket != 0:
+		return "socket", true
+	case mode&os.ModeCharDevice != 0:
+		return "character device", true
+	case mode&os.ModeDevice != 0:
+		return "block device", true
+	}
+	return "", false
+}
+
+// ensureRegularFile returns a specialFileError when the file, or the file its symlink points to, is a named pipe,
+// a socket or a device. Other errors are left to the open of the file.
+func ensureRegularFile(fileAbsolutePath string) error {
+	fileInfo, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return nil
+	}
+	if kind, special := specialFileKind(fileInfo.Mode()); special {
+		return &specialFileError{Kind: kind}
+	}
+	return nil
+}
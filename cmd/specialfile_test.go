@@ -0,0 +1,48 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCreateChecksumFile_NamedPipe(t *testing.T) {
+	fifoPath := filepath.Join(t.TempDir(), "pipe")
+	if err := syscall.Mkfifo(fifoPath, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+
+	result := createChecksumFile(fifoPath)
+	if result.Status != SpecialCreation {
+		t.Fatalf("expected status %s, got %s", SpecialCreation, result.Status)
+	}
+	if _, err := os.Stat(fifoPath + checksumFileExtension); !os.IsNotExist(err) {
+		t.Fatalf("expected no checksum file for a named pipe")
+	}
+
+	if result := checkChecksumFile(fifoPath); result.Status != SpecialVerification {
+		t.Fatalf("expected status %s, got %s", SpecialVerification, result.Status)
+	}
+}
+
+func TestHandleChecksumFileRepair_NamedPipe(t *testing.T) {
+	fifoPath := filepath.Join(t.TempDir(), "pipe")
+	if err := syscall.Mkfifo(fifoPath, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	if err := os.WriteFile(fifoPath+checksumFileExtension, []byte(strings.Repeat("0", 128)), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	var results []ChecksumFileRepairResult
+	if err := handleChecksumFileRepair(fifoPath, &results, func(string) bool { return true }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != SpecialRepair || results[0].Error == nil {
+		t.Fatalf("expected status %s, got %+v", SpecialRepair, results)
+	}
+}
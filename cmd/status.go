@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// The states of a check in the state file
+const (
+	runningCheckState = "running"
+	okCheckState      = "ok"
+	failedCheckState  = "failed"
+	stoppedCheckState = "stopped"
+)
+
+// checkStatePath is the file where check records the state of the last check, read by the status command
+var checkStatePath = defaultCheckStatePath()
+
+// checkStatePathUsage is the usage of the --state-file flag
+const checkStatePathUsage = "file where check records the state of the last check, read by the status command"
+
+// statusMaxAge is set by --max-age in status
+var statusMaxAge pointInTime
+
+// checkState is the state of the last check
+type checkState struct {
+	State           string    `json:"state"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	FilesChecked    int       `json:"files_checked"`
+	FilesMatched    int       `json:"files_matched"`
+	FilesMismatched int       `json:"files_mismatched"`
+	FilesFailed     int       `json:"files_failed"`
+	Errors          int       `json:"errors"`
+}
+
+// defaultCheckStatePath returns last-check.json in the user cache directory
+func defaultCheckStatePath() string {
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "checksum-utils-last-check.json")
+	}
+	return filepath.Join(cacheDirectory, "checksum-utils", "last-check.json")
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the state of the last check.",
+	Long: `Print the state of the last check in a single line, like
+"state=ok started_at=... finished_at=... checked=120 matched=120 mismatched=0 failed=0 errors=0",
+and exit with 0 when it is healthy or 1 when it is not, so it can back a Docker HEALTHCHECK.
+The last check is healthy when it is running or finished without files that do not match or failed,
+and, with --max-age, when it started after that age.
+
+Example:
+  checksum-utils status
+  checksum-utils status --max-age 35d
+  checksum-utils status --state-file /data/last-check.json
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := readCheckState(checkStatePath)
+		if err != nil {
+			fmt.Printf("state=unknown error=%q\n", err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Println(formatCheckState(state))
+		if !isHealthyCheckState(state, statusMaxAge.time) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringVar(&checkStatePath, "state-file", checkStatePath, checkStatePathUsage)
+	statusCmd.Flags().Var(&statusMaxAge, "max-age", "unhealthy when the last check started before this date, like 2024-01-01, or this age, like 35d")
+}
+
+// isHealthyCheckState reports whether the check is running or finished without files that do not match or failed,
+// and started after notBefore when it is set
+func isHealthyCheckState(state checkState, notBefore time.Time) bool {
+	if !notBefore.IsZero() && state.StartedAt.Before(notBefore) {
+		return false
+	}
+	return state.State != failedCheckState
+}
+
+// formatCheckState returns the state of the check in a single line of key=value pairs
+func formatCheckState(state checkState) string {
+	line := fmt.Sprintf("state=%s started_at=%s", state.State, state.StartedAt.Format(time.RFC3339))
+	if !state.FinishedAt.IsZero() {
+		line += " finished_at=" + state.FinishedAt.Format(time.RFC3339)
+	}
+	return line + fmt.Sprintf(" checked=%d matched=%d mismatched=%d failed=%d errors=%d", state.FilesChecked, state.FilesMatched, state.FilesMismatched, state.FilesFailed, state.Errors)
+}
+
+// readCheckState reads the state of the last check
+func readCheckState(path string) (checkState, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkState{}, fmt.Errorf("no check has run yet")
+	}
+	if err != nil {
+		return checkState{}, err
+	}
+
+	var state checkState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return checkState{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// writeCheckState records the state of the check in the file given with --state-file
+func writeCheckState(state checkState) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(checkStatePath), 0o755); err != nil {
+		return err
+	}
+	return replaceFile(checkStatePath, string(content)+"\n")
+}
+
+// recordCheckStarted records that a check is running
+func recordCheckStarted() error {
+	return writeCheckState(checkState{State: runningCheckState, StartedAt: checkStartedAt})
+}
+
+// recordCheckFinished records the results of the finished check
+func recordCheckFinished(results []ChecksumFileVerificationResult, errs []error, stopped bool) error {
+	summary := summarizeCheck(results)
+	state := checkState{
+		State:           okCheckState,
+		StartedAt:       checkStartedAt,
+		FinishedAt:      time.Now(),
+		FilesChecked:    summary.Checked,
+		FilesMatched:    summary.Matched,
+		FilesMismatched: summary.Mismatched,
+		FilesFailed:     summary.Failed,
+		Errors:          len(errs),
+	}
+	if summary.Mismatched > 0 || summary.Failed > 0 || len(errs) > 0 {
+		state.State = failedCheckState
+	} else if stopped {
+		state.State = stoppedCheckState
+	}
+	return writeCheckState(state)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckState(t *testing.T) {
+	previousPath := checkStatePath
+	checkStatePath = filepath.Join(t.TempDir(), "state", "last-check.json")
+	defer func() { checkStatePath = previousPath }()
+
+	if _, err := readCheckState(checkStatePath); err == nil {
+		t.Fatalf("expected an error without a state file")
+	}
+
+	checkStartedAt = time.Now()
+	if err := recordCheckStarted(); err != nil {
+		t.Fatalf("record start: %v", err)
+	}
+	state, err := readCheckState(checkStatePath)
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	if state.State != runningCheckState || !isHealthyCheckState(state, time.Time{}) {
+		t.Fatalf("expected a healthy running check, got %+v", state)
+	}
+
+	results := []ChecksumFileVerificationResult{{Path: "a", Status: Match}, {Path: "b", Status: NotMatch}}
+	if err := recordCheckFinished(results, nil, false); err != nil {
+		t.Fatalf("record finish: %v", err)
+	}
+	state, err = readCheckState(checkStatePath)
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	if state.State != failedCheckState || isHealthyCheckState(state, time.Time{}) {
+		t.Fatalf("expected an unhealthy failed check, got %+v", state)
+	}
+	if line := formatCheckState(state); !strings.HasPrefix(line, "state=failed ") || !strings.HasSuffix(line, " checked=2 matched=1 mismatched=1 failed=0 errors=0") {
+		t.Fatalf("unexpected status line %q", line)
+	}
+}
+
+func TestIsHealthyCheckState_MaxAge(t *testing.T) {
+	state := checkState{State: okCheckState, StartedAt: time.Now().Add(-40 * 24 * time.Hour)}
+
+	if !isHealthyCheckState(state, time.Time{}) {
+		t.Fatalf("expected the check to be healthy without --max-age")
+	}
+	if isHealthyCheckState(state, time.Now().Add(-35*24*time.Hour)) {
+		t.Fatalf("expected a check older than --max-age to be unhealthy")
+	}
+}
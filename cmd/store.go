@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storeDirectory keeps the checksum files in a directory that mirrors the absolute paths of the files,
+// instead of next to them, when it is not empty
+var storeDirectory string
+
+const storeDirectoryUsage = "keep the checksum files in this directory, mirroring the absolute paths of the files, instead of next to them"
+
+// storedChecksumFilePath returns the path of the checksum file of the file in the store directory, like
+// /volume1/.checksums/volume1/photos/wedding.raw.sha512, or /volume1/.checksums/C/photos/wedding.raw.sha512
+// for the files of the C: drive
+func storedChecksumFilePath(fileAbsolutePath string) string {
+	volume := filepath.VolumeName(fileAbsolutePath)
+	mirroredVolume := strings.ReplaceAll(strings.TrimLeft(volume, `\/`), ":", "")
+	return filepath.Join(storeDirectory, mirroredVolume, fileAbsolutePath[len(volume):]) + checksumFileExtension
+}
+
+// adsChecksumFilePath returns the path of the alternate data stream that keeps the checksum of the file, which is
+// read and written as any other checksum file on NTFS
+func adsChecksumFilePath(fileAbsolutePath string) string {
+	return fileAbsolutePath + ":" + adsStreamName + checksumFileExtension
+}
+
+// isStoreDirectory reports whether the directory is the store directory, which the walks skip
+func isStoreDirectory(path string) bool {
+	return storeDirectory != "" && filepath.Clean(path) == storeDirectory
+}
+
+// The storage modes of the checksums, chosen with --store
+const (
+	sidecarStoreMode = "sidecar"
+	xattrStoreMode   = "xattr"
+	adsStoreMode     = "ads"
+)
+
+// storeMode is where the checksums are kept: in checksum files, or in extended attributes of the files
+var storeMode = sidecarStoreMode
+
+const storeModeUsage = "where the checksums are kept: sidecar, in checksum files, xattr, in user.checksum-utils.* extended attributes of the files, or ads, in an alternate data stream of the files on NTFS"
+
+// adsStreamName is the name of the alternate data stream that keeps the checksum with --store ads, followed by the
+// extension of the checksum files, like wedding.raw:checksum.sha512
+const adsStreamName = "checksum"
+
+// The extended attributes of the files that keep their checksum with --store xattr
+const (
+	checksumAttribute  = "user.checksum-utils.sha512"
+	timestampAttribute = "user.checksum-utils.timestamp"
+	sizeAttribute      = "user.checksum-utils.size"
+	modTimeAttribute   = "user.checksum-utils.mtime"
+)
+
+// validateStoreMode checks the --store mode and the flags that cannot be used with it
+func validateStoreMode() error {
+	if hiddenSidecars && (storeMode != sidecarStoreMode || storeDirectory != "") {
+		return fmt.Errorf("--hidden-sidecar can only be used with the checksum files next to the files, not with --store or --store-dir")
+	}
+
+	switch storeMode {
+	case sidecarStoreMode:
+		return nil
+	case xattrStoreMode:
+		if storeDirectory != "" {
+			return fmt.Errorf("--store xattr cannot be used with --store-dir")
+		}
+		if signChecksumFiles || verifySignatures {
+			return fmt.Errorf("--store xattr cannot be used with --sign or --verify-signature, there are no checksum files to sign")
+		}
+		return nil
+	case adsStoreMode:
+		if runtime.GOOS != "windows" {
+			return fmt.Errorf("--store ads is only supported on Windows, use --store xattr instead")
+		}
+		if storeDirectory != "" {
+			return fmt.Errorf("--store ads cannot be used with --store-dir")
+		}
+		return nil
+	default:
+		return fmt.Errorf("--store must be %s, %s or %s, not %q", sidecarStoreMode, xattrStoreMode, adsStoreMode, storeMode)
+	}
+}
+
+// hasStoredChecksum reports whether the checksum of the file is stored, in its checksum file or in its extended attributes
+func hasStoredChecksum(fileAbsolutePath string) (bool, error) {
+	var err error
+	if storeMode == xattrStoreMode {
+		_, err = getXattr(fileAbsolutePath, checksumAttribute)
+	} else {
+		_, err = os.Stat(checksumFilePath(fileAbsolutePath))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// readStoredChecksum reads the checksum of the file, from its checksum file or from its extended attributes
+func readStoredChecksum(fileAbsolutePath string) (checksumFileContent, error) {
+	if storeMode != xattrStoreMode {
+		return readChecksumFileContent(checksumFilePath(fileAbsolutePath))
+	}
+
+	checksum, err := getXattr(fileAbsolutePath, checksumAttribute)
+	if err != nil {
+		return checksumFileContent{}, err
+	}
+	content := checksumFileContent{Checksum: decodeDigest(strings.TrimSpace(string(checksum))), Size: -1}
+	if size, err := getXattr(fileAbsolutePath, sizeAttribute); err == nil {
+		if parsedSize, err := strconv.ParseInt(string(size), 10, 64); err == nil {
+			content.Size = parsedSize
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return checksumFileContent{}, err
+	}
+	if modTime, err := getXattr(fileAbsolutePath, modTimeAttribute); err == nil {
+		if parsedModTime, err := time.Parse(time.RFC3339Nano, string(modTime)); err == nil {
+			content.ModTime = parsedModTime
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return checksumFileContent{}, err
+	}
+	return content, nil
+}
+
+// storedChecksumModTime returns when the checksum of the file was stored: the modification time of its checksum file,
+// or the time recorded in its extended attributes
+func storedChecksumModTime(fileAbsolutePath string) (time.Time, error) {
+	if storeMode != xattrStoreMode {
+		checksumFileInfo, err := os.Stat(checksumFilePath(fileAbsolutePath))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return checksumFileInfo.ModTime(), nil
+	}
+
+	timestamp, err := getXattr(fileAbsolutePath, timestampAttribute)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(timestamp))
+}
+
+// writeChecksumAttributes stores the checksum, the current time and, with --record-size, the size and the modification
+// time in the extended attributes of the file, replacing them when they exist
+func writeChecksumAttributes(fileAbsolutePath string, hexFileChecksum string) error {
+	if recordFileSize {
+		fileInfo, err := os.Stat(fileAbsolutePath)
+		if err != nil {
+			return err
+		}
+		if err := setXattr(fileAbsolutePath, sizeAttribute, []byte(strconv.FormatInt(fileInfo.Size(), 10))); err != nil {
+			return err
+		}
+		if err := setXattr(fileAbsolutePath, modTimeAttribute, []byte(fileInfo.ModTime().UTC().Format(time.RFC3339Nano))); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range []string{sizeAttribute, modTimeAttribute} {
+			if err := removeXattr(fileAbsolutePath, name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	if err := setXattr(fileAbsolutePath, timestampAttribute, []byte(time.Now().UTC().Format(time.RFC3339Nano))); err != nil {
+		return err
+	}
+	return setXattr(fileAbsolutePath, checksumAttribute, []byte(encodeDigest(hexFileChecksum)))
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateAndCheck_StoreDirectory(t *testing.T) {
+	storeDirectory = filepath.Join(t.TempDir(), ".checksums")
+	defer func() { storeDirectory = "" }()
+
+	dataDirectory := t.TempDir()
+	filePath := filepath.Join(dataDirectory, "photos", "wedding.raw")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("raw"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	if _, err := os.Stat(filePath + checksumFileExtension); !os.IsNotExist(err) {
+		t.Fatalf("expected no checksum file next to the file")
+	}
+	storedPath := storedChecksumFilePath(filePath)
+	if !strings.HasPrefix(storedPath, storeDirectory) || !strings.HasSuffix(storedPath, filepath.Join("photos", "wedding.raw"+checksumFileExtension)) {
+		t.Fatalf("expected the checksum file to mirror the path of the file in the store directory, got %s", storedPath)
+	}
+	if _, err := os.Stat(storedPath); err != nil {
+		t.Fatalf("expected the checksum file in the store directory: %v", err)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+}
+
+func TestIsFilteredOut_StoreDirectory(t *testing.T) {
+	root := t.TempDir()
+	storeDirectory = filepath.Join(root, ".checksums")
+	defer func() { storeDirectory = "" }()
+
+	if !isFilteredOut(root, storeDirectory, true) {
+		t.Fatalf("expected the store directory to be skipped by the walks")
+	}
+	if isFilteredOut(root, filepath.Join(root, "photos"), true) {
+		t.Fatalf("expected the other directories to be walked")
+	}
+}
+
+func TestCreateAndCheck_XattrStore(t *testing.T) {
+	storeMode = xattrStoreMode
+	defer func() { storeMode = sidecarStoreMode }()
+
+	filePath := filepath.Join(t.TempDir(), "wedding.raw")
+	if err := os.WriteFile(filePath, []byte("raw"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := setXattr(filePath, timestampAttribute, []byte("probe")); err != nil {
+		t.Skipf("extended attributes are not supported here: %v", err)
+	}
+
+	if result := checkChecksumFile(filePath); result.Status != NotFound {
+		t.Fatalf("expected status %s before creating the checksum, got %s (%v)", NotFound, result.Status, result.Error)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	if _, err := os.Stat(filePath + checksumFileExtension); !os.IsNotExist(err) {
+		t.Fatalf("expected no checksum file next to the file")
+	}
+	if _, err := storedChecksumModTime(filePath); err != nil {
+		t.Fatalf("expected the timestamp attribute: %v", err)
+	}
+	if result := checkChecksumFile(filePath); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+
+	if err := os.WriteFile(filePath, []byte("corrupted"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := checkChecksumFile(filePath); result.Status != NotMatch {
+		t.Fatalf("expected status %s, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+}
+
+func TestValidateStoreMode(t *testing.T) {
+	defer func() { storeMode = sidecarStoreMode; storeDirectory = "" }()
+
+	storeMode = "database"
+	if err := validateStoreMode(); err == nil {
+		t.Fatalf("expected an error for an unknown store mode")
+	}
+
+	storeMode = xattrStoreMode
+	storeDirectory = "/volume1/.checksums"
+	if err := validateStoreMode(); err == nil {
+		t.Fatalf("expected an error using --store xattr with --store-dir")
+	}
+}
+
+func TestChecksumFilePath_AdsStore(t *testing.T) {
+	storeMode = adsStoreMode
+	defer func() { storeMode = sidecarStoreMode }()
+
+	filePath := filepath.Join(t.TempDir(), "wedding.raw")
+	if got, want := checksumFilePath(filePath), filePath+":checksum"+checksumFileExtension; got != want {
+		t.Fatalf("expected the alternate data stream %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// followSymlinks walks into the directories that symlinks point to, instead of skipping them
+var followSymlinks bool
+
+const followSymlinksUsage = "walk into the directories that symlinks point to, each directory once so loops are not followed (default: skip them)"
+
+// visitedDirectories are the directories already walked, identified by their device and inode, so the symlinks
+// that point to a directory walked before, like the ones that make loops, are not followed
+type visitedDirectories struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newVisitedDirectories() *visitedDirectories {
+	return &visitedDirectories{seen: map[string]bool{}}
+}
+
+// visit records the directory, reporting false when it was already visited
+func (v *visitedDirectories) visit(path string, fileInfo os.FileInfo) bool {
+	key := directoryKey(path, fileInfo)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen[key] {
+		return false
+	}
+	v.seen[key] = true
+	return true
+}
+
+// directoryKey identifies the directory by its device and inode, or by its path without symlinks on the systems
+// without inodes
+func directoryKey(path string, fileInfo os.FileInfo) string {
+	if device, ok := fileDevice(fileInfo); ok {
+		return fmt.Sprintf("%d:%d", device, fileInode(fileInfo))
+	}
+	if resolvedPath, err := filepath.EvalSymlinks(path); err == nil {
+		return resolvedPath
+	}
+	return path
+}
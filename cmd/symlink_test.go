@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProcessPaths_FollowSymlinks(t *testing.T) {
+	defer func() { followSymlinks = false }()
+
+	directory := t.TempDir()
+	outside := t.TempDir()
+	for _, filePath := range []string{filepath.Join(directory, "a", "b.txt"), filepath.Join(outside, "c.txt")} {
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(filePath), 0o600); err != nil {
+			t.Fatalf("write %s: %v", filePath, err)
+		}
+	}
+	links := map[string]string{
+		"loop":    directory,
+		"a-alias": filepath.Join(directory, "a"),
+		"outside": outside,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(directory, name)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	expected := map[bool][]string{
+		false: {"a/b.txt"},
+		true:  {"a/b.txt", "outside/c.txt"},
+	}
+	for _, walkers := range []int{1, 4} {
+		for follow, expectedFiles := range expected {
+			followSymlinks = follow
+			jobs = walkers
+			var files []string
+			var errs []error
+			processPaths([]string{directory}, &errs, func(filePath string) error {
+				relativePath, _ := filepath.Rel(directory, filePath)
+				appendLocked(&files, filepath.ToSlash(relativePath))
+				return nil
+			})
+			jobs = 1
+
+			slices.Sort(files)
+			if !slices.Equal(files, expectedFiles) {
+				t.Fatalf("expected %v with --follow-symlinks %v and %d jobs, got %v", expectedFiles, follow, walkers, files)
+			}
+			if len(errs) > 0 {
+				t.Fatalf("expected no errors, got %v", errs)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tagManifestName is the name of the tag manifest, named like the one of BagIt: it lists the checksums of the
+// checksum files of a directory, so the changes to the checksum files themselves can be detected
+const tagManifestName = "tagmanifest-sha512.txt"
+
+// createTagManifest is set by --tag-manifest in create
+var createTagManifest bool
+
+// isTagManifest reports whether the path is a tag manifest
+func isTagManifest(path string) bool {
+	return strings.EqualFold(filepath.Base(path), tagManifestName)
+}
+
+// createTagManifestFile stores the checksums of the checksum files found in the directory and its subdirectories
+// in the tag manifest of the directory, replacing the one it already has, and signs it with --sign
+func createTagManifestFile(directoryAbsolutePath string) ChecksumFileCreationResult {
+	manifestPath := filepath.Join(directoryAbsolutePath, tagManifestName)
+
+	_, err := os.Stat(manifestPath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+
+	var checksumFiles []string
+	err = filepath.WalkDir(directoryAbsolutePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && (hasSuffixFold(path, checksumFileExtension) || hasSuffixFold(path, multiHashExtension)) {
+			checksumFiles = append(checksumFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if os.IsPermission(err) {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: LockedCreation, Error: err}
+		}
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+	sort.Strings(checksumFiles)
+
+	var manifest strings.Builder
+	if writeHeader {
+		manifest.WriteString(metadataHeader(sha512Algorithm.Name))
+	}
+	var hashedBytes int64
+	for _, checksumFile := range checksumFiles {
+		relativePath, err := filepath.Rel(directoryAbsolutePath, checksumFile)
+		if err != nil {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+		}
+		if strings.ContainsAny(relativePath, "\n\r") {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: fmt.Errorf("the name of the checksum file %q is not supported", relativePath)}
+		}
+
+		hexChecksum, size, err := hashChecksumFile(checksumFile)
+		if err != nil {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+		}
+		hashedBytes += size
+
+		manifest.WriteString(hexChecksum + "  " + filepath.ToSlash(relativePath) + "\n")
+	}
+
+	if err := replaceChecksumFile(manifestPath, manifest.String()); err != nil {
+		return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+	}
+	if signChecksumFiles {
+		if err := signFile(manifestPath, signingKey); err != nil {
+			return ChecksumFileCreationResult{Path: manifestPath, Status: Failed, Error: err}
+		}
+	} else if _, err := os.Stat(manifestPath + signatureExtension); exists && err == nil {
+		appendLocked(&errorsCreatingChecksumFiles, fmt.Errorf("the signature of %s is no longer valid, create it again with --sign", manifestPath))
+	}
+
+	status := Created
+	if exists {
+		status = Overwritten
+	}
+	return ChecksumFileCreationResult{Path: manifestPath, Status: status, Error: nil, HashedBytes: hashedBytes}
+}
+
+// hashChecksumFile returns the hexadecimal SHA512 checksum of the checksum file and its size
+func hashChecksumFile(checksumFileAbsolutePath string) (string, int64, error) {
+	file, err := os.Open(checksumFileAbsolutePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	counter := &countingReader{reader: file}
+	hexChecksum, err := hashReader(counter)
+	return hexChecksum, counter.count, err
+}
+
+// checkTagManifest verifies the checksum files listed in the tag manifest when the path is a tag manifest,
+// and reports whether it is
+func checkTagManifest(manifestAbsolutePath string, results *[]ChecksumFileVerificationResult) bool {
+	if !isTagManifest(manifestAbsolutePath) {
+		return false
+	}
+
+	content, err := os.ReadFile(manifestAbsolutePath)
+	if err != nil {
+		appendLocked(results, ChecksumFileVerificationResult{Path: manifestAbsolutePath, Status: CheckingFailed, Error: err})
+		return true
+	}
+
+	entries, err := parseManifest(string(content))
+	if err != nil {
+		appendLocked(results, ChecksumFileVerificationResult{Path: manifestAbsolutePath, Status: Malformed, Error: err})
+		return true
+	}
+
+	for _, entry := range resolveManifestEntries(entries, filepath.Dir(manifestAbsolutePath)) {
+		verificationReporter(entry.Path, results, func(checksumFileAbsolutePath string) ChecksumFileVerificationResult {
+			result := checkExpectedChecksum(checksumFileAbsolutePath, entry.Checksum, entry.Algorithm)
+			if result.Status == NotMatch {
+				result.Error = fmt.Errorf("the checksum file changed after the tag manifest was created")
+			}
+			return result
+		})
+	}
+	return true
+}
+
+// handleTagManifestCreation creates the tag manifest of the path when it is a directory
+func handleTagManifestCreation(path string, results *[]ChecksumFileCreationResult) {
+	directoryAbsolutePath, err := filepath.Abs(path)
+	if err != nil {
+		appendLocked(&errorsCreatingChecksumFiles, err)
+		return
+	}
+	if info, err := os.Stat(directoryAbsolutePath); err != nil || !info.IsDir() {
+		return
+	}
+
+	manifestPath := filepath.Join(directoryAbsolutePath, tagManifestName)
+	reportChecksumFileCreation(manifestPath, results, func(string) ChecksumFileCreationResult {
+		return createTagManifestFile(directoryAbsolutePath)
+	})
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateAndCheck_TagManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "2024", "wedding.raw")
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("raw"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	result := createTagManifestFile(tempDir)
+	if result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	manifestPath := filepath.Join(tempDir, tagManifestName)
+	if !isChecksumFile(manifestPath) {
+		t.Fatalf("expected the tag manifest to be a checksum file")
+	}
+
+	var results []ChecksumFileVerificationResult
+	if !checkTagManifest(manifestPath, &results) {
+		t.Fatalf("expected %s to be checked as a tag manifest", manifestPath)
+	}
+	if len(results) != 1 || results[0].Path != filePath+checksumFileExtension || results[0].Status != Match {
+		t.Fatalf("expected the checksum file to match, got %+v", results)
+	}
+
+	if err := os.WriteFile(filePath+checksumFileExtension, []byte("tampered"), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+	results = nil
+	checkTagManifest(manifestPath, &results)
+	if len(results) != 1 || results[0].Status != NotMatch {
+		t.Fatalf("expected the tampered checksum file not to match, got %+v", results)
+	}
+
+	if result := createTagManifestFile(tempDir); result.Status != Overwritten {
+		t.Fatalf("expected status %s, got %s (%v)", Overwritten, result.Status, result.Error)
+	}
+}
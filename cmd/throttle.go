@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxThroughput is the maximum number of bytes per second read by all the jobs together, 0 for no limit
+var maxThroughput throughput
+
+// maxThroughputUsage is the usage of the --max-throughput flag of the commands that hash files
+const maxThroughputUsage = "maximum speed of the reads of the hashed files, like 80MB/s, so other workloads are not starved (default: no limit)"
+
+// throughput is a number of bytes per second given as a flag, like 80MB/s
+type throughput int64
+
+func (t *throughput) String() string {
+	if *t == 0 {
+		return ""
+	}
+	return formatBytes(int64(*t)) + "/s"
+}
+
+func (t *throughput) Set(value string) error {
+	size, err := parseSize(strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+	if err != nil {
+		return fmt.Errorf("%q is not a valid throughput, use a size per second like 80MB/s", value)
+	}
+	*t = throughput(size)
+	return nil
+}
+
+func (t *throughput) Type() string {
+	return "rate"
+}
+
+var throughputLimiterOnce sync.Once
+var sharedThroughputLimiter *throughputLimiter
+
+// throttle limits the reads of the reader to --max-throughput, shared with the other readers
+func throttle(reader io.Reader) io.Reader {
+	if maxThroughput <= 0 {
+		return reader
+	}
+
+	throughputLimiterOnce.Do(func() {
+		sharedThroughputLimiter = newThroughputLimiter(float64(maxThroughput))
+	})
+	return &throttledReader{reader: reader, limiter: sharedThroughputLimiter}
+}
+
+// throughputLimiter is a token bucket that fills with rate bytes per second, up to one second of reads
+type throughputLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newThroughputLimiter(rate float64) *throughputLimiter {
+	return &throughputLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// wait takes n bytes from the bucket, sleeping while it is in debt. Reads larger than the bucket
+// are allowed and paid afterwards, so they are not blocked forever.
+func (l *throughputLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+
+	if debt < 0 {
+		time.Sleep(time.Duration(-debt / l.rate * float64(time.Second)))
+	}
+}
+
+type throttledReader struct {
+	reader  io.Reader
+	limiter *throughputLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
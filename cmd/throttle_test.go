@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestThrottledReader(t *testing.T) {
+	data := make([]byte, 150_000)
+	reader := &throttledReader{reader: bytes.NewReader(data), limiter: newThroughputLimiter(100_000)}
+
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first second of reads is allowed at once, the rest is paid at 100 KB/s
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the reads to be throttled, took %s", elapsed)
+	}
+}
+
+func TestThroughputSet(t *testing.T) {
+	var value throughput
+	if err := value.Set("80MB/s"); err != nil || value != 80_000_000 {
+		t.Fatalf("expected 80000000, got %d (%v)", value, err)
+	}
+	if err := value.Set("fast"); err == nil {
+		t.Fatalf("expected an error for an invalid throughput")
+	}
+}
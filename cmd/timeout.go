@@ -0,0 +1,80 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// fileTimeout is the time after which the hashing of a file is abandoned, 0 to wait for it forever
+var fileTimeout time.Duration
+
+const fileTimeoutUsage = "abandon a file whose hashing takes longer than this duration, like 30m, reporting it as timed out, for the reads that block forever when a network share drops (default: no limit)"
+
+// fileTimedOutError tells that the hashing of a file was abandoned after --file-timeout
+type fileTimedOutError struct {
+	timeout time.Duration
+}
+
+func (e *fileTimedOutError) Error() string {
+	return fmt.Sprintf("hashing abandoned after %s, the reads of the file stalled or were too slow", formatDuration(e.timeout))
+}
+
+// abandonableReader fails every read once the hashing it belongs to is abandoned, so a read that was blocked
+// and returns after the timeout cannot complete the checksum, nor store it
+type abandonableReader struct {
+	reader    io.Reader
+	abandoned *atomic.Bool
+	timeout   time.Duration
+}
+
+func (r *abandonableReader) Read(p []byte) (int, error) {
+	if r.abandoned.Load() {
+		return 0, &fileTimedOutError{timeout: r.timeout}
+	}
+	return r.reader.Read(p)
+}
+
+// withFileTimeout hashes the file in the background, passing hash a wrap for the reads of its content, and returns
+// the result of timedOut when the hashing takes longer than --file-timeout. The abandoned hashing keeps blocked
+// on its read, but fails as soon as the read returns.
+func withFileTimeout[T any](hash func(wrap func(io.Reader) io.Reader) T, timedOut func(error) T) T {
+	if fileTimeout <= 0 {
+		return hash(nil)
+	}
+
+	abandoned := &atomic.Bool{}
+	wrap := func(reader io.Reader) io.Reader {
+		return &abandonableReader{reader: reader, abandoned: abandoned, timeout: fileTimeout}
+	}
+	done := make(chan T, 1)
+	go func() {
+		done <- hash(wrap)
+	}()
+
+	timer := time.NewTimer(fileTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		abandoned.Store(true)
+		return timedOut(&fileTimedOutError{timeout: fileTimeout})
+	}
+}
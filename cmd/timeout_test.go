@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stalledReader blocks on its first read until released, like a read from a network share that dropped
+type stalledReader struct {
+	reader   io.Reader
+	released chan struct{}
+}
+
+func (r *stalledReader) Read(p []byte) (int, error) {
+	<-r.released
+	return r.reader.Read(p)
+}
+
+func TestWithFileTimeout(t *testing.T) {
+	fileTimeout = 50 * time.Millisecond
+	defer func() { fileTimeout = 0 }()
+
+	filePath := filepath.Join(t.TempDir(), "video.mkv")
+	if err := os.WriteFile(filePath, []byte("frames"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	released := make(chan struct{})
+	finished := make(chan ChecksumFileCreationResult, 1)
+	result := withFileTimeout(func(wrap func(io.Reader) io.Reader) ChecksumFileCreationResult {
+		abandonedResult := createChecksumFileWith(filePath, func(reader io.Reader) io.Reader {
+			return wrap(&stalledReader{reader: reader, released: released})
+		})
+		finished <- abandonedResult
+		return abandonedResult
+	}, func(err error) ChecksumFileCreationResult {
+		return ChecksumFileCreationResult{Path: filePath, Status: TimedOutCreation, Error: err}
+	})
+	var timedOut *fileTimedOutError
+	if result.Status != TimedOutCreation || !errors.As(result.Error, &timedOut) {
+		t.Fatalf("expected status %s, got %s (%v)", TimedOutCreation, result.Status, result.Error)
+	}
+
+	// The abandoned hashing fails once its read returns, without creating the checksum file
+	close(released)
+	if abandonedResult := <-finished; abandonedResult.Status != Failed {
+		t.Fatalf("expected the abandoned hashing to fail, got %s", abandonedResult.Status)
+	}
+	if _, err := os.Stat(checksumFilePath(filePath)); !os.IsNotExist(err) {
+		t.Fatalf("expected no checksum file for the abandoned file, got %v", err)
+	}
+
+	// A file hashed in time is not affected
+	fileTimeout = time.Minute
+	result = withFileTimeout(func(wrap func(io.Reader) io.Reader) ChecksumFileCreationResult {
+		return createChecksumFileWith(filePath, wrap)
+	}, func(err error) ChecksumFileCreationResult {
+		return ChecksumFileCreationResult{Path: filePath, Status: TimedOutCreation, Error: err}
+	})
+	if result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+}
@@ -0,0 +1,448 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// tuiRefreshInterval is the interval between two redraws of the screen, and between two throughput samples
+const tuiRefreshInterval = 250 * time.Millisecond
+
+// tuiSparkline are the bars used to draw the throughput graph, from the lowest to the highest
+var tuiSparkline = []rune("▁▂▃▄▅▆▇█")
+
+// errSkippedByUser is returned by the reads of a file the user skipped
+var errSkippedByUser = errors.New("skipped by the user")
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Check files checksum in a full-screen terminal UI.",
+	Long: `Check the checksum files like the check command, in a full-screen terminal UI that shows the live progress,
+the throughput and the failures.
+Keys: p or space to pause and resume, s to skip the current file, up and down arrows to scroll the failures,
+q to stop checking. The results are printed when it finishes.
+Example:
+  checksum-utils tui ~/documents
+  checksum-utils tui /volume1/photos /volume1/videos
+`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completePaths,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isStdinTTY() || !isStdoutTTY() {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, errors.New("tui requires an interactive terminal, use check instead"))
+			printErrorsCheckingChecksumFiles()
+			os.Exit(1)
+		}
+
+		paths, expandErrors, _ := expandArgs(args)
+		errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, expandErrors...)
+
+		resultsCheckingChecksumFiles = []ChecksumFileVerificationResult{}
+		if len(paths) > 0 {
+			if err := runTUI(paths); err != nil {
+				errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+			}
+		}
+
+		printHeader()
+		printResultsCheckingChecksumFiles(resultsCheckingChecksumFiles)
+		printErrorsCheckingChecksumFiles()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().StringVar(&checksumFileExtension, "suffix", checksumFileExtension, checksumFileExtensionUsage)
+	tuiCmd.Flags().StringVar(&storeDirectory, "store-dir", "", storeDirectoryUsage)
+	tuiCmd.Flags().StringVar(&storeMode, "store", sidecarStoreMode, storeModeUsage)
+	tuiCmd.Flags().BoolVar(&hiddenSidecars, "hidden-sidecar", false, hiddenSidecarsUsage)
+	tuiCmd.Flags().IntVar(&unstableRetries, "unstable-retries", 0, unstableRetriesUsage)
+	tuiCmd.Flags().DurationVar(&fileTimeout, "file-timeout", 0, fileTimeoutUsage)
+}
+
+// tuiState is the state of the verification shared by the checking goroutine, the renderer and the keyboard reader
+type tuiState struct {
+	mu      sync.Mutex
+	resumed *sync.Cond
+
+	paused   bool
+	skip     bool
+	quit     bool
+	finished bool
+
+	current     string
+	currentSize int64
+	currentRead atomic.Int64
+	bytesRead   atomic.Int64
+
+	results  []ChecksumFileVerificationResult
+	failures []ChecksumFileVerificationResult
+	scroll   int
+
+	samples   []float64
+	lastBytes int64
+	start     time.Time
+}
+
+func newTUIState() *tuiState {
+	state := &tuiState{start: time.Now()}
+	state.resumed = sync.NewCond(&state.mu)
+	return state
+}
+
+// begin marks the file as the one being checked
+func (s *tuiState) begin(fileAbsolutePath string) {
+	var size int64
+	if fileInfo, err := os.Stat(fileAbsolutePath); err == nil {
+		size = fileInfo.Size()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = fileAbsolutePath
+	s.currentSize = size
+	s.currentRead.Store(0)
+	s.skip = false
+}
+
+// report verifies the file as the one being checked, in place of reportChecksumFileVerification
+func (s *tuiState) report(fileAbsolutePath string, results *[]ChecksumFileVerificationResult, verify func(string) ChecksumFileVerificationResult) {
+	s.begin(fileAbsolutePath)
+	result := verify(fileAbsolutePath)
+	if errors.Is(result.Error, errSkippedByUser) {
+		result = ChecksumFileVerificationResult{Path: fileAbsolutePath, Status: SkippedVerification}
+	}
+	appendLocked(results, result)
+}
+
+// finish records the result of the file being checked
+func (s *tuiState) finish(result ChecksumFileVerificationResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = ""
+	s.results = append(s.results, result)
+	if result.Status != Match && result.Status != SkippedVerification {
+		s.failures = append(s.failures, result)
+	}
+}
+
+func (s *tuiState) togglePause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = !s.paused
+	s.resumed.Broadcast()
+}
+
+func (s *tuiState) skipCurrent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skip = true
+	s.resumed.Broadcast()
+}
+
+func (s *tuiState) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quit = true
+	s.resumed.Broadcast()
+}
+
+func (s *tuiState) stopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quit
+}
+
+func (s *tuiState) scrollFailures(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scroll = max(0, min(s.scroll+delta, len(s.failures)-1))
+}
+
+// sample records the throughput since the previous sample, keeping the last maxSamples of them
+func (s *tuiState) sample(interval time.Duration, maxSamples int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bytesRead := s.bytesRead.Load()
+	s.samples = append(s.samples, float64(bytesRead-s.lastBytes)/interval.Seconds())
+	s.lastBytes = bytesRead
+	if len(s.samples) > maxSamples {
+		s.samples = s.samples[len(s.samples)-maxSamples:]
+	}
+}
+
+// reader wraps the content of the file being checked, so it can be paused and skipped and its reads counted
+func (s *tuiState) reader(reader io.Reader) io.Reader {
+	return &tuiReader{reader: reader, state: s}
+}
+
+type tuiReader struct {
+	reader io.Reader
+	state  *tuiState
+}
+
+func (r *tuiReader) Read(p []byte) (int, error) {
+	r.state.mu.Lock()
+	for r.state.paused && !r.state.skip && !r.state.quit {
+		r.state.resumed.Wait()
+	}
+	skipped := r.state.skip || r.state.quit
+	r.state.mu.Unlock()
+	if skipped {
+		return 0, errSkippedByUser
+	}
+
+	n, err := r.reader.Read(p)
+	r.state.currentRead.Add(int64(n))
+	r.state.bytesRead.Add(int64(n))
+	return n, err
+}
+
+// runTUI checks the paths while drawing the state of the verification in the alternate screen of the terminal,
+// until every file is checked or the user quits, and leaves the results in resultsCheckingChecksumFiles
+func runTUI(paths []string) error {
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+
+	state := newTUIState()
+
+	// Enter the alternate screen and hide the cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+
+	go readTUIKeys(state)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		checkPathsInTUI(paths, state)
+	}()
+
+	ticker := time.NewTicker(tuiRefreshInterval)
+	defer ticker.Stop()
+	renderTUI(state, paths)
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ticker.C:
+		}
+		width, _ := terminalSize()
+		state.sample(tuiRefreshInterval, width)
+		renderTUI(state, paths)
+	}
+
+	// Show the cursor and leave the alternate screen
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+	if err := term.Restore(int(os.Stdin.Fd()), oldState); err != nil {
+		return err
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	resultsCheckingChecksumFiles = detectMovedFiles(dropListedNotFound(state.results))
+	return nil
+}
+
+// checkPathsInTUI verifies the files like check, reading them through the reader of the state so they can be paused
+// and skipped, and showing their results in the screen instead of printing them
+func checkPathsInTUI(paths []string, state *tuiState) {
+	setCheckedDirectories(paths)
+	verificationReporter = state.report
+	defer func() { verificationReporter = reportChecksumFileVerification }()
+
+	var errs []error
+	processPaths(paths, &errs, func(filePath string) error {
+		if state.stopped() {
+			return filepath.SkipAll
+		}
+
+		var results []ChecksumFileVerificationResult
+		err := handleChecksumFileVerificationWith(filePath, &results, func(fileAbsolutePath string) ChecksumFileVerificationResult {
+			return verifyChecksumFileWith(fileAbsolutePath, state.reader)
+		}, state.reader)
+		for _, result := range results {
+			state.finish(result)
+		}
+		return err
+	})
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.finished = true
+	for _, err := range errs {
+		if !errors.Is(err, filepath.SkipAll) {
+			errorsCheckingChecksumFiles = append(errorsCheckingChecksumFiles, err)
+		}
+	}
+}
+
+// readTUIKeys handles the keys pressed by the user until the verification finishes
+func readTUIKeys(state *tuiState) {
+	buffer := make([]byte, 8)
+	for {
+		n, err := os.Stdin.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		switch key := string(buffer[:n]); key {
+		case "p", "P", " ":
+			state.togglePause()
+		case "s", "S":
+			state.skipCurrent()
+		case "q", "Q", "\x03":
+			state.stop()
+		case "\x1b[A", "k":
+			state.scrollFailures(-1)
+		case "\x1b[B", "j":
+			state.scrollFailures(1)
+		}
+	}
+}
+
+func terminalSize() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+func renderTUI(state *tuiState, paths []string) {
+	width, height := terminalSize()
+
+	state.mu.Lock()
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Checksum-Utils %s - checking %s", version, strings.Join(paths, ", ")))
+
+	status := "Checking"
+	switch {
+	case state.finished:
+		status = "Finished"
+	case state.quit:
+		status = "Stopping"
+	case state.paused:
+		status = "Paused"
+	}
+	lines = append(lines, fmt.Sprintf("Status: %s (%s)", status, formatDuration(time.Since(state.start))))
+
+	if state.current != "" {
+		progress := ""
+		if state.currentSize > 0 {
+			progress = fmt.Sprintf(" (%d%% of %s)", state.currentRead.Load()*100/state.currentSize, formatBytes(state.currentSize))
+		}
+		lines = append(lines, fmt.Sprintf("Current: %s%s", state.current, progress))
+	} else {
+		lines = append(lines, "Current: -")
+	}
+
+	counts := map[ChecksumFileVerificationStatus]int{}
+	for _, result := range state.results {
+		counts[result.Status]++
+	}
+	lines = append(lines, fmt.Sprintf("Checked: %d files | ✅ %d | ⚠️ %d | 👻 %d | ❌ %d | ⏭️ %d",
+		len(state.results), counts[Match], counts[NotMatch], counts[NotFound], counts[CheckingFailed]+counts[LockedVerification]+counts[Malformed]+counts[TimedOutVerification], counts[SkippedVerification]))
+
+	throughput := 0.0
+	if len(state.samples) > 0 {
+		throughput = state.samples[len(state.samples)-1]
+	}
+	lines = append(lines, fmt.Sprintf("Throughput: %s/s (%s read)", formatBytes(int64(throughput)), formatBytes(state.bytesRead.Load())))
+	lines = append(lines, sparkline(state.samples))
+	lines = append(lines, "")
+
+	lines = append(lines, fmt.Sprintf("Failures (%d):", len(state.failures)))
+	// Keep room for the help line at the bottom
+	visible := max(0, height-len(lines)-2)
+	end := min(len(state.failures), state.scroll+visible)
+	for _, failure := range state.failures[min(state.scroll, end):end] {
+		lines = append(lines, fmt.Sprintf("- %s %s", failure.Status, failure.Path))
+	}
+	state.mu.Unlock()
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "[p] pause/resume  [s] skip file  [↑/↓] scroll failures  [q] quit")
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			screen.WriteString("\r\n")
+		}
+		screen.WriteString(truncateLine(line, width))
+		// Clear the rest of the line
+		screen.WriteString("\x1b[K")
+	}
+	fmt.Print(screen.String())
+}
+
+// sparkline draws the samples as bars relative to the highest of them
+func sparkline(samples []float64) string {
+	highest := 0.0
+	for _, sample := range samples {
+		highest = max(highest, sample)
+	}
+
+	var builder strings.Builder
+	for _, sample := range samples {
+		index := 0
+		if highest > 0 {
+			index = int(sample / highest * float64(len(tuiSparkline)-1))
+		}
+		builder.WriteRune(tuiSparkline[index])
+	}
+	return builder.String()
+}
+
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return line
+	}
+	return string(runes[:width])
+}
+
+// formatBytes formats the number of bytes with binary units, like 1.5 GiB
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	divisor, exponent := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		divisor *= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(divisor), "KMGTPE"[exponent])
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTUIReader_Skip(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "data.txt")
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s", Created, result.Status)
+	}
+
+	state := newTUIState()
+	state.begin(filePath)
+	if result := checkChecksumFileWith(filePath, state.reader); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+	if read := state.bytesRead.Load(); read != 5 {
+		t.Fatalf("expected 5 bytes read, got %d", read)
+	}
+
+	state.paused = true
+	state.skipCurrent()
+	result := checkChecksumFileWith(filePath, state.reader)
+	if result.Status != CheckingFailed || result.Error != errSkippedByUser {
+		t.Fatalf("expected the paused file to be skipped, got %s (%v)", result.Status, result.Error)
+	}
+}
+
+func TestCheckPathsInTUI_ListedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	checksums := map[string]string{}
+	for name, data := range map[string]string{"a.jpg": "first", "b.jpg": "second"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(data), 0o600); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+		hash := sha512.Sum512([]byte(data))
+		checksums[name] = hex.EncodeToString(hash[:])
+	}
+	listing := fmt.Sprintf("%s  a.jpg\n%s  b.jpg\n", checksums["a.jpg"], strings.Repeat("0", 128))
+	if err := os.WriteFile(filepath.Join(tempDir, "photos.sha512"), []byte(listing), 0o600); err != nil {
+		t.Fatalf("write checksum file: %v", err)
+	}
+
+	// The files listed in the checksum file are verified like check does
+	state := newTUIState()
+	checkPathsInTUI([]string{tempDir}, state)
+	statuses := map[string]ChecksumFileVerificationStatus{}
+	for _, result := range dropListedNotFound(state.results) {
+		statuses[filepath.Base(result.Path)] = result.Status
+	}
+	if len(statuses) != 2 || statuses["a.jpg"] != Match || statuses["b.jpg"] != NotMatch {
+		t.Fatalf("expected a.jpg to match and b.jpg not to match, got %v", state.results)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 50, 100}); got != "▁▄█" {
+		t.Fatalf("expected ▁▄█, got %s", got)
+	}
+	if got := sparkline([]float64{0, 0}); got != strings.Repeat("▁", 2) {
+		t.Fatalf("expected ▁▁, got %s", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		1536:            "1.5 KiB",
+		3 * 1024 * 1024: "3.0 MiB",
+	}
+	for bytes, expected := range tests {
+		if got := formatBytes(bytes); got != expected {
+			t.Fatalf("expected %s for %d bytes, got %s", expected, bytes, got)
+		}
+	}
+}
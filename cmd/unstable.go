@@ -0,0 +1,57 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// unstableRetries is the number of times a file modified while it was hashed is hashed again before it is reported
+// as unstable
+var unstableRetries int
+
+const unstableRetriesUsage = "number of times a file modified while it was hashed, like a file being written, is hashed again after --retry-delay before it is reported as unstable"
+
+// modifiedWhileHashing returns an error telling how the file changed since before, its information taken before
+// hashing it, or nil when it has the same size and modification time
+func modifiedWhileHashing(fileAbsolutePath string, before os.FileInfo) error {
+	after, err := os.Stat(fileAbsolutePath)
+	if err != nil {
+		return fmt.Errorf("modified while it was hashed: %w", err)
+	}
+	if after.Size() != before.Size() {
+		return fmt.Errorf("modified while it was hashed, the size changed from %d to %d bytes", before.Size(), after.Size())
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		return fmt.Errorf("modified while it was hashed, the modification time changed from %s to %s", before.ModTime().Format(time.RFC3339), after.ModTime().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// retryUnstable hashes the file again while it is unstable, up to --unstable-retries times, waiting --retry-delay
+// before the first retry and doubling it after each one
+func retryUnstable[T any](hash func() T, isUnstable func(T) bool) T {
+	result := hash()
+	delay := readRetryDelay
+	for attempt := 0; attempt < unstableRetries && isUnstable(result); attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+		result = hash()
+	}
+	return result
+}
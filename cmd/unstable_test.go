@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// appendingReader appends to the file on its first read, like a file being written while it is hashed
+type appendingReader struct {
+	reader   io.Reader
+	path     string
+	appended bool
+}
+
+func (r *appendingReader) Read(p []byte) (int, error) {
+	if !r.appended {
+		r.appended = true
+		file, err := os.OpenFile(r.path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return 0, err
+		}
+		file.WriteString(" and more")
+		file.Close()
+	}
+	return r.reader.Read(p)
+}
+
+func TestCheckChecksumFile_Unstable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "recording.wav")
+	if err := os.WriteFile(filePath, []byte("first take"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+
+	result := checkChecksumFileWith(filePath, func(reader io.Reader) io.Reader {
+		return &appendingReader{reader: reader, path: filePath}
+	})
+	if result.Status != UnstableVerification || result.Error == nil {
+		t.Fatalf("expected status %s, got %s (%v)", UnstableVerification, result.Status, result.Error)
+	}
+}
+
+func TestModifiedWhileHashing(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	before, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("stat file: %v", err)
+	}
+
+	if err := modifiedWhileHashing(filePath, before); err != nil {
+		t.Fatalf("expected the unmodified file to be stable, got %v", err)
+	}
+
+	// The same size with another modification time, like a file rewritten in place
+	if err := os.Chtimes(filePath, time.Now(), before.ModTime().Add(time.Minute)); err != nil {
+		t.Fatalf("change times: %v", err)
+	}
+	if err := modifiedWhileHashing(filePath, before); err == nil {
+		t.Fatalf("expected the file with another modification time to be unstable")
+	}
+}
+
+func TestRetryUnstable(t *testing.T) {
+	previousDelay := readRetryDelay
+	readRetryDelay, unstableRetries = time.Millisecond, 2
+	defer func() { readRetryDelay, unstableRetries = previousDelay, 0 }()
+
+	attempts := 0
+	result := retryUnstable(func() ChecksumFileCreationResult {
+		attempts++
+		if attempts < 2 {
+			return ChecksumFileCreationResult{Status: UnstableCreation}
+		}
+		return ChecksumFileCreationResult{Status: Created}
+	}, func(result ChecksumFileCreationResult) bool {
+		return result.Status == UnstableCreation
+	})
+	if result.Status != Created || attempts != 2 {
+		t.Fatalf("expected the file created on the second attempt, got %s after %d attempts", result.Status, attempts)
+	}
+
+	attempts = 0
+	retryUnstable(func() ChecksumFileCreationResult {
+		attempts++
+		return ChecksumFileCreationResult{Status: UnstableCreation}
+	}, func(result ChecksumFileCreationResult) bool {
+		return result.Status == UnstableCreation
+	})
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestHandleChecksumFileRepair_Unstable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "recording.wav")
+	if err := os.WriteFile(filePath, []byte("first take"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if result := createChecksumFile(filePath); result.Status != Created {
+		t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+	}
+	checksumBefore, err := os.ReadFile(filePath + checksumFileExtension)
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+
+	var results []ChecksumFileRepairResult
+	err = handleChecksumFileRepairWith(filePath, &results, func(string) bool { return true }, func(reader io.Reader) io.Reader {
+		return &appendingReader{reader: reader, path: filePath}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != UnstableRepair || results[0].Error == nil {
+		t.Fatalf("expected status %s, got %+v", UnstableRepair, results)
+	}
+
+	// The checksum file of a file being written is not regenerated
+	checksumAfter, err := os.ReadFile(filePath + checksumFileExtension)
+	if err != nil {
+		t.Fatalf("read checksum file: %v", err)
+	}
+	if string(checksumAfter) != string(checksumBefore) {
+		t.Fatalf("expected the checksum file to be kept")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// virusTotalLookup is set by --vt-lookup in hash
+var virusTotalLookup bool
+
+// virusTotalRequestsPerMinute is set by --vt-requests-per-minute, 4 for the public API
+var virusTotalRequestsPerMinute = 4
+
+// virusTotalAPIURL is the URL of the API of VirusTotal
+var virusTotalAPIURL = "https://www.virustotal.com/api/v3"
+
+// virusTotalAlgorithm is the algorithm of the checksums looked up in VirusTotal
+var virusTotalAlgorithm = hashAlgorithm{Name: "sha256", New: sha256.New}
+
+// virusTotalReport is the result of the analysis of a file by the antivirus engines of VirusTotal
+type virusTotalReport struct {
+	Found      bool
+	Malicious  int
+	Suspicious int
+	Engines    int
+}
+
+// String returns the report as the tag printed after the checksum of the file
+func (r virusTotalReport) String() string {
+	if !r.Found {
+		return "[vt: unknown]"
+	}
+	if r.Suspicious > 0 {
+		return fmt.Sprintf("[vt: %d/%d malicious, %d suspicious]", r.Malicious, r.Engines, r.Suspicious)
+	}
+	return fmt.Sprintf("[vt: %d/%d malicious]", r.Malicious, r.Engines)
+}
+
+// virusTotalClient looks up checksums in VirusTotal, spacing the requests to stay within the quota of the API key
+type virusTotalClient struct {
+	apiKey      string
+	interval    time.Duration
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// newVirusTotalClient returns a client with the API key of $VT_API_KEY
+func newVirusTotalClient() (*virusTotalClient, error) {
+	apiKey := os.Getenv("VT_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("--vt-lookup requires the API key of VirusTotal in VT_API_KEY")
+	}
+	if virusTotalRequestsPerMinute <= 0 {
+		return nil, errors.New("--vt-requests-per-minute has to be greater than 0")
+	}
+	return &virusTotalClient{apiKey: apiKey, interval: time.Minute / time.Duration(virusTotalRequestsPerMinute)}, nil
+}
+
+// lookup returns the report of the file with the hexadecimal SHA256 checksum. Only the checksum is sent,
+// never the content of the file.
+func (c *virusTotalClient) lookup(hexSHA256 string) (virusTotalReport, error) {
+	c.wait()
+
+	request, err := http.NewRequest(http.MethodGet, virusTotalAPIURL+"/files/"+hexSHA256, nil)
+	if err != nil {
+		return virusTotalReport{}, err
+	}
+	request.Header.Set("x-apikey", c.apiKey)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return virusTotalReport{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return virusTotalReport{Found: false}, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return virusTotalReport{}, fmt.Errorf("VirusTotal: %s", response.Status)
+	}
+
+	var file struct {
+		Data struct {
+			Attributes struct {
+				LastAnalysisStats map[string]int `json:"last_analysis_stats"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&file); err != nil {
+		return virusTotalReport{}, fmt.Errorf("VirusTotal: %w", err)
+	}
+
+	stats := file.Data.Attributes.LastAnalysisStats
+	report := virusTotalReport{Found: true, Malicious: stats["malicious"], Suspicious: stats["suspicious"]}
+	for _, count := range stats {
+		report.Engines += count
+	}
+	return report, nil
+}
+
+// wait spaces the requests by the interval of the client
+func (c *virusTotalClient) wait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if next := c.lastRequest.Add(c.interval); time.Now().Before(next) {
+		time.Sleep(time.Until(next))
+	}
+	c.lastRequest = time.Now()
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVirusTotalLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("x-apikey") != "secret" {
+			t.Errorf("unexpected request %s with the key %q", r.Method, r.Header.Get("x-apikey"))
+		}
+		switch r.URL.Path {
+		case "/files/bad":
+			w.Write([]byte(`{"data":{"attributes":{"last_analysis_stats":{"malicious":5,"suspicious":1,"undetected":60,"harmless":0}}}}`))
+		case "/files/clean":
+			w.Write([]byte(`{"data":{"attributes":{"last_analysis_stats":{"malicious":0,"suspicious":0,"undetected":66,"harmless":0}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	previousURL := virusTotalAPIURL
+	virusTotalAPIURL = server.URL
+	defer func() { virusTotalAPIURL = previousURL }()
+	t.Setenv("VT_API_KEY", "secret")
+	virusTotalRequestsPerMinute = 6000
+	defer func() { virusTotalRequestsPerMinute = 4 }()
+
+	client, err := newVirusTotalClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	for checksum, expected := range map[string]string{
+		"bad":     "[vt: 5/66 malicious, 1 suspicious]",
+		"clean":   "[vt: 0/66 malicious]",
+		"missing": "[vt: unknown]",
+	} {
+		report, err := client.lookup(checksum)
+		if err != nil {
+			t.Fatalf("%s: %v", checksum, err)
+		}
+		if report.String() != expected {
+			t.Errorf("%s: expected %q, got %q", checksum, expected, report.String())
+		}
+	}
+}
+
+func TestNewVirusTotalClient_RequiresAPIKey(t *testing.T) {
+	t.Setenv("VT_API_KEY", "")
+	if _, err := newVirusTotalClient(); err == nil {
+		t.Fatalf("expected an error without VT_API_KEY")
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// webdavScheme and webdavSecureScheme are the prefixes of the paths of files on WebDAV servers, over HTTP and HTTPS
+const webdavScheme = "dav://"
+const webdavSecureScheme = "davs://"
+
+// webdavPropfindBody asks only for the properties needed to tell the files from the directories
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`
+
+// webdavClient is a minimal WebDAV client, enough to list, download and upload files
+type webdavClient struct {
+	BaseURL    *url.URL
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+// isWebDAVURL reports whether the argument is a dav:// or davs:// URL
+func isWebDAVURL(arg string) bool {
+	return strings.HasPrefix(arg, webdavScheme) || strings.HasPrefix(arg, webdavSecureScheme)
+}
+
+// parseWebDAVURL returns a client for the server of a URL like davs://user@host/remote.php/dav/files/user/photos
+// and the path in it. The password is read from the URL or from WEBDAV_PASSWORD, and the user from WEBDAV_USER
+// when the URL does not have one.
+func parseWebDAVURL(rawURL string) (*webdavClient, string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if parsedURL.Host == "" {
+		return nil, "", fmt.Errorf("%s is not a valid WebDAV URL", rawURL)
+	}
+
+	client := &webdavClient{
+		BaseURL:    &url.URL{Scheme: "https", Host: parsedURL.Host},
+		Username:   os.Getenv("WEBDAV_USER"),
+		Password:   os.Getenv("WEBDAV_PASSWORD"),
+		HTTPClient: http.DefaultClient,
+	}
+	if parsedURL.Scheme == "dav" {
+		client.BaseURL.Scheme = "http"
+	}
+	if parsedURL.User != nil {
+		client.Username = parsedURL.User.Username()
+		if password, ok := parsedURL.User.Password(); ok {
+			client.Password = password
+		}
+	}
+
+	remotePath := parsedURL.Path
+	if remotePath == "" {
+		remotePath = "/"
+	}
+
+	return client, remotePath, nil
+}
+
+// URL returns the dav:// or davs:// URL of the path on the same server
+func (c *webdavClient) URL(remotePath string) string {
+	scheme := webdavSecureScheme
+	if c.BaseURL.Scheme == "http" {
+		scheme = webdavScheme
+	}
+	return scheme + c.BaseURL.Host + remotePath
+}
+
+// do sends the request and returns the response when its status is successful
+func (c *webdavClient) do(method string, remotePath string, headers map[string]string, body []byte) (*http.Response, error) {
+	requestURL := *c.BaseURL
+	requestURL.Path = remotePath
+
+	request, err := http.NewRequest(method, requestURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	if c.Username != "" || c.Password != "" {
+		request.SetBasicAuth(c.Username, c.Password)
+	}
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, fmt.Errorf("%s: %s", c.URL(remotePath), response.Status)
+	}
+
+	return response, nil
+}
+
+// ListFiles returns the paths of the files inside the path, or the path itself when it is a file
+func (c *webdavClient) ListFiles(remotePath string) ([]string, error) {
+	var files []string
+	pending := []string{remotePath}
+
+	for len(pending) > 0 {
+		directory := pending[0]
+		pending = pending[1:]
+
+		response, err := c.do("PROPFIND", directory, map[string]string{"Depth": "1", "Content-Type": "application/xml"}, []byte(webdavPropfindBody))
+		if err != nil {
+			return nil, err
+		}
+
+		var multistatus struct {
+			Responses []struct {
+				Href     string `xml:"href"`
+				Propstat []struct {
+					Collection *struct{} `xml:"prop>resourcetype>collection"`
+				} `xml:"propstat"`
+			} `xml:"response"`
+		}
+		err = xml.NewDecoder(response.Body).Decode(&multistatus)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.URL(directory), err)
+		}
+
+		for _, entry := range multistatus.Responses {
+			href, err := url.Parse(entry.Href)
+			if err != nil {
+				return nil, err
+			}
+			entryPath := href.Path
+
+			isCollection := false
+			for _, propstat := range entry.Propstat {
+				if propstat.Collection != nil {
+					isCollection = true
+				}
+			}
+
+			if strings.TrimSuffix(entryPath, "/") == strings.TrimSuffix(directory, "/") {
+				if !isCollection {
+					files = append(files, entryPath)
+				}
+				continue
+			}
+
+			if isCollection {
+				pending = append(pending, entryPath)
+				continue
+			}
+			files = append(files, entryPath)
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Get returns the content of the file
+func (c *webdavClient) Get(remotePath string) (io.ReadCloser, error) {
+	response, err := c.do(http.MethodGet, remotePath, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// Put stores the content in the file
+func (c *webdavClient) Put(remotePath string, content []byte) error {
+	response, err := c.do(http.MethodPut, remotePath, nil, content)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// webdavBackend is the files inside a path of a WebDAV server
+type webdavBackend struct {
+	*webdavClient
+	Root string
+}
+
+// openWebDAVBackend returns the backend of the files inside the WebDAV URL
+func openWebDAVBackend(rawURL string) (storageBackend, error) {
+	c, remotePath, err := parseWebDAVURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return webdavBackend{webdavClient: c, Root: remotePath}, nil
+}
+
+// List returns the paths of the files inside the path, or the path itself when it is a file
+func (b webdavBackend) List() ([]string, error) {
+	return b.ListFiles(b.Root)
+}
+
+func (b webdavBackend) Resolve(relativePath string) string {
+	return path.Join(b.Root, relativePath)
+}
+
+func (b webdavBackend) Open(remotePath string) (io.ReadCloser, error) {
+	return b.Get(remotePath)
+}
+
+func (b webdavBackend) Write(remotePath string, content []byte) error {
+	return b.Put(remotePath, content)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWebDAVCreateAndCheck(t *testing.T) {
+	var mutex sync.Mutex
+	files := map[string]string{
+		"/dav/photos/a.raw":        "a",
+		"/dav/photos/2024/b c.raw": "b",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if user, password, ok := r.BasicAuth(); !ok || user != "juan" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case "PROPFIND":
+			directory := strings.TrimSuffix(r.URL.Path, "/") + "/"
+			children := map[string]bool{}
+			for name := range files {
+				if !strings.HasPrefix(name, directory) {
+					continue
+				}
+				child, _, isDirectory := strings.Cut(strings.TrimPrefix(name, directory), "/")
+				if isDirectory {
+					child += "/"
+				}
+				children[directory+child] = true
+			}
+
+			names := []string{directory}
+			for child := range children {
+				names = append(names, child)
+			}
+			sort.Strings(names)
+
+			w.WriteHeader(http.StatusMultiStatus)
+			io.WriteString(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+			for _, name := range names {
+				resourceType := ""
+				if strings.HasSuffix(name, "/") {
+					resourceType = "<d:collection/>"
+				}
+				href := (&url.URL{Path: name}).EscapedPath()
+				fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype>%s</d:resourcetype></d:prop></d:propstat></d:response>`, href, resourceType)
+			}
+			io.WriteString(w, `</d:multistatus>`)
+		case http.MethodGet:
+			content, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, content)
+		case http.MethodPut:
+			content, _ := io.ReadAll(r.Body)
+			files[r.URL.Path] = string(content)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	rawURL := strings.Replace(server.URL, "http://", "dav://juan:secret@", 1) + "/dav/photos"
+
+	backend, err := openWebDAVBackend(rawURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	paths, existing, err := listBackendFiles(backend)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/dav/photos/2024/b c.raw" || paths[1] != "/dav/photos/a.raw" {
+		t.Fatalf("unexpected files: %v", paths)
+	}
+
+	for _, path := range paths {
+		if result := createBackendChecksumFile(backend, path, existing); result.Status != Created {
+			t.Fatalf("expected status %s, got %s (%v)", Created, result.Status, result.Error)
+		}
+	}
+
+	_, existing, err = listBackendFiles(backend)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result := checkBackendChecksumFile(backend, "/dav/photos/2024/b c.raw", existing); result.Status != Match {
+		t.Fatalf("expected status %s, got %s (%v)", Match, result.Status, result.Error)
+	}
+
+	mutex.Lock()
+	files["/dav/photos/a.raw"] = "corrupted"
+	mutex.Unlock()
+	if result := checkBackendChecksumFile(backend, "/dav/photos/a.raw", existing); result.Status != NotMatch {
+		t.Fatalf("expected status %s, got %s (%v)", NotMatch, result.Status, result.Error)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !linux && !darwin
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// errXattrUnsupported is returned by the extended attribute functions on the systems without them
+var errXattrUnsupported = errors.New("the extended attributes used by --store xattr are only supported on Linux and macOS")
+
+func getXattr(path string, name string) ([]byte, error) {
+	return nil, &os.PathError{Op: "getxattr", Path: path, Err: errXattrUnsupported}
+}
+
+func setXattr(path string, name string, value []byte) error {
+	return &os.PathError{Op: "setxattr", Path: path, Err: errXattrUnsupported}
+}
+
+func removeXattr(path string, name string) error {
+	return &os.PathError{Op: "removexattr", Path: path, Err: errXattrUnsupported}
+}
@@ -0,0 +1,67 @@
+//go:build linux || darwin
+
+/*
+Copyright © 2025 Juan Orbegoso
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// getXattr returns the value of the extended attribute of the file, or an error wrapping os.ErrNotExist when the
+// file does not have it
+func getXattr(path string, name string) ([]byte, error) {
+	for {
+		size, err := unix.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, xattrError("getxattr", path, err)
+		}
+		value := make([]byte, size)
+		n, err := unix.Getxattr(path, name, value)
+		// The attribute grew between both calls
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, xattrError("getxattr", path, err)
+		}
+		return value[:n], nil
+	}
+}
+
+// setXattr creates or replaces the extended attribute of the file
+func setXattr(path string, name string, value []byte) error {
+	return xattrError("setxattr", path, unix.Setxattr(path, name, value, 0))
+}
+
+// removeXattr removes the extended attribute of the file
+func removeXattr(path string, name string) error {
+	return xattrError("removexattr", path, unix.Removexattr(path, name))
+}
+
+// xattrError wraps the error of the extended attribute operation, as os.ErrNotExist when the attribute is missing
+func xattrError(operation string, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, errNoAttribute) {
+		err = os.ErrNotExist
+	}
+	return &os.PathError{Op: operation, Path: path, Err: err}
+}